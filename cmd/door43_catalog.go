@@ -0,0 +1,546 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/door43metadata"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	// CmdDoor43Catalog represents the available door43-catalog sub-command.
+	CmdDoor43Catalog = cli.Command{
+		Name:        "door43-catalog",
+		Usage:       "Export and import offline snapshots of the catalog",
+		Description: "A snapshot is a static copy of the v5 catalog for some owners, languages and stage, for use by an instance without network access",
+		Subcommands: []cli.Command{
+			subcmdDoor43CatalogExport,
+			subcmdDoor43CatalogImport,
+		},
+	}
+
+	subcmdDoor43CatalogExport = cli.Command{
+		Name:        "export",
+		Usage:       "Export a snapshot of the catalog",
+		Description: "Writes the v5 catalog entries matching the filters, their metadata and optionally their zipballs, with a checksums file, to a directory or a .tar.gz file",
+		Action:      runDoor43CatalogExport,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "output, o",
+				Usage: "Directory or .tar.gz file to write the snapshot to",
+			},
+			cli.StringFlag{
+				Name:  "owner",
+				Usage: "Comma-separated list of the owners of the entries to export, all if empty",
+			},
+			cli.StringFlag{
+				Name:  "lang",
+				Usage: "Comma-separated list of the languages of the entries to export, all if empty",
+			},
+			cli.StringFlag{
+				Name:  "stage",
+				Value: "prod",
+				Usage: "Stage of the entries to export: prod, preprod, draft, latest or branch",
+			},
+			cli.BoolFlag{
+				Name:  "zipballs",
+				Usage: "Export the zipball of each entry too, which is needed to import the entries into an instance without their repos",
+			},
+		},
+	}
+
+	subcmdDoor43CatalogImport = cli.Command{
+		Name:        "import",
+		Usage:       "Import a snapshot of the catalog",
+		Description: "Verifies a snapshot exported by the export command and loads it into this instance, creating the owners, repos and releases of its entries from their zipballs if they don't exist, with the releases as tags and each branch on its own",
+		Action:      runDoor43CatalogImport,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "input, i",
+				Usage: "Directory or .tar.gz file to read the snapshot from",
+			},
+			cli.StringFlag{
+				Name:  "admin",
+				Usage: "Name of the user that creates the owners, repos and releases that don't exist, the first admin if empty",
+			},
+		},
+	}
+)
+
+// splitFlag splits a comma-separated flag value into its non-empty values
+func splitFlag(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// writeJSONFile writes the value as JSON to the path relative to the directory, creating its parent directories
+func writeJSONFile(dir, path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// readJSONFile reads the JSON file at the path relative to the directory into the value
+func readJSONFile(dir, path string, v interface{}) error {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func runDoor43CatalogExport(ctx *cli.Context) error {
+	if err := argsSet(ctx, "output"); err != nil {
+		return err
+	}
+	stage, ok := models.StageMap[ctx.String("stage")]
+	if !ok {
+		return fmt.Errorf("invalid stage: \"%s\"", ctx.String("stage"))
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	output := ctx.String("output")
+	dir := output
+	isTarball := strings.HasSuffix(output, ".tar.gz")
+	if isTarball {
+		var err error
+		if dir, err = ioutil.TempDir(os.TempDir(), "gitea-catalog-snapshot"); err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Warn("Unable to remove temporary directory: %s: Error: %v", dir, err)
+			}
+		}()
+	} else if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	snapshot := &door43metadata.CatalogSnapshot{
+		Version:   door43metadata.CatalogSnapshotVersion,
+		AppURL:    setting.AppURL,
+		Created:   time.Now().UTC(),
+		Owners:    splitFlag(ctx.String("owner")),
+		Languages: splitFlag(ctx.String("lang")),
+		Stage:     stage.String(),
+		Zipballs:  ctx.Bool("zipballs"),
+	}
+	opts := &models.SearchCatalogOptions{
+		ListOptions:     models.ListOptions{PageSize: setting.API.MaxResponseItems},
+		Owners:          snapshot.Owners,
+		Languages:       snapshot.Languages,
+		Stage:           stage,
+		ShowIngredients: true,
+		OrderBy:         []models.CatalogOrderBy{models.CatalogOrderByOldest},
+	}
+
+	results := &api.CatalogSearchResultsV5{OK: true}
+	for opts.Page = 1; ; opts.Page++ {
+		dms, _, err := models.SearchCatalog(opts)
+		if err != nil {
+			return fmt.Errorf("SearchCatalog: %v", err)
+		}
+		if len(dms) == 0 {
+			break
+		}
+		for _, dm := range dms {
+			entry := convert.ToDoor43MetadataV5(dm, models.AccessModeRead)
+			if entry == nil {
+				log.Warn("Unable to convert catalog entry %d, skipping it", dm.ID)
+				continue
+			}
+			log.Info("Exporting %s %s", entry.FullName, entry.BranchOrTag)
+			if err := writeJSONFile(dir, door43metadata.SnapshotEntryPath(entry.Owner, entry.Name, entry.BranchOrTag), entry); err != nil {
+				return err
+			}
+			if err := writeJSONFile(dir, door43metadata.SnapshotMetadataPath(entry.Owner, entry.Name, entry.BranchOrTag), dm.Metadata); err != nil {
+				return err
+			}
+			if snapshot.Zipballs {
+				if err := exportDoor43CatalogZipball(dir, dm); err != nil {
+					return fmt.Errorf("unable to export the zipball of %s %s: %v", entry.FullName, entry.BranchOrTag, err)
+				}
+			}
+			results.Data = append(results.Data, entry)
+		}
+	}
+	snapshot.Entries = len(results.Data)
+
+	if err := writeJSONFile(dir, door43metadata.SnapshotCatalogFile, results); err != nil {
+		return err
+	}
+	if err := writeJSONFile(dir, door43metadata.SnapshotFile, snapshot); err != nil {
+		return err
+	}
+	if err := door43metadata.WriteSnapshotChecksums(dir); err != nil {
+		return err
+	}
+
+	if isTarball {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		if err := door43metadata.TarSnapshot(dir, f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	log.Info("Exported %d catalog entries to %s", snapshot.Entries, output)
+	return nil
+}
+
+// exportDoor43CatalogZipball writes the zipball of the commit of a catalog entry to the snapshot in the directory
+func exportDoor43CatalogZipball(dir string, dm *models.Door43Metadata) error {
+	gitRepo, err := git.OpenRepository(dm.Repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	var commitID string
+	if dm.ReleaseID > 0 {
+		commitID, err = gitRepo.GetTagCommitID(dm.BranchOrTag)
+	} else {
+		commitID, err = gitRepo.GetBranchCommitID(dm.BranchOrTag)
+	}
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(dir, filepath.FromSlash(door43metadata.SnapshotZipballPath(dm.Repo.OwnerName, dm.Repo.Name, dm.BranchOrTag)))
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := gitRepo.CreateArchive(graceful.GetManager().ShutdownContext(), git.ZIP, f, setting.Repository.PrefixArchiveFiles, commitID); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runDoor43CatalogImport(ctx *cli.Context) error {
+	if err := argsSet(ctx, "input"); err != nil {
+		return err
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	var admin *models.User
+	var err error
+	if ctx.IsSet("admin") {
+		admin, err = models.GetUserByName(ctx.String("admin"))
+	} else {
+		admin, err = models.GetAdminUser()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get the admin user: %v", err)
+	}
+
+	input := ctx.String("input")
+	dir := input
+	if strings.HasSuffix(input, ".tar.gz") {
+		if dir, err = ioutil.TempDir(os.TempDir(), "gitea-catalog-snapshot"); err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Warn("Unable to remove temporary directory: %s: Error: %v", dir, err)
+			}
+		}()
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		err = door43metadata.UntarSnapshot(f, dir)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := door43metadata.VerifySnapshotChecksums(dir); err != nil {
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+	var snapshot door43metadata.CatalogSnapshot
+	if err := readJSONFile(dir, door43metadata.SnapshotFile, &snapshot); err != nil {
+		return err
+	}
+	if snapshot.Version != door43metadata.CatalogSnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+	var results api.CatalogSearchResultsV5
+	if err := readJSONFile(dir, door43metadata.SnapshotCatalogFile, &results); err != nil {
+		return err
+	}
+
+	// The entries of each repo are imported together, its releases oldest first
+	var fullNames []string
+	entriesByRepo := make(map[string][]*api.Door43MetadataV5)
+	for _, entry := range results.Data {
+		if _, ok := entriesByRepo[entry.FullName]; !ok {
+			fullNames = append(fullNames, entry.FullName)
+		}
+		entriesByRepo[entry.FullName] = append(entriesByRepo[entry.FullName], entry)
+	}
+	for _, fullName := range fullNames {
+		entries := entriesByRepo[fullName]
+		sort.SliceStable(entries, func(i, j int) bool {
+			if (entries[i].Release == nil) != (entries[j].Release == nil) {
+				return entries[j].Release == nil
+			}
+			return entries[i].Release != nil && entries[i].Release.CreatedAt.Before(entries[j].Release.CreatedAt)
+		})
+		if err := importDoor43CatalogRepo(dir, snapshot.Zipballs, admin, entries); err != nil {
+			log.Error("Unable to import %s: %v", fullName, err)
+			continue
+		}
+		log.Info("Imported %d catalog entries of %s", len(entries), fullName)
+	}
+	return nil
+}
+
+// importDoor43CatalogRepo imports the entries of a repo, creating its owner, the repo and its releases from their
+// zipballs if the repo doesn't exist, and then processes the metadata of the repo
+func importDoor43CatalogRepo(dir string, hasZipballs bool, admin *models.User, entries []*api.Door43MetadataV5) error {
+	first := entries[0]
+	owner, err := models.GetUserByName(first.Owner)
+	if err != nil && !models.IsErrUserNotExist(err) {
+		return err
+	}
+	var repo *models.Repository
+	if owner != nil {
+		if repo, err = models.GetRepositoryByName(owner.ID, first.Name); err != nil && !models.IsErrRepoNotExist(err) {
+			return err
+		}
+	}
+
+	if repo == nil {
+		if !hasZipballs {
+			return fmt.Errorf("the repo does not exist and the snapshot has no zipballs")
+		}
+		if owner == nil {
+			owner = &models.User{
+				Name:       first.Owner,
+				IsActive:   true,
+				Visibility: api.VisibleTypePublic,
+			}
+			if first.Repo != nil && first.Repo.Owner != nil {
+				owner.FullName = first.Repo.Owner.FullName
+			}
+			if err := models.CreateOrganization(owner, admin); err != nil {
+				return fmt.Errorf("CreateOrganization: %v", err)
+			}
+		}
+		opts := models.CreateRepoOptions{Name: first.Name}
+		if first.Repo != nil {
+			opts.Description = first.Repo.Description
+			opts.DefaultBranch = first.Repo.DefaultBranch
+		}
+		if repo, err = repo_module.CreateRepository(admin, owner, opts); err != nil {
+			return fmt.Errorf("CreateRepository: %v", err)
+		}
+		if err := importDoor43CatalogCommits(dir, admin, repo, entries); err != nil {
+			return err
+		}
+	} else if err := repo.GetOwner(); err != nil {
+		return err
+	}
+
+	return door43metadata.ProcessDoor43MetadataForRepo(repo)
+}
+
+// importDoor43CatalogCommits commits the zipball of each entry to the new repo and pushes them. The releases are
+// committed one after the other, oldest first, and tagged and released, while each branch is committed on its own. The
+// default branch is its entry if there is one, else the latest release. The zipballs are extracted into a work tree
+// apart from the git directory, so that their files can't reach its hooks or config.
+func importDoor43CatalogCommits(dir string, admin *models.User, repo *models.Repository, entries []*api.Door43MetadataV5) error {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-catalog-import")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warn("Unable to remove temporary directory: %s: Error: %v", tmpDir, err)
+		}
+	}()
+
+	gitDir := filepath.Join(tmpDir, "repo.git")
+	workTree := filepath.Join(tmpDir, "files")
+	if _, err := git.NewCommand("init", "--bare", gitDir).RunInDir(tmpDir); err != nil {
+		return fmt.Errorf("git init: %v", err)
+	}
+	gitCommand := func(args ...string) *git.Command {
+		return git.NewCommand(append([]string{"--git-dir=" + gitDir, "--work-tree=" + workTree}, args...)...)
+	}
+
+	sig := admin.NewGitSig()
+	var lastReleaseID string
+	var branches []string
+	for _, entry := range entries {
+		zipball := filepath.Join(dir, filepath.FromSlash(door43metadata.SnapshotZipballPath(entry.Owner, entry.Name, entry.BranchOrTag)))
+		if _, err := os.Stat(zipball); err != nil {
+			log.Warn("No zipball of %s %s: %v", entry.FullName, entry.BranchOrTag, err)
+			continue
+		}
+		if err := os.RemoveAll(workTree); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(workTree, os.ModePerm); err != nil {
+			return err
+		}
+		if err := door43metadata.ExtractSnapshotZipball(zipball, workTree); err != nil {
+			return err
+		}
+
+		date := time.Now()
+		if entry.Release != nil {
+			date = entry.Release.CreatedAt
+		}
+		env := append(os.Environ(),
+			"GIT_AUTHOR_NAME="+sig.Name,
+			"GIT_AUTHOR_EMAIL="+sig.Email,
+			"GIT_AUTHOR_DATE="+date.Format(time.RFC3339),
+			"GIT_COMMITTER_NAME="+sig.Name,
+			"GIT_COMMITTER_EMAIL="+sig.Email,
+			"GIT_COMMITTER_DATE="+date.Format(time.RFC3339),
+		)
+		if _, err := gitCommand("add", "--all").RunInDir(workTree); err != nil {
+			return fmt.Errorf("git add: %v", err)
+		}
+		treeID, err := gitCommand("write-tree").RunInDir(workTree)
+		if err != nil {
+			return fmt.Errorf("git write-tree: %v", err)
+		}
+		args := []string{"commit-tree", strings.TrimSpace(treeID), "-m", "Import " + entry.BranchOrTag}
+		if entry.Release != nil && lastReleaseID != "" {
+			args = append(args, "-p", lastReleaseID)
+		}
+		commitID, err := gitCommand(args...).RunInDirWithEnv(workTree, env)
+		if err != nil {
+			return fmt.Errorf("git commit-tree: %v", err)
+		}
+		commitID = strings.TrimSpace(commitID)
+
+		refName := git.BranchPrefix + entry.BranchOrTag
+		if entry.Release != nil {
+			refName = git.TagPrefix + entry.BranchOrTag
+			lastReleaseID = commitID
+		} else {
+			branches = append(branches, entry.BranchOrTag)
+		}
+		if _, err := gitCommand("update-ref", refName, commitID).RunInDir(workTree); err != nil {
+			return fmt.Errorf("git update-ref: %v", err)
+		}
+	}
+
+	if !util.IsStringInSlice(repo.DefaultBranch, branches) {
+		if lastReleaseID != "" {
+			if _, err := gitCommand("update-ref", git.BranchPrefix+repo.DefaultBranch, lastReleaseID).RunInDir(workTree); err != nil {
+				return fmt.Errorf("git update-ref: %v", err)
+			}
+		} else if len(branches) > 0 {
+			repo.DefaultBranch = branches[0]
+			if err := models.UpdateRepositoryCols(repo, "default_branch"); err != nil {
+				return err
+			}
+		} else {
+			return fmt.Errorf("the snapshot has no zipballs of the repo")
+		}
+	}
+
+	if _, err := gitCommand("push", repo.RepoPath(), "refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*").
+		RunInDirWithEnv(tmpDir, models.InternalPushingEnvironment(admin, repo)); err != nil {
+		return fmt.Errorf("git push: %v", err)
+	}
+	repo.IsEmpty = false
+	if err := models.UpdateRepositoryCols(repo, "is_empty"); err != nil {
+		return err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+	if err := gitRepo.SetDefaultBranch(repo.DefaultBranch); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Release == nil {
+			continue
+		}
+		commit, err := gitRepo.GetTagCommit(entry.BranchOrTag)
+		if err != nil {
+			return err
+		}
+		numCommits, err := commit.CommitsCount()
+		if err != nil {
+			return err
+		}
+		if err := models.InsertRelease(&models.Release{
+			RepoID:       repo.ID,
+			PublisherID:  admin.ID,
+			TagName:      entry.BranchOrTag,
+			LowerTagName: strings.ToLower(entry.BranchOrTag),
+			Target:       repo.DefaultBranch,
+			Title:        entry.Release.Title,
+			Note:         entry.Release.Note,
+			Sha1:         commit.ID.String(),
+			NumCommits:   numCommits,
+			IsPrerelease: entry.Release.IsPrerelease,
+			CreatedUnix:  timeutil.TimeStamp(entry.Release.CreatedAt.Unix()),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
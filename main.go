@@ -21,6 +21,8 @@ import (
 	_ "code.gitea.io/gitea/modules/markup/csv"
 	_ "code.gitea.io/gitea/modules/markup/markdown"
 	_ "code.gitea.io/gitea/modules/markup/orgmode"
+	_ "code.gitea.io/gitea/modules/markup/tsv"  // DCS Customizations
+	_ "code.gitea.io/gitea/modules/markup/usfm" // DCS Customizations
 
 	"github.com/urfave/cli"
 )
@@ -71,6 +73,7 @@ arguments - which can alternatively be run by running the subcommand web.`
 		cmd.CmdManager,
 		cmd.Cmdembedded,
 		cmd.CmdDoor43MetadataGenerate,
+		cmd.CmdDoor43Catalog, // DCS Customizations
 		cmd.CmdMigrateStorage,
 		cmd.CmdDocs,
 		cmd.CmdDumpRepository,
@@ -0,0 +1,143 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CatalogFacet is a field of the catalog entries that the entries matching a search can be counted by
+type CatalogFacet string
+
+// CatalogFacet values, the same as the query parameters they filter the catalog by
+const (
+	CatalogFacetLanguage      CatalogFacet = "lang"
+	CatalogFacetSubject       CatalogFacet = "subject"
+	CatalogFacetOwner         CatalogFacet = "owner"
+	CatalogFacetBook          CatalogFacet = "book"
+	CatalogFacetCheckingLevel CatalogFacet = "checkingLevel"
+	CatalogFacetStage         CatalogFacet = "stage"
+)
+
+// catalogFacetColumns are the columns the entries are grouped by for each facet. The books are grouped by their JSON
+// list, which is then counted book by book.
+var catalogFacetColumns = map[CatalogFacet]string{
+	CatalogFacetLanguage:      "`door43_metadata`.language",
+	CatalogFacetSubject:       "`door43_metadata`.subject",
+	CatalogFacetOwner:         "`repository`.owner_name",
+	CatalogFacetBook:          "`door43_metadata`.books",
+	CatalogFacetCheckingLevel: "`door43_metadata`.checking_level",
+	CatalogFacetStage:         "`door43_metadata`.stage",
+}
+
+// IsValid returns true if the facet is one the catalog can be counted by
+func (f CatalogFacet) IsValid() bool {
+	_, ok := catalogFacetColumns[f]
+	return ok
+}
+
+// CatalogFacetCount is the number of catalog entries matching a search that have a value of a facet
+type CatalogFacetCount struct {
+	Value string
+	Count int64
+}
+
+// catalogFacetRow is the number of catalog entries matching a search with the same values of all the facets
+type catalogFacetRow struct {
+	Language      string
+	Subject       string
+	OwnerName     string
+	Books         []string `xorm:"TEXT JSON"`
+	CheckingLevel int
+	Stage         Stage
+	FacetCount    int64
+}
+
+// values returns the values the row has for a facet, there being one for each book of its list for the book facet
+func (row *catalogFacetRow) values(facet CatalogFacet) []string {
+	switch facet {
+	case CatalogFacetLanguage:
+		return []string{row.Language}
+	case CatalogFacetSubject:
+		return []string{row.Subject}
+	case CatalogFacetOwner:
+		return []string{row.OwnerName}
+	case CatalogFacetBook:
+		return row.Books
+	case CatalogFacetCheckingLevel:
+		return []string{strconv.Itoa(row.CheckingLevel)}
+	case CatalogFacetStage:
+		return []string{row.Stage.String()}
+	}
+	return nil
+}
+
+// SearchCatalogFacets returns the number of catalog entries matching the search options for each value of each of the
+// facets, the most common first. The entries are counted by the values of all the facets at once in a single query,
+// grouping the entries with the same list of books to count each of its books by, and values differing only in case
+// are counted together as they are when filtering.
+func SearchCatalogFacets(opts *SearchCatalogOptions, facets []CatalogFacet) (map[CatalogFacet][]*CatalogFacetCount, error) {
+	results := make(map[CatalogFacet][]*CatalogFacetCount, len(facets))
+	if len(facets) == 0 {
+		return results, nil
+	}
+
+	columns := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		columns = append(columns, catalogFacetColumns[facet])
+	}
+
+	sess, err := newCatalogSession(opts, SearchCatalogCondition(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+
+	rows := make([]*catalogFacetRow, 0, 50)
+	if err := sess.Table("door43_metadata").
+		Select(strings.Join(columns, ", ") + ", COUNT(*) AS facet_count").
+		GroupBy(strings.Join(columns, ", ")).
+		Find(&rows); err != nil {
+		return nil, err
+	}
+
+	for _, facet := range facets {
+		counts := make(map[string]*CatalogFacetCount)
+		spellings := make(map[string]map[string]int64)
+		results[facet] = make([]*CatalogFacetCount, 0, 10)
+		for _, row := range rows {
+			for _, value := range row.values(facet) {
+				if value == "" {
+					continue
+				}
+				key := strings.ToLower(value)
+				if _, ok := counts[key]; !ok {
+					counts[key] = &CatalogFacetCount{Value: value}
+					spellings[key] = make(map[string]int64)
+					results[facet] = append(results[facet], counts[key])
+				}
+				counts[key].Count += row.FacetCount
+				spellings[key][value] += row.FacetCount
+			}
+		}
+		// Each value is given in its most common spelling, preferring lower case on a tie
+		for key, count := range counts {
+			for value, n := range spellings[key] {
+				if n > spellings[key][count.Value] || (n == spellings[key][count.Value] && value > count.Value) {
+					count.Value = value
+				}
+			}
+		}
+		sort.SliceStable(results[facet], func(i, j int) bool {
+			if results[facet][i].Count != results[facet][j].Count {
+				return results[facet][i].Count > results[facet][j].Count
+			}
+			return strings.ToLower(results[facet][i].Value) < strings.ToLower(results[facet][j].Value)
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchCatalogFacets(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, dm := range []*Door43Metadata{
+		{RepoID: 1, Language: "en", Subject: "Bible", Books: []string{"gen", "exo"}, CheckingLevel: 3},
+		{RepoID: 4, Language: "EN", Subject: "Translation Notes", Books: []string{"gen"}, CheckingLevel: 1},
+		{RepoID: 8, Language: "fr", Subject: "Bible", Books: []string{"gen"}, CheckingLevel: 3},
+	} {
+		dm.MetadataType = MetadataTypeRC
+		dm.MetadataVersion = "rc0.2"
+		dm.Metadata = &map[string]interface{}{}
+		dm.Stage = StageLatest
+		dm.BranchOrTag = "master"
+		dm.ReleaseDateUnix = 1
+		assert.NoError(t, InsertDoor43Metadata(dm))
+	}
+
+	facets, err := SearchCatalogFacets(&SearchCatalogOptions{Stage: StageLatest}, []CatalogFacet{
+		CatalogFacetLanguage, CatalogFacetSubject, CatalogFacetBook, CatalogFacetCheckingLevel, CatalogFacetStage,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*CatalogFacetCount{{"en", 2}, {"fr", 1}}, facets[CatalogFacetLanguage])
+	assert.Equal(t, []*CatalogFacetCount{{"Bible", 2}, {"Translation Notes", 1}}, facets[CatalogFacetSubject])
+	assert.Equal(t, []*CatalogFacetCount{{"gen", 3}, {"exo", 1}}, facets[CatalogFacetBook])
+	assert.Equal(t, []*CatalogFacetCount{{"3", 2}, {"1", 1}}, facets[CatalogFacetCheckingLevel])
+	assert.Equal(t, []*CatalogFacetCount{{"latest", 3}}, facets[CatalogFacetStage])
+
+	// the entries with the same books are counted together
+	facets, err = SearchCatalogFacets(&SearchCatalogOptions{Stage: StageLatest}, []CatalogFacet{CatalogFacetBook})
+	assert.NoError(t, err)
+	assert.Equal(t, []*CatalogFacetCount{{"gen", 3}, {"exo", 1}}, facets[CatalogFacetBook])
+
+	// counts are of the entries matching the filters
+	facets, err = SearchCatalogFacets(&SearchCatalogOptions{Stage: StageLatest, Subjects: []string{"bible"}},
+		[]CatalogFacet{CatalogFacetLanguage})
+	assert.NoError(t, err)
+	assert.Equal(t, []*CatalogFacetCount{{"en", 1}, {"fr", 1}}, facets[CatalogFacetLanguage])
+
+	_, err = DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+	_, err = DeleteAllDoor43MetadatasByRepoID(4, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+	_, err = DeleteAllDoor43MetadatasByRepoID(8, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+}
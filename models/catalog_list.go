@@ -6,28 +6,35 @@ package models
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/util"
+
 	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
 )
 
 //CatalogOrderBy is used to sort the result
 type CatalogOrderBy string
 
+// String returns the ORDER BY clause
 func (s CatalogOrderBy) String() string {
 	return string(s)
 }
 
 // Strings for sorting result
 const (
-	CatalogOrderByTitle           CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.title') ASC"
-	CatalogOrderByTitleReverse    CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.title') DESC"
-	CatalogOrderBySubject         CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.subject') ASC"
-	CatalogOrderBySubjectReverse  CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.subject') DESC"
-	CatalogOrderByTag             CatalogOrderBy = "CAST(TRIM(LEADING 'v' FROM `release`.tag_name) AS unsigned) ASC, `door43_metadata`.branch_or_tag ASC, `door43_metadata`.release_date_unix ASC"
-	CatalogOrderByTagReverse      CatalogOrderBy = "CAST(TRIM(LEADING 'v' FROM `release`.tag_name) AS unsigned) DESC, `door43_metadata`.branch_or_tag DESC, `door43_metadata`.release_date_unix DESC"
-	CatalogOrderByLangCode        CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.language.identifier') ASC"
-	CatalogOrderByLangCodeReverse CatalogOrderBy = "JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.language.identifier') DESC"
+	CatalogOrderByTitle           CatalogOrderBy = "LOWER(`door43_metadata`.title) ASC"
+	CatalogOrderByTitleReverse    CatalogOrderBy = "LOWER(`door43_metadata`.title) DESC"
+	CatalogOrderBySubject         CatalogOrderBy = "LOWER(`door43_metadata`.subject) ASC"
+	CatalogOrderBySubjectReverse  CatalogOrderBy = "LOWER(`door43_metadata`.subject) DESC"
+	CatalogOrderByTag             CatalogOrderBy = "`door43_metadata`.version_key ASC, `door43_metadata`.branch_or_tag ASC, `door43_metadata`.release_date_unix ASC"
+	CatalogOrderByTagReverse      CatalogOrderBy = "`door43_metadata`.version_key DESC, `door43_metadata`.branch_or_tag DESC, `door43_metadata`.release_date_unix DESC"
+	CatalogOrderByLangCode        CatalogOrderBy = "LOWER(`door43_metadata`.language) ASC"
+	CatalogOrderByLangCodeReverse CatalogOrderBy = "LOWER(`door43_metadata`.language) DESC"
 	CatalogOrderByOldest          CatalogOrderBy = "`door43_metadata`.release_date_unix ASC"
 	CatalogOrderByNewest          CatalogOrderBy = "`door43_metadata`.release_date_unix DESC"
 	CatalogOrderByReleases        CatalogOrderBy = "release_count ASC"
@@ -36,8 +43,21 @@ const (
 	CatalogOrderByStarsReverse    CatalogOrderBy = "`repository`.num_stars DESC"
 	CatalogOrderByForks           CatalogOrderBy = "`repository`.num_forks ASC"
 	CatalogOrderByForksReverse    CatalogOrderBy = "`repository`.num_forks DESC"
+	CatalogOrderByID              CatalogOrderBy = "`door43_metadata`.id ASC"
 )
 
+// jsonTextExpr returns the expression to search a JSON column as lower case text on the current database
+func jsonTextExpr(column string) string {
+	switch x.Dialect().URI().DBType {
+	case schemas.MYSQL:
+		return "LOWER(CAST(" + column + " AS CHAR))"
+	case schemas.POSTGRES:
+		return "LOWER(CAST(" + column + " AS TEXT))"
+	default:
+		return "LOWER(" + column + ")"
+	}
+}
+
 // Door43MetadataListDefaultPageSize is the default number of repositories
 // to load in memory when running administrative tasks on all (or almost
 // all) of them.
@@ -99,6 +119,7 @@ type SearchCatalogOptions struct {
 	Owners          []string
 	Repos           []string
 	Tags            []string
+	Branches        []string
 	Stage           Stage
 	Subjects        []string
 	CheckingLevels  []string
@@ -107,7 +128,48 @@ type SearchCatalogOptions struct {
 	IncludeMetadata bool
 	ShowIngredients bool
 	Languages       []string
+	GatewayLanguage util.OptionalBool
+	MetadataTypes   []string
+	ExcludeTypes    []string
+	Version         dcs.VersionConstraint
 	OrderBy         []CatalogOrderBy
+	Actor           *User // the entries of the repos the actor can read, public ones only if nil, all for admins
+}
+
+// catalogAccessCond returns the condition of the repos the actor can read the catalog entries of, which is every repo
+// for admins and only the public repos of public owners for a nil actor
+func catalogAccessCond(actor *User) builder.Cond {
+	if actor != nil && actor.IsAdmin {
+		return builder.NewCond()
+	}
+	return accessibleRepositoryCondition(actor)
+}
+
+// catalogRecordAccessCond returns the condition of the catalog changes and tombstones the actor can read, which are
+// the ones recorded as public and the ones of the repos the actor can read
+func catalogRecordAccessCond(actor *User) builder.Cond {
+	if actor != nil && actor.IsAdmin {
+		return builder.NewCond()
+	}
+	return builder.Or(builder.Eq{"is_private": false}, builder.In("repo_id", AccessibleRepoIDsQuery(actor)))
+}
+
+// isCatalogPrivate returns true if not everyone can read the catalog entries of the repo, as it is private or its
+// owner is not public
+func (repo *Repository) isCatalogPrivate(e Engine) (bool, error) {
+	if repo.IsPrivate {
+		return true, nil
+	}
+	if err := repo.getOwner(e); err != nil {
+		return false, err
+	}
+	return !repo.Owner.Visibility.IsPublic(), nil
+}
+
+// notArchivedCond returns the condition of the repo of an entry not being archived, which is also the case if the
+// column was never set
+func notArchivedCond() builder.Cond {
+	return builder.Or(builder.Eq{"`repository`.is_archived": false}, builder.IsNull{"`repository`.is_archived"})
 }
 
 // SearchCatalogCondition creates a query condition according search repository options
@@ -124,11 +186,7 @@ func SearchCatalogCondition(opts *SearchCatalogOptions) builder.Cond {
 	for _, keyword := range opts.Keywords {
 		keywordCond = keywordCond.Or(builder.Like{"`repository`.lower_name", strings.ToLower(keyword)})
 		keywordCond = keywordCond.Or(builder.Like{"`user`.lower_name", strings.ToLower(keyword)})
-		keywordCond = keywordCond.Or(builder.Like{"LOWER(REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.title'), '\"', ''))", strings.ToLower(keyword)})
-		keywordCond = keywordCond.Or(builder.Like{"LOWER(REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.subject'), '\"', ''))", strings.ToLower(keyword)})
-		if opts.IncludeMetadata {
-			keywordCond = keywordCond.Or(builder.Expr("JSON_SEARCH(LOWER(`door43_metadata`.metadata), 'one', ?) IS NOT NULL", "%"+strings.ToLower(keyword)+"%"))
-		}
+		keywordCond = keywordCond.Or(GetKeywordMetadataCond(keyword, opts.IncludeMetadata))
 	}
 
 	stageCond := GetStageCond(opts.Stage)
@@ -137,15 +195,20 @@ func SearchCatalogCondition(opts *SearchCatalogOptions) builder.Cond {
 	cond := builder.NewCond().And(GetSubjectCond(opts.Subjects),
 		GetBookCond(opts.Books),
 		GetLanguageCond(opts.Languages),
+		GetGatewayLanguageCond(opts.GatewayLanguage),
 		GetCheckingLevelCond(opts.CheckingLevels),
 		GetTagCond(opts.Tags),
+		GetBranchCond(opts.Branches),
+		GetMetadataTypeCond(opts.MetadataTypes),
+		GetExcludeMetadataTypeCond(opts.ExcludeTypes),
+		GetVersionCond(opts.Version),
 		repoCond,
 		ownerCond,
 		stageCond,
 		historyCond,
 		keywordCond,
-		builder.Eq{"`repository`.is_private": false},
-		builder.Eq{"`repository`.is_archived": false})
+		catalogAccessCond(opts.Actor),
+		notArchivedCond())
 
 	return cond
 }
@@ -167,39 +230,20 @@ func SearchCatalogByCondition(opts *SearchCatalogOptions, cond builder.Cond, loa
 		opts.OrderBy = []CatalogOrderBy{CatalogOrderByNewest}
 	}
 
-	sess := x.NewSession()
-	defer sess.Close()
-
-	dms := make(Door43MetadataList, 0, opts.PageSize)
-
-	releaseInfoInner, err := builder.Select("`door43_metadata`.repo_id", "COUNT(*) AS release_count", "MAX(`door43_metadata`.release_date_unix) AS latest_unix").
-		From("door43_metadata").
-		GroupBy("`door43_metadata`.repo_id").
-		Where(GetStageCond(opts.Stage)).
-		ToBoundSQL()
-	if err != nil {
-		return nil, 0, err
-	}
-
-	releaseInfoOuter, err := builder.Select("`door43_metadata`.repo_id", "MAX(release_count) AS release_count", "MAX(latest_unix) AS latest_unix", "MIN(stage) AS latest_stage").
-		From("door43_metadata").
-		Join("INNER", "("+releaseInfoInner+") release_info_inner", "`release_info_inner`.repo_id = `door43_metadata`.repo_id AND `door43_metadata`.release_date_unix = `release_info_inner`.latest_unix").
-		GroupBy("`door43_metadata`.repo_id").
-		ToBoundSQL()
+	sess, err := newCatalogSession(opts, cond)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer sess.Close()
 
-	sess.
-		Join("INNER", "repository", "`repository`.id = `door43_metadata`.repo_id").
-		Join("INNER", "user", "`repository`.owner_id = `user`.id").
-		Join("LEFT", "release", "`release`.id = `door43_metadata`.release_id").
-		Join("INNER", "("+releaseInfoOuter+") release_info", "release_info.repo_id = `door43_metadata`.repo_id").
-		Where(cond)
+	dms := make(Door43MetadataList, 0, opts.PageSize)
 
 	for _, orderBy := range opts.OrderBy {
 		sess.OrderBy(orderBy.String())
 	}
+	// The entries are ordered by their ID last, so that those equal by the other orders are always in the same order
+	// and a page doesn't repeat nor skip any of them
+	sess.OrderBy(CatalogOrderByID.String())
 
 	if opts.PageSize > 0 {
 		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
@@ -218,6 +262,46 @@ func SearchCatalogByCondition(opts *SearchCatalogOptions, cond builder.Cond, loa
 	return dms, count, nil
 }
 
+// latestKeyExpr is the key the latest entry of a repo has the highest of, the version key of a release, so a patch of
+// an old version released after a new version is not the latest, and above all of them the default branch, which is
+// returned instead of the releases when the stage includes it
+var latestKeyExpr = fmt.Sprintf("CASE WHEN `door43_metadata`.stage = %d THEN 'z' ELSE `door43_metadata`.version_key END", StageLatest)
+
+// newCatalogSession returns a new session of the catalog entries matching the condition, joined with their repo, owner,
+// release and the release info of their repo the conditions can refer to, which repos with only other branches in the
+// catalog don't have. The caller must close it.
+func newCatalogSession(opts *SearchCatalogOptions, cond builder.Cond) (*xorm.Session, error) {
+	releaseCond := builder.And(GetStageCond(getLatestStage(opts.Stage)), GetVersionCond(opts.Version))
+	releaseInfoInner, err := builder.Select("`door43_metadata`.repo_id", "COUNT(*) AS release_count", "MAX("+latestKeyExpr+") AS latest_key").
+		From("door43_metadata").
+		GroupBy("`door43_metadata`.repo_id").
+		Where(releaseCond).
+		ToBoundSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	// Of the entries of a repo with the latest key, which are usually one, the most recently released is the latest
+	releaseInfoOuterBuilder := builder.Select("`door43_metadata`.repo_id", "MAX(release_count) AS release_count", "MAX(latest_key) AS latest_key", "MAX(`door43_metadata`.release_date_unix) AS latest_unix", "MIN(stage) AS latest_stage").
+		From("door43_metadata").
+		Join("INNER", "("+releaseInfoInner+") release_info_inner", "`release_info_inner`.repo_id = `door43_metadata`.repo_id AND "+latestKeyExpr+" = `release_info_inner`.latest_key").
+		Where(releaseCond).
+		GroupBy("`door43_metadata`.repo_id")
+	releaseInfoOuter, err := releaseInfoOuterBuilder.ToBoundSQL()
+	if err != nil {
+		return nil, err
+	}
+	// xorm does not convert the quotes of a sub query given as a string, so must be done here for PostgreSQL and MSSQL
+	releaseInfoOuter = x.Dialect().Quoter().Replace(releaseInfoOuter)
+
+	return x.NewSession().
+		Join("INNER", "repository", "`repository`.id = `door43_metadata`.repo_id").
+		Join("INNER", "user", "`repository`.owner_id = `user`.id").
+		Join("LEFT", "release", "`release`.id = `door43_metadata`.release_id").
+		Join("LEFT", "("+releaseInfoOuter+") release_info", "release_info.repo_id = `door43_metadata`.repo_id").
+		Where(cond), nil
+}
+
 // SplitAtCommaNotInString split s at commas, ignoring commas in strings.
 func SplitAtCommaNotInString(s string, requireSpaceAfterComma bool) []string {
 	var res []string
@@ -256,29 +340,77 @@ func GetStageCond(stage Stage) builder.Cond {
 	return builder.Lte{"`door43_metadata`.stage": stage}
 }
 
+// getLatestStage returns the stage the latest entry of each repo is found up to, which leaves out the other branches
+// of a repo as they are listed besides its latest entry rather than instead of it
+func getLatestStage(stage Stage) Stage {
+	if stage > StageLatest {
+		return StageLatest
+	}
+	return stage
+}
+
 // GetHistoryCond gets the conditions if IncludeHistory is true based on stage
 func GetHistoryCond(stage Stage, includeHistory bool) builder.Cond {
 	if includeHistory {
 		return nil
 	}
-	return builder.And(builder.Expr("`door43_metadata`.release_date_unix = latest_unix"), builder.Expr("`door43_metadata`.stage = latest_stage"))
+	cond := builder.And(builder.Expr(latestKeyExpr+" = latest_key"), builder.Expr("`door43_metadata`.release_date_unix = latest_unix"),
+		builder.Expr("`door43_metadata`.stage = latest_stage"))
+	if stage == StageBranch {
+		return builder.Or(cond, builder.Eq{"`door43_metadata`.stage": StageBranch})
+	}
+	return cond
+}
+
+// GetKeywordMetadataCond gets the condition for a keyword to be in the title or subject, or anywhere in the metadata if includeMetadata is true
+func GetKeywordMetadataCond(keyword string, includeMetadata bool) builder.Cond {
+	keyword = strings.ToLower(keyword)
+	cond := builder.NewCond().
+		Or(builder.Like{"LOWER(`door43_metadata`.title)", keyword}).
+		Or(builder.Like{"LOWER(`door43_metadata`.subject)", keyword})
+	if includeMetadata {
+		cond = cond.Or(builder.Like{jsonTextExpr("`door43_metadata`.metadata"), keyword})
+	}
+	return cond
 }
 
-// GetSubjectCond gets the subject condition
+// GetSubjectCond gets the subject condition, matching a subject given by its title or resource ID by any of the
+// titles it is known by in the subject registry
 func GetSubjectCond(subjects []string) builder.Cond {
 	var subjectCond = builder.NewCond()
 	for _, subject := range subjects {
-		subjectCond = subjectCond.Or(builder.Eq{"LOWER(REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.subject'), '\"', ''))": strings.ToLower(subject)})
+		for _, title := range dcs.GetSubjectTitles(subject) {
+			subjectCond = subjectCond.Or(builder.Eq{"LOWER(`door43_metadata`.subject)": strings.ToLower(title)})
+		}
 	}
 	return subjectCond
 }
 
+// GetGatewayLanguageCond gets the condition for the language to be, or not to be, a gateway language in the language
+// registry
+func GetGatewayLanguageCond(isGateway util.OptionalBool) builder.Cond {
+	if isGateway.IsNone() {
+		return nil
+	}
+	codes := dcs.GetGatewayLanguageCodes()
+	if isGateway.IsTrue() {
+		if len(codes) == 0 {
+			return builder.Expr("1 = 0")
+		}
+		return builder.In("`door43_metadata`.language", codes)
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return builder.NotIn("`door43_metadata`.language", codes)
+}
+
 // GetLanguageCond gets the language condition
 func GetLanguageCond(languages []string) builder.Cond {
 	var langCond = builder.NewCond()
 	for _, lang := range languages {
 		for _, v := range strings.Split(lang, ",") {
-			langCond = langCond.Or(builder.Eq{"LOWER(REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.language.identifier'), '\"', ''))": strings.ToLower(v)})
+			langCond = langCond.Or(builder.Eq{"LOWER(`door43_metadata`.language)": strings.ToLower(v)})
 		}
 	}
 	return langCond
@@ -289,7 +421,7 @@ func GetBookCond(books []string) builder.Cond {
 	var bookCond = builder.NewCond()
 	for _, book := range books {
 		for _, v := range strings.Split(book, ",") {
-			bookCond = bookCond.Or(builder.Expr("JSON_CONTAINS(LOWER(JSON_EXTRACT(`door43_metadata`.metadata, '$.projects')), JSON_OBJECT('identifier', ?))", strings.ToLower(v)))
+			bookCond = bookCond.Or(builder.Like{jsonTextExpr("`door43_metadata`.books"), `"` + strings.ToLower(v) + `"`})
 		}
 	}
 	return bookCond
@@ -300,12 +432,65 @@ func GetCheckingLevelCond(checkingLevels []string) builder.Cond {
 	var checkingCond = builder.NewCond()
 	for _, checking := range checkingLevels {
 		for _, v := range strings.Split(checking, ",") {
-			checkingCond = checkingCond.Or(builder.Gte{"REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.checking.checking_level'), '\"', '')": v})
+			level, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				// Not a valid checking level so nothing should match
+				checkingCond = checkingCond.Or(builder.Expr("1 = 0"))
+				continue
+			}
+			checkingCond = checkingCond.Or(builder.Gte{"`door43_metadata`.checking_level": level})
 		}
 	}
 	return checkingCond
 }
 
+// GetMetadataTypeCond gets the metadata type condition
+func GetMetadataTypeCond(types []string) builder.Cond {
+	var metadataTypeCond = builder.NewCond()
+	for _, metadataType := range types {
+		for _, v := range strings.Split(metadataType, ",") {
+			metadataTypeCond = metadataTypeCond.Or(builder.Eq{"`door43_metadata`.metadata_type": strings.ToLower(v)})
+		}
+	}
+	return metadataTypeCond
+}
+
+// GetVersionCond gets the condition for the version of the entries' release tags to satisfy the constraint, entries of
+// default branches and tags that are not versions never doing so
+func GetVersionCond(constraint dcs.VersionConstraint) builder.Cond {
+	if constraint == nil {
+		return builder.NewCond()
+	}
+	versionCond := builder.NewCond()
+	for _, comparators := range constraint {
+		cond := builder.NewCond()
+		for _, comparator := range comparators {
+			switch comparator.Op {
+			case "=":
+				cond = cond.And(builder.Eq{"`door43_metadata`.version_key": comparator.Key})
+			case ">":
+				cond = cond.And(builder.Gt{"`door43_metadata`.version_key": comparator.Key})
+			case ">=":
+				cond = cond.And(builder.Gte{"`door43_metadata`.version_key": comparator.Key})
+			case "<":
+				cond = cond.And(builder.Lt{"`door43_metadata`.version_key": comparator.Key})
+			case "<=":
+				cond = cond.And(builder.Lte{"`door43_metadata`.version_key": comparator.Key})
+			}
+		}
+		versionCond = versionCond.Or(cond)
+	}
+	return builder.And(builder.Gt{"`door43_metadata`.version_key": dcs.UnknownVersionKey}, versionCond)
+}
+
+// GetExcludeMetadataTypeCond gets the condition excluding the metadata types
+func GetExcludeMetadataTypeCond(types []string) builder.Cond {
+	if len(types) == 0 {
+		return builder.NewCond()
+	}
+	return builder.NotIn("`door43_metadata`.metadata_type", types)
+}
+
 // GetTagCond gets the tag condition
 func GetTagCond(tags []string) builder.Cond {
 	var tagCond = builder.NewCond()
@@ -317,6 +502,17 @@ func GetTagCond(tags []string) builder.Cond {
 	return tagCond
 }
 
+// GetBranchCond gets the condition of the entries of the default branch or other branches with the given names
+func GetBranchCond(branches []string) builder.Cond {
+	var branchCond = builder.NewCond()
+	for _, branch := range branches {
+		for _, v := range strings.Split(branch, ",") {
+			branchCond = branchCond.Or(builder.Eq{"`door43_metadata`.release_id": 0, "`door43_metadata`.branch_or_tag": v})
+		}
+	}
+	return branchCond
+}
+
 // GetRepoCond gets the repo condition
 func GetRepoCond(repos []string) builder.Cond {
 	var repoCond = builder.NewCond()
@@ -0,0 +1,207 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/dcs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchCatalog_Actor(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	lastID, err := GetLatestDoor43MetadataChangeID()
+	assert.NoError(t, err)
+
+	for _, repoID := range []int64{1, 2} {
+		assert.NoError(t, InsertDoor43Metadata(&Door43Metadata{
+			RepoID:          repoID,
+			MetadataType:    MetadataTypeRC,
+			MetadataVersion: "rc0.2",
+			Metadata:        &map[string]interface{}{},
+			Stage:           StageLatest,
+			BranchOrTag:     "master",
+		}))
+	}
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	other := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	for _, test := range []struct {
+		actor   *User
+		repoIDs []int64
+	}{
+		{nil, []int64{1}},
+		{other, []int64{1}},
+		{owner, []int64{1, 2}},
+		{admin, []int64{1, 2}},
+	} {
+		dms, count, err := SearchCatalog(&SearchCatalogOptions{Owners: []string{"user2"}, Stage: StageLatest, Actor: test.actor})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(test.repoIDs), count)
+		repoIDs := make([]int64, 0, len(dms))
+		for _, dm := range dms {
+			repoIDs = append(repoIDs, dm.RepoID)
+		}
+		assert.ElementsMatch(t, test.repoIDs, repoIDs)
+
+		changes, err := FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{AfterID: lastID, Actor: test.actor})
+		assert.NoError(t, err)
+		assert.Len(t, changes, len(test.repoIDs))
+	}
+
+	_, err = DeleteAllDoor43MetadatasByRepoID(2, DeleteReasonArchived)
+	assert.NoError(t, err)
+	tombstones, _, err := FindDoor43MetadataTombstones(FindDoor43MetadataTombstonesOptions{OwnerName: "user2"})
+	assert.NoError(t, err)
+	assert.Len(t, tombstones, 0)
+	tombstones, _, err = FindDoor43MetadataTombstones(FindDoor43MetadataTombstonesOptions{OwnerName: "user2", Actor: owner})
+	assert.NoError(t, err)
+	if assert.Len(t, tombstones, 1) {
+		assert.True(t, tombstones[0].IsPrivate)
+	}
+
+	_, err = DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+}
+
+func TestSearchCatalog_Branches(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, dm := range []*Door43Metadata{
+		{RepoID: 1, Stage: StageLatest, BranchOrTag: "master", ReleaseDateUnix: 1},
+		{RepoID: 1, Stage: StageBranch, BranchOrTag: "review", ReleaseDateUnix: 2},
+		{RepoID: 1, Stage: StageBranch, BranchOrTag: "checking", ReleaseDateUnix: 3},
+		// only another branch of the repo is in the catalog
+		{RepoID: 4, Stage: StageBranch, BranchOrTag: "review", ReleaseDateUnix: 1},
+	} {
+		dm.MetadataType = MetadataTypeRC
+		dm.MetadataVersion = "rc0.2"
+		dm.Metadata = &map[string]interface{}{}
+		assert.NoError(t, InsertDoor43Metadata(dm))
+	}
+
+	dm, err := GetDoor43MetadataByRepoIDAndReleaseID(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "master", dm.BranchOrTag)
+	dm, err = GetDoor43MetadataByRepoIDAndBranch(1, "review")
+	assert.NoError(t, err)
+	assert.Equal(t, StageBranch, dm.Stage)
+	_, err = GetDoor43MetadataByRepoIDAndBranch(1, "master")
+	assert.True(t, IsErrDoor43MetadataNotExist(err))
+	branches, err := GetDoor43MetadataBranchNames(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"checking", "review"}, branches)
+
+	for _, test := range []struct {
+		opts     *SearchCatalogOptions
+		branches []string
+	}{
+		// the other branches don't replace the latest entry of their repo
+		{&SearchCatalogOptions{Stage: StageLatest}, []string{"master"}},
+		{&SearchCatalogOptions{Stage: StageBranch}, []string{"master", "review", "checking", "review"}},
+		{&SearchCatalogOptions{Stage: StageBranch, Branches: []string{"review"}}, []string{"review", "review"}},
+		{&SearchCatalogOptions{Stage: StageBranch, Branches: []string{"master,checking"}}, []string{"master", "checking"}},
+	} {
+		dms, count, err := SearchCatalog(test.opts)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(test.branches), count)
+		branches := make([]string, 0, len(dms))
+		for _, dm := range dms {
+			branches = append(branches, dm.BranchOrTag)
+		}
+		assert.ElementsMatch(t, test.branches, branches)
+	}
+
+	_, err = DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+	_, err = DeleteAllDoor43MetadatasByRepoID(4, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+}
+
+func TestSearchCatalog_LatestVersion(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, dm := range []*Door43Metadata{
+		{Stage: StageProd, BranchOrTag: "v2", ReleaseDateUnix: 1},
+		// a patch of an old version released after the new version
+		{Stage: StageProd, BranchOrTag: "v1.1", ReleaseDateUnix: 2},
+		{Stage: StagePreProd, BranchOrTag: "v3-rc1", ReleaseDateUnix: 3},
+		{Stage: StageLatest, BranchOrTag: "master", ReleaseDateUnix: 0},
+	} {
+		dm.RepoID = 1
+		dm.MetadataType = MetadataTypeRC
+		dm.MetadataVersion = "rc0.2"
+		dm.Metadata = &map[string]interface{}{}
+		if dm.Stage != StageLatest {
+			dm.VersionKey = dcs.GetTagVersionKey(dm.BranchOrTag)
+		}
+		assert.NoError(t, InsertDoor43Metadata(dm))
+	}
+
+	for _, test := range []struct {
+		opts   *SearchCatalogOptions
+		latest string
+	}{
+		{&SearchCatalogOptions{Stage: StageProd}, "v2"},
+		{&SearchCatalogOptions{Stage: StagePreProd}, "v3-rc1"},
+		// the default branch is returned instead of the releases whatever their version
+		{&SearchCatalogOptions{Stage: StageLatest}, "master"},
+		{&SearchCatalogOptions{Stage: StageProd, Version: mustParseVersionConstraint(t, "1.x")}, "v1.1"},
+	} {
+		dms, _, err := SearchCatalog(test.opts)
+		assert.NoError(t, err)
+		if assert.Len(t, dms, 1) {
+			assert.Equal(t, test.latest, dms[0].BranchOrTag)
+		}
+	}
+
+	_, err := DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonRepoDeleted)
+	assert.NoError(t, err)
+}
+
+func TestSearchCatalog_Pages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// entries released at the same time are paged in the order they were added
+	ids := make([]int64, 0, 3)
+	for _, repoID := range []int64{1, 4, 8} {
+		dm := &Door43Metadata{
+			RepoID:          repoID,
+			MetadataType:    MetadataTypeRC,
+			MetadataVersion: "rc0.2",
+			Metadata:        &map[string]interface{}{},
+			Stage:           StageProd,
+			BranchOrTag:     "v1",
+			ReleaseDateUnix: 1,
+		}
+		assert.NoError(t, InsertDoor43Metadata(dm))
+		ids = append(ids, dm.ID)
+	}
+
+	paged := make([]int64, 0, 3)
+	for page := 1; page <= 3; page++ {
+		dms, count, err := SearchCatalog(&SearchCatalogOptions{
+			ListOptions: ListOptions{Page: page, PageSize: 1},
+			Stage:       StageProd,
+			OrderBy:     []CatalogOrderBy{CatalogOrderByOldest},
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, count)
+		if assert.Len(t, dms, 1) {
+			paged = append(paged, dms[0].ID)
+		}
+	}
+	assert.Equal(t, ids, paged)
+}
+
+func mustParseVersionConstraint(t *testing.T, s string) dcs.VersionConstraint {
+	c, err := dcs.ParseVersionConstraint(s)
+	assert.NoError(t, err)
+	return c
+}
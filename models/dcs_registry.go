@@ -0,0 +1,451 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// dcsLanguageInsertBatchSize is how many languages are inserted at a time when seeding, small enough to stay under
+// the bound parameter limit of every database
+const dcsLanguageInsertBatchSize = 200
+
+// DCSSubject is a subject of the subject registry, seeded from dcs.BuiltinSubjects and editable by site admins
+type DCSSubject struct {
+	ID         int64  `xorm:"pk autoincr"`
+	ResourceID string `xorm:"UNIQUE NOT NULL"`
+	Title      string `xorm:"NOT NULL"`
+	// AliasOf is the resource ID of the subject this is another name for, "" if it is not an alias
+	AliasOf      string             `xorm:"NOT NULL DEFAULT ''"`
+	IsDeprecated bool               `xorm:"NOT NULL DEFAULT false"`
+	IsBuiltin    bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix  timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix  timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// DCSLanguage is a language of the language registry, seeded from langnames.json. Once a site admin overrides its
+// name, direction or gateway flag, it is no longer updated when langnames.json is refreshed.
+type DCSLanguage struct {
+	ID             int64  `xorm:"pk autoincr"`
+	Code           string `xorm:"UNIQUE NOT NULL"`
+	Name           string
+	AnglicizedName string
+	Direction      string             `xorm:"VARCHAR(3) NOT NULL DEFAULT 'ltr'"`
+	IsGateway      bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	IsOverridden   bool               `xorm:"NOT NULL DEFAULT false"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// ToDCS returns the subject as a subject of the in-memory registry
+func (s *DCSSubject) ToDCS() *dcs.Subject {
+	return &dcs.Subject{
+		ResourceID:   s.ResourceID,
+		Title:        s.Title,
+		AliasOf:      s.AliasOf,
+		IsDeprecated: s.IsDeprecated,
+	}
+}
+
+// ToDCS returns the language as a language of the in-memory registry
+func (l *DCSLanguage) ToDCS() *dcs.Language {
+	return &dcs.Language{
+		Code:           l.Code,
+		Name:           l.Name,
+		AnglicizedName: l.AnglicizedName,
+		Direction:      l.Direction,
+		IsGateway:      l.IsGateway,
+	}
+}
+
+// IsValidLanguageDirection returns true if the direction is ltr or rtl
+func IsValidLanguageDirection(direction string) bool {
+	return direction == "ltr" || direction == "rtl"
+}
+
+// InitDCSRegistries seeds the subject and language registries from the built-in values and loads them
+func InitDCSRegistries() error {
+	if err := seedDCSSubjects(); err != nil {
+		return err
+	}
+	if err := syncDCSLanguages(); err != nil {
+		return err
+	}
+	if err := loadDCSSubjects(); err != nil {
+		return err
+	}
+	return loadDCSLanguages()
+}
+
+// seedDCSSubjects adds the built-in subjects that are not in the subject registry, leaving the others as they were
+// edited
+func seedDCSSubjects() error {
+	existing := make(map[string]bool)
+	if err := x.Cols("resource_id").Iterate(new(DCSSubject), func(_ int, bean interface{}) error {
+		existing[bean.(*DCSSubject).ResourceID] = true
+		return nil
+	}); err != nil {
+		return err
+	}
+	var subjects []*DCSSubject
+	for resourceID, title := range dcs.BuiltinSubjects {
+		if !existing[resourceID] {
+			subjects = append(subjects, &DCSSubject{ResourceID: resourceID, Title: title, IsBuiltin: true})
+		}
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+	_, err := x.Insert(subjects)
+	return err
+}
+
+// syncDCSLanguages adds the languages of langnames.json that are not in the language registry and updates the ones
+// that are not overridden
+func syncDCSLanguages() error {
+	existing := make(map[string]*DCSLanguage)
+	if err := x.Iterate(new(DCSLanguage), func(_ int, bean interface{}) error {
+		l := bean.(*DCSLanguage)
+		existing[l.Code] = l
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var inserts []*DCSLanguage
+	for _, builtin := range dcs.GetBuiltinLanguages() {
+		l, ok := existing[builtin.Code]
+		if !ok {
+			inserts = append(inserts, &DCSLanguage{
+				Code:           builtin.Code,
+				Name:           builtin.Name,
+				AnglicizedName: builtin.AnglicizedName,
+				Direction:      builtin.Direction,
+				IsGateway:      builtin.IsGateway,
+			})
+			continue
+		}
+		if l.IsOverridden || *l.ToDCS() == *builtin {
+			continue
+		}
+		l.Name, l.AnglicizedName, l.Direction, l.IsGateway = builtin.Name, builtin.AnglicizedName, builtin.Direction, builtin.IsGateway
+		if _, err := x.ID(l.ID).Cols("name", "anglicized_name", "direction", "is_gateway").Update(l); err != nil {
+			return err
+		}
+	}
+	for len(inserts) > 0 {
+		n := dcsLanguageInsertBatchSize
+		if n > len(inserts) {
+			n = len(inserts)
+		}
+		if _, err := x.Insert(inserts[:n]); err != nil {
+			return err
+		}
+		inserts = inserts[n:]
+	}
+	return nil
+}
+
+// SyncDCSLanguages updates the language registry from langnames.json, e.g. after it is refreshed, and reloads it
+func SyncDCSLanguages() error {
+	if err := syncDCSLanguages(); err != nil {
+		return err
+	}
+	return loadDCSLanguages()
+}
+
+// loadDCSSubjects loads the subject registry into memory
+func loadDCSSubjects() error {
+	subjects, err := GetDCSSubjects()
+	if err != nil {
+		return err
+	}
+	dcsSubjects := make([]*dcs.Subject, len(subjects))
+	for i, s := range subjects {
+		dcsSubjects[i] = s.ToDCS()
+	}
+	dcs.SetSubjects(dcsSubjects)
+	return nil
+}
+
+// loadDCSLanguages loads the language registry into memory
+func loadDCSLanguages() error {
+	languages := make([]*dcs.Language, 0, 8000)
+	if err := x.Iterate(new(DCSLanguage), func(_ int, bean interface{}) error {
+		languages = append(languages, bean.(*DCSLanguage).ToDCS())
+		return nil
+	}); err != nil {
+		return err
+	}
+	dcs.SetLanguages(languages)
+	log.Trace("Loaded %d languages", len(languages))
+	return nil
+}
+
+// GetDCSSubjects returns the subjects of the subject registry sorted by resource ID
+func GetDCSSubjects() ([]*DCSSubject, error) {
+	subjects := make([]*DCSSubject, 0, len(dcs.BuiltinSubjects))
+	if err := x.OrderBy("resource_id").Find(&subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// GetDCSSubjectByResourceID returns the subject of the resource ID
+func GetDCSSubjectByResourceID(resourceID string) (*DCSSubject, error) {
+	s := &DCSSubject{}
+	has, err := x.Where("resource_id = ?", strings.ToLower(resourceID)).Get(s)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrDCSSubjectNotExist{resourceID}
+	}
+	return s, nil
+}
+
+// validateDCSSubject checks the resource ID and title of a subject and that it is an alias of a subject that is not
+// itself an alias, and that no subject is an alias of it if it is an alias
+func validateDCSSubject(e Engine, s *DCSSubject) error {
+	if !dcs.ResourceIDRegexp.MatchString(s.ResourceID) {
+		return ErrDCSSubjectInvalid{s.ResourceID, "invalid resource ID"}
+	}
+	if s.AliasOf == "" {
+		if strings.TrimSpace(s.Title) == "" {
+			return ErrDCSSubjectInvalid{s.ResourceID, "a subject that is not an alias needs a title"}
+		}
+		return nil
+	}
+	if s.AliasOf == s.ResourceID {
+		return ErrDCSSubjectInvalid{s.ResourceID, "a subject can not be an alias of itself"}
+	}
+	target := &DCSSubject{}
+	has, err := e.Where("resource_id = ?", s.AliasOf).Get(target)
+	if err != nil {
+		return err
+	} else if !has {
+		return ErrDCSSubjectInvalid{s.ResourceID, fmt.Sprintf("alias of %s, which does not exist", s.AliasOf)}
+	} else if target.AliasOf != "" {
+		return ErrDCSSubjectInvalid{s.ResourceID, fmt.Sprintf("alias of %s, which is itself an alias", s.AliasOf)}
+	}
+	aliases, err := e.Where("alias_of = ?", s.ResourceID).Count(new(DCSSubject))
+	if err != nil {
+		return err
+	} else if aliases > 0 {
+		return ErrDCSSubjectInvalid{s.ResourceID, "other subjects are aliases of it"}
+	}
+	return nil
+}
+
+// CreateDCSSubject adds a subject to the subject registry
+func CreateDCSSubject(s *DCSSubject) error {
+	s.ResourceID = strings.ToLower(strings.TrimSpace(s.ResourceID))
+	s.AliasOf = strings.ToLower(strings.TrimSpace(s.AliasOf))
+	s.IsBuiltin = false
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	has, err := sess.Where("resource_id = ?", s.ResourceID).Exist(new(DCSSubject))
+	if err != nil {
+		return err
+	} else if has {
+		return ErrDCSSubjectAlreadyExist{s.ResourceID}
+	}
+	if err := validateDCSSubject(sess, s); err != nil {
+		return err
+	}
+	if _, err := sess.Insert(s); err != nil {
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+	return loadDCSSubjects()
+}
+
+// UpdateDCSSubject updates the title, alias and deprecation of a subject of the subject registry
+func UpdateDCSSubject(s *DCSSubject) error {
+	s.AliasOf = strings.ToLower(strings.TrimSpace(s.AliasOf))
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if err := validateDCSSubject(sess, s); err != nil {
+		return err
+	}
+	if _, err := sess.ID(s.ID).Cols("title", "alias_of", "is_deprecated").Update(s); err != nil {
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+	return loadDCSSubjects()
+}
+
+// SearchDCSLanguagesOptions are the options to search the language registry
+type SearchDCSLanguagesOptions struct {
+	ListOptions
+	Keyword      string
+	IsGateway    bool
+	IsOverridden bool
+}
+
+// SearchDCSLanguages returns the languages of the language registry matching the options sorted by code, and how
+// many there are
+func SearchDCSLanguages(opts *SearchDCSLanguagesOptions) ([]*DCSLanguage, int64, error) {
+	cond := builder.NewCond()
+	if opts.Keyword != "" {
+		keyword := strings.ToLower(opts.Keyword)
+		cond = cond.And(builder.Or(
+			builder.Eq{"code": keyword},
+			builder.Like{"LOWER(name)", keyword},
+			builder.Like{"LOWER(anglicized_name)", keyword}))
+	}
+	if opts.IsGateway {
+		cond = cond.And(builder.Eq{"is_gateway": true})
+	}
+	if opts.IsOverridden {
+		cond = cond.And(builder.Eq{"is_overridden": true})
+	}
+
+	count, err := x.Where(cond).Count(new(DCSLanguage))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.setDefaultValues()
+	languages := make([]*DCSLanguage, 0, opts.PageSize)
+	if err := x.Where(cond).OrderBy("code").Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&languages); err != nil {
+		return nil, 0, err
+	}
+	return languages, count, nil
+}
+
+// GetDCSLanguageByCode returns the language of the code
+func GetDCSLanguageByCode(code string) (*DCSLanguage, error) {
+	l := &DCSLanguage{}
+	has, err := x.Where("code = ?", code).Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrDCSLanguageNotExist{code}
+	}
+	return l, nil
+}
+
+// OverrideDCSLanguage saves the name, direction and gateway flag of a language as set by a site admin, so they are
+// kept when langnames.json is refreshed
+func OverrideDCSLanguage(l *DCSLanguage) error {
+	if !IsValidLanguageDirection(l.Direction) {
+		return ErrDCSLanguageInvalidDirection{l.Code, l.Direction}
+	}
+	l.IsOverridden = true
+	if _, err := x.ID(l.ID).Cols("name", "anglicized_name", "direction", "is_gateway", "is_overridden").Update(l); err != nil {
+		return err
+	}
+	return loadDCSLanguages()
+}
+
+// ResetDCSLanguage drops the override of a language, restoring it from langnames.json if it is there
+func ResetDCSLanguage(l *DCSLanguage) error {
+	for _, builtin := range dcs.GetBuiltinLanguages() {
+		if builtin.Code == l.Code {
+			l.Name, l.AnglicizedName, l.Direction, l.IsGateway = builtin.Name, builtin.AnglicizedName, builtin.Direction, builtin.IsGateway
+			break
+		}
+	}
+	l.IsOverridden = false
+	if _, err := x.ID(l.ID).Cols("name", "anglicized_name", "direction", "is_gateway", "is_overridden").Update(l); err != nil {
+		return err
+	}
+	return loadDCSLanguages()
+}
+
+// ErrDCSSubjectNotExist represents a "DCSSubjectNotExist" kind of error.
+type ErrDCSSubjectNotExist struct {
+	ResourceID string
+}
+
+// IsErrDCSSubjectNotExist checks if an error is a ErrDCSSubjectNotExist.
+func IsErrDCSSubjectNotExist(err error) bool {
+	_, ok := err.(ErrDCSSubjectNotExist)
+	return ok
+}
+
+func (err ErrDCSSubjectNotExist) Error() string {
+	return fmt.Sprintf("subject does not exist [resource_id: %s]", err.ResourceID)
+}
+
+// ErrDCSSubjectAlreadyExist represents a "DCSSubjectAlreadyExist" kind of error.
+type ErrDCSSubjectAlreadyExist struct {
+	ResourceID string
+}
+
+// IsErrDCSSubjectAlreadyExist checks if an error is a ErrDCSSubjectAlreadyExist.
+func IsErrDCSSubjectAlreadyExist(err error) bool {
+	_, ok := err.(ErrDCSSubjectAlreadyExist)
+	return ok
+}
+
+func (err ErrDCSSubjectAlreadyExist) Error() string {
+	return fmt.Sprintf("subject already exists [resource_id: %s]", err.ResourceID)
+}
+
+// ErrDCSSubjectInvalid represents a "DCSSubjectInvalid" kind of error.
+type ErrDCSSubjectInvalid struct {
+	ResourceID string
+	Reason     string
+}
+
+// IsErrDCSSubjectInvalid checks if an error is a ErrDCSSubjectInvalid.
+func IsErrDCSSubjectInvalid(err error) bool {
+	_, ok := err.(ErrDCSSubjectInvalid)
+	return ok
+}
+
+func (err ErrDCSSubjectInvalid) Error() string {
+	return fmt.Sprintf("subject is invalid [resource_id: %s, reason: %s]", err.ResourceID, err.Reason)
+}
+
+// ErrDCSLanguageNotExist represents a "DCSLanguageNotExist" kind of error.
+type ErrDCSLanguageNotExist struct {
+	Code string
+}
+
+// IsErrDCSLanguageNotExist checks if an error is a ErrDCSLanguageNotExist.
+func IsErrDCSLanguageNotExist(err error) bool {
+	_, ok := err.(ErrDCSLanguageNotExist)
+	return ok
+}
+
+func (err ErrDCSLanguageNotExist) Error() string {
+	return fmt.Sprintf("language does not exist [code: %s]", err.Code)
+}
+
+// ErrDCSLanguageInvalidDirection represents a "DCSLanguageInvalidDirection" kind of error.
+type ErrDCSLanguageInvalidDirection struct {
+	Code      string
+	Direction string
+}
+
+// IsErrDCSLanguageInvalidDirection checks if an error is a ErrDCSLanguageInvalidDirection.
+func IsErrDCSLanguageInvalidDirection(err error) bool {
+	_, ok := err.(ErrDCSLanguageInvalidDirection)
+	return ok
+}
+
+func (err ErrDCSLanguageInvalidDirection) Error() string {
+	return fmt.Sprintf("language direction must be ltr or rtl [code: %s, direction: %s]", err.Code, err.Direction)
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/dcs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDCSSubjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer dcs.SetSubjects(nil)
+
+	assert.NoError(t, seedDCSSubjects())
+	assert.NoError(t, loadDCSSubjects())
+	subjects, err := GetDCSSubjects()
+	assert.NoError(t, err)
+	assert.Len(t, subjects, len(dcs.BuiltinSubjects))
+
+	// Seeding again keeps the edited subjects
+	tn, err := GetDCSSubjectByResourceID("tn")
+	assert.NoError(t, err)
+	tn.Title = "Notes"
+	assert.NoError(t, UpdateDCSSubject(tn))
+	assert.NoError(t, seedDCSSubjects())
+	tn, err = GetDCSSubjectByResourceID("tn")
+	assert.NoError(t, err)
+	assert.Equal(t, "Notes", tn.Title)
+	assert.True(t, tn.IsBuiltin)
+
+	assert.True(t, IsErrDCSSubjectAlreadyExist(CreateDCSSubject(&DCSSubject{ResourceID: "tn", Title: "Notes"})))
+	assert.True(t, IsErrDCSSubjectInvalid(CreateDCSSubject(&DCSSubject{ResourceID: "Not Valid", Title: "Not Valid"})))
+	assert.True(t, IsErrDCSSubjectInvalid(CreateDCSSubject(&DCSSubject{ResourceID: "tn-x"})))
+	assert.True(t, IsErrDCSSubjectInvalid(CreateDCSSubject(&DCSSubject{ResourceID: "tn-x", AliasOf: "missing"})))
+
+	// An alias is recognized in repo names as the subject it is an alias of
+	assert.NoError(t, CreateDCSSubject(&DCSSubject{ResourceID: "tn-x", Title: "Extra Notes", AliasOf: "tn"}))
+	assert.Equal(t, "Notes", dcs.GetSubjectFromRepoName("en_tn-x"))
+	assert.ElementsMatch(t, []string{"Notes", "Extra Notes"}, dcs.GetSubjectTitles("tn"))
+	assert.True(t, IsErrDCSSubjectInvalid(CreateDCSSubject(&DCSSubject{ResourceID: "tn-y", AliasOf: "tn-x"})))
+	tn.AliasOf = "tq"
+	assert.True(t, IsErrDCSSubjectInvalid(UpdateDCSSubject(tn)))
+
+	// A deprecated subject is no longer recognized in repo names
+	tn.AliasOf = ""
+	tn.IsDeprecated = true
+	assert.NoError(t, UpdateDCSSubject(tn))
+	assert.Empty(t, dcs.GetSubjectFromRepoName("en_tn"))
+}
@@ -7,15 +7,16 @@ package models
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"github.com/unknwon/com"
 	"xorm.io/builder"
-	"xorm.io/xorm/schemas"
 )
 
 // Door43Metadata represents the metadata of repository's release or default branch (ReleaseID = 0).
@@ -25,15 +26,50 @@ type Door43Metadata struct {
 	Repo            *Repository             `xorm:"-"`
 	ReleaseID       int64                   `xorm:"INDEX UNIQUE(n)"`
 	Release         *Release                `xorm:"-"`
+	MetadataType    string                  `xorm:"INDEX"`
 	MetadataVersion string                  `xorm:"NOT NULL"`
 	Metadata        *map[string]interface{} `xorm:"JSON NOT NULL"`
-	Stage           Stage                   `xorm:"NOT NULL"`
-	BranchOrTag     string                  `xorm:"NOT NULL"`
-	ReleaseDateUnix timeutil.TimeStamp      `xorm:"NOT NULL"`
-	CreatedUnix     timeutil.TimeStamp      `xorm:"INDEX created NOT NULL"`
-	UpdatedUnix     timeutil.TimeStamp      `xorm:"INDEX updated"`
+	Title           string                  `xorm:"INDEX"`
+	Subject         string                  `xorm:"INDEX"`
+	Language        string                  `xorm:"INDEX"`
+	LanguageTitle   string
+	LanguageDir     string
+	CheckingLevel   int                `xorm:"INDEX"`
+	Books           []string           `xorm:"TEXT JSON"`
+	Ingredients     []interface{}      `xorm:"TEXT JSON"`
+	Relations       []string           `xorm:"TEXT JSON"`
+	Stage           Stage              `xorm:"NOT NULL"`
+	BranchOrTag     string             `xorm:"UNIQUE(n) NOT NULL"`
+	VersionKey      string             `xorm:"INDEX NOT NULL DEFAULT ''"`
+	ReleaseDateUnix timeutil.TimeStamp `xorm:"NOT NULL"`
+	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created NOT NULL"`
+	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// Metadata types of the files that can put a repo into the catalog
+const (
+	MetadataTypeRC = "rc" // Resource Container, manifest.yaml
+	MetadataTypeSB = "sb" // Scripture Burrito, metadata.json
+	MetadataTypeTS = "ts" // translationStudio project, manifest.json
+	MetadataTypeTC = "tc" // translationCore project, manifest.json
+)
+
+// MetadataTypeFilenames are the names of the metadata file at the root of the repo for each metadata type
+var MetadataTypeFilenames = map[string]string{
+	MetadataTypeRC: "manifest.yaml",
+	MetadataTypeSB: "metadata.json",
+	MetadataTypeTS: "manifest.json",
+	MetadataTypeTC: "manifest.json",
 }
 
+// ProjectMetadataTypes are the metadata types of in-progress translation projects rather than released resources
+var ProjectMetadataTypes = []string{MetadataTypeTS, MetadataTypeTC}
+
+// CatalogColumns are the columns normalized from Metadata by its metadata type so the catalog can be searched and sorted the same way
+// for all types and without any database specific JSON functions
+var CatalogColumns = []string{"metadata_type", "metadata_version", "title", "subject", "language", "language_title", "language_dir",
+	"checking_level", "books", "ingredients", "relations"}
+
 // GetRepo gets the repo associated with the door43 metadata entry
 func (dm *Door43Metadata) GetRepo() error {
 	return dm.getRepo(x)
@@ -158,30 +194,38 @@ func (dm *Door43Metadata) GetReleaseURL() string {
 	return ""
 }
 
-// GetMetadataURL gets the url to the raw manifest.yaml file
+// GetMetadataFilename gets the name of the metadata file of the entry's metadata type, e.g. manifest.yaml
+func (dm *Door43Metadata) GetMetadataFilename() string {
+	if filename, ok := MetadataTypeFilenames[dm.MetadataType]; ok {
+		return filename
+	}
+	return MetadataTypeFilenames[MetadataTypeRC]
+}
+
+// GetMetadataURL gets the url to the raw metadata file (e.g. manifest.yaml)
 func (dm *Door43Metadata) GetMetadataURL() string {
-	return fmt.Sprintf("%s/raw/%s/%s/manifest.yaml", dm.Repo.HTMLURL(), dm.GetBranchOrTagType(), dm.BranchOrTag)
+	return fmt.Sprintf("%s/raw/%s/%s/%s", dm.Repo.HTMLURL(), dm.GetBranchOrTagType(), dm.BranchOrTag, dm.GetMetadataFilename())
+}
+
+// GetFileURL gets the url of a file of the tag or branch on the web UI, or of its root directory if filePath is empty
+func (dm *Door43Metadata) GetFileURL(filePath string) string {
+	return strings.TrimSuffix(fmt.Sprintf("%s/src/%s/%s/%s", dm.Repo.HTMLURL(), dm.GetBranchOrTagType(),
+		util.PathEscapeSegments(dm.BranchOrTag), util.PathEscapeSegments(filePath)), "/")
 }
 
-// GetMetadataJSONURL gets the json representation of the contents of the manifest.yaml file
+// GetMetadataJSONURL gets the json representation of the contents of the metadata file
 func (dm *Door43Metadata) GetMetadataJSONURL() string {
 	return fmt.Sprintf("%s/metadata", dm.APIURLLatest())
 }
 
-// GetMetadataAPIContentsURL gets the metadata API contents URL of the manifest.yaml file
+// GetMetadataAPIContentsURL gets the metadata API contents URL of the metadata file
 func (dm *Door43Metadata) GetMetadataAPIContentsURL() string {
-	return fmt.Sprintf("%s/contents/manifest.yaml?ref=%s", dm.Repo.APIURL(), dm.BranchOrTag)
+	return fmt.Sprintf("%s/contents/%s?ref=%s", dm.Repo.APIURL(), dm.GetMetadataFilename(), dm.BranchOrTag)
 }
 
 // GetBooks get the books of the resource
 func (dm *Door43Metadata) GetBooks() []string {
-	var books []string
-	if len((*dm.Metadata)["projects"].([]interface{})) > 0 {
-		for _, prod := range (*dm.Metadata)["projects"].([]interface{}) {
-			books = append(books, prod.(map[string]interface{})["identifier"].(string))
-		}
-	}
-	return books
+	return dm.Books
 }
 
 // IsDoor43MetadataExist returns true if door43 metadata with given release ID already exists.
@@ -189,11 +233,26 @@ func IsDoor43MetadataExist(repoID, releaseID int64) (bool, error) {
 	return x.Get(&Door43Metadata{RepoID: repoID, ReleaseID: releaseID})
 }
 
-// InsertDoor43Metadata inserts a door43 metadata
+// InsertDoor43Metadata inserts a door43 metadata, recording its catalog change and relations and removing its
+// tombstone in the same transaction
 func InsertDoor43Metadata(dm *Door43Metadata) error {
-	if id, err := x.Insert(dm); err != nil {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
 		return err
-	} else if id > 0 && dm.ReleaseID > 0 {
+	}
+	id, err := sess.Insert(dm)
+	if err != nil {
+		return err
+	}
+	if err := insertedDoor43Metadatas(sess, dm); err != nil {
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	if id > 0 && dm.ReleaseID > 0 {
 		if err := dm.LoadAttributes(); err != nil {
 			return err
 		}
@@ -206,27 +265,66 @@ func InsertDoor43Metadata(dm *Door43Metadata) error {
 
 // InsertDoor43MetadatasContext inserts door43 metadatas
 func InsertDoor43MetadatasContext(ctx DBContext, dms []*Door43Metadata) error {
-	_, err := ctx.e.Insert(dms)
-	return err
+	if _, err := ctx.e.Insert(dms); err != nil {
+		return err
+	}
+	return insertedDoor43Metadatas(ctx.e, dms...)
 }
 
-// UpdateDoor43MetadataCols update door43 metadata according special columns
-func UpdateDoor43MetadataCols(dm *Door43Metadata, cols ...string) error {
-	return updateDoor43MetadataCols(x, dm, cols...)
+// insertedDoor43Metadatas records the catalog changes and relations of inserted door43 metadatas and removes their
+// tombstones
+func insertedDoor43Metadatas(e Engine, dms ...*Door43Metadata) error {
+	if err := addDoor43MetadataChanges(e, Door43MetadataChangeCreated, dms...); err != nil {
+		return err
+	}
+	if err := updateDoor43MetadataRelations(e, dms...); err != nil {
+		return err
+	}
+	return removeDoor43MetadataTombstones(e, dms...)
 }
 
-func updateDoor43MetadataCols(e Engine, dm *Door43Metadata, cols ...string) error {
-	id, err := e.ID(dm.ID).Cols(cols...).Update(dm)
+// UpdateDoor43MetadataCols update door43 metadata according special columns, recording its catalog change and
+// relations in the same transaction
+func UpdateDoor43MetadataCols(dm *Door43Metadata, cols ...string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	id, err := updateDoor43MetadataCols(sess, dm, cols...)
+	if err != nil {
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
 	if id > 0 && dm.ReleaseID > 0 {
-		err := dm.LoadAttributes()
-		if err != nil {
+		if err := dm.LoadAttributes(); err != nil {
 			return err
 		}
 		if err := CreateRepositoryNotice("Door43 Metadata updated for repo: %s, tag: %s", dm.Repo.Name, dm.Release.TagName); err != nil {
 			log.Error("CreateRepositoryNotice: %v", err)
 		}
 	}
-	return err
+	return nil
+}
+
+func updateDoor43MetadataCols(e Engine, dm *Door43Metadata, cols ...string) (int64, error) {
+	for _, col := range cols {
+		if col == "metadata" {
+			cols = append(cols, CatalogColumns...)
+			break
+		}
+	}
+	id, err := e.ID(dm.ID).Cols(cols...).Update(dm)
+	if err != nil {
+		return 0, err
+	}
+	if err := addDoor43MetadataChanges(e, Door43MetadataChangeUpdated, dm); err != nil {
+		return 0, err
+	}
+	return id, updateDoor43MetadataRelations(e, dm)
 }
 
 // GetDoor43MetadataByRepoIDAndTagName returns metadata by given repo ID and tag name.
@@ -241,16 +339,20 @@ func GetDoor43MetadataByRepoIDAndTagName(repoID int64, tagName string) (*Door43M
 		releaseID = release.ID
 	}
 
-	isExist, err := IsDoor43MetadataExist(repoID, releaseID)
-	if err != nil {
-		return nil, err
-	} else if !isExist {
-		return nil, ErrDoor43MetadataNotExist{0, repoID, releaseID}
-	}
+	return getDoor43MetadataByRepoIDAndReleaseID(x, repoID, releaseID)
+}
 
-	dm := &Door43Metadata{RepoID: repoID, ReleaseID: releaseID}
-	_, err = x.Get(dm)
-	return dm, err
+// GetDoor43MetadataByRepoAndRef returns the metadata of a ref of a repo, which is either its default branch, another
+// branch it has in the catalog or the tag of a release
+func GetDoor43MetadataByRepoAndRef(repo *Repository, ref string) (*Door43Metadata, error) {
+	if ref == repo.DefaultBranch {
+		return GetDoor43MetadataByRepoIDAndReleaseID(repo.ID, 0)
+	}
+	dm, err := GetDoor43MetadataByRepoIDAndBranch(repo.ID, ref)
+	if err == nil || !IsErrDoor43MetadataNotExist(err) {
+		return dm, err
+	}
+	return GetDoor43MetadataByRepoIDAndTagName(repo.ID, ref)
 }
 
 // GetDoor43MetadataByID returns door43 metadata with given ID.
@@ -298,7 +400,8 @@ func GetDoor43MetadatasByRepoID(repoID int64, opts FindDoor43MetadatasOptions) (
 	return dms, sess.Find(&dms)
 }
 
-// GetLatestCatalogMetadataByRepoID returns the latest door43 metadata in the catalog by repoID, if canBePrerelease, a prerelease entry can match
+// GetLatestCatalogMetadataByRepoID returns the latest door43 metadata in the catalog by repoID, the one with the highest
+// version of its release tag and then the most recently released, if canBePrerelease, a prerelease entry can match
 func GetLatestCatalogMetadataByRepoID(repoID int64, canBePrerelease bool) (*Door43Metadata, error) {
 	return getLatestCatalogMetadataByRepoID(x, repoID, canBePrerelease)
 }
@@ -306,18 +409,18 @@ func GetLatestCatalogMetadataByRepoID(repoID int64, canBePrerelease bool) (*Door
 func getLatestCatalogMetadataByRepoID(e Engine, repoID int64, canBePrerelease bool) (*Door43Metadata, error) {
 	cond := builder.NewCond().
 		And(builder.Eq{"`door43_metadata`.repo_id": repoID}).
-		And(builder.Eq{"`release`.is_tag": 0}).
-		And(builder.Eq{"`release`.is_draft": 0})
+		And(builder.Eq{"`release`.is_tag": false}).
+		And(builder.Eq{"`release`.is_draft": false})
 
 	if !canBePrerelease {
-		cond = cond.And(builder.Eq{"`release`.is_prerelease": 0})
+		cond = cond.And(builder.Eq{"`release`.is_prerelease": false})
 	}
 
 	dm := new(Door43Metadata)
 	has, err := e.
 		Join("INNER", "release", "`release`.id = `door43_metadata`.release_id").
 		Where(cond).
-		Desc("`release`.created_unix", "`release`.id").
+		Desc("`door43_metadata`.version_key", "`release`.created_unix", "`release`.id").
 		Get(dm)
 
 	if err != nil {
@@ -361,8 +464,13 @@ func GetDoor43MetadataByRepoIDAndReleaseID(repoID, releaseID int64) (*Door43Meta
 }
 
 func getDoor43MetadataByRepoIDAndReleaseID(e Engine, repoID, releaseID int64) (*Door43Metadata, error) {
-	dm := &Door43Metadata{RepoID: repoID, ReleaseID: releaseID}
-	has, err := e.Get(dm)
+	cond := builder.Eq{"repo_id": repoID, "release_id": releaseID}
+	if releaseID == 0 {
+		// The default branch, not one of the other branches of the repo in the catalog
+		cond["stage"] = StageLatest
+	}
+	dm := new(Door43Metadata)
+	has, err := e.Where(cond).Get(dm)
 	if err != nil {
 		return nil, err
 	}
@@ -372,6 +480,31 @@ func getDoor43MetadataByRepoIDAndReleaseID(e Engine, repoID, releaseID int64) (*
 	return dm, err
 }
 
+// GetDoor43MetadataByRepoIDAndBranch returns the metadata of a branch of a repo the repo has in the catalog besides its
+// default branch
+func GetDoor43MetadataByRepoIDAndBranch(repoID int64, branch string) (*Door43Metadata, error) {
+	dm := new(Door43Metadata)
+	has, err := x.Where(builder.Eq{"repo_id": repoID, "release_id": 0, "stage": StageBranch, "branch_or_tag": branch}).Get(dm)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrDoor43MetadataNotExist{0, repoID, 0}
+	}
+	return dm, nil
+}
+
+// GetDoor43MetadataBranchNames returns the names of the branches of a repo the repo has in the catalog besides its
+// default branch
+func GetDoor43MetadataBranchNames(repoID int64) ([]string, error) {
+	branches := make([]string, 0, 5)
+	return branches, x.Table("door43_metadata").
+		Where(builder.Eq{"repo_id": repoID, "stage": StageBranch}).
+		Asc("branch_or_tag").
+		Cols("branch_or_tag").
+		Find(&branches)
+}
+
 // GetDoor43MetadataByRepoIDAndStage returns the metadata of a given repo ID and stage.
 func GetDoor43MetadataByRepoIDAndStage(repoID int64, stage Stage) (*Door43Metadata, error) {
 	return getDoor43MetadataByRepoIDAndStage(x, repoID, stage)
@@ -414,20 +547,32 @@ func SortDoorMetadatas(dms []*Door43Metadata) {
 	sort.Sort(sorter)
 }
 
-// DeleteDoor43MetadataByID deletes a metadata from database by given ID.
-func DeleteDoor43MetadataByID(id int64) error {
+// DeleteDoor43MetadataByID deletes a metadata from database by given ID, leaving a tombstone with the given reason.
+func DeleteDoor43MetadataByID(id int64, reason Door43MetadataDeleteReason) error {
 	if dm, err := GetDoor43MetadataByID(id); err != nil {
 		return err
 	} else if err := dm.LoadAttributes(); err != nil {
 		return err
 	} else {
-		return DeleteDoor43Metadata(dm)
+		return DeleteDoor43Metadata(dm, reason)
 	}
 }
 
-// DeleteDoor43Metadata deletes a metadata from database by given ID.
-func DeleteDoor43Metadata(dm *Door43Metadata) error {
-	id, err := x.Delete(dm)
+// DeleteDoor43Metadata deletes a metadata from database by given ID, leaving a tombstone with the given reason.
+func DeleteDoor43Metadata(dm *Door43Metadata, reason Door43MetadataDeleteReason) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	id, err := deleteDoor43Metadatas(sess, reason, dm)
+	if err != nil {
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
 	if id > 0 && dm.ReleaseID > 0 {
 		if err := dm.LoadAttributes(); err != nil {
 			return err
@@ -435,25 +580,67 @@ func DeleteDoor43Metadata(dm *Door43Metadata) error {
 			log.Error("CreateRepositoryNotice: %v", err)
 		}
 	}
-	return err
+	return nil
+}
+
+// deleteDoor43Metadatas deletes the door43 metadatas along with their relations, leaving a tombstone with the given
+// reason for each, and returns how many were deleted
+func deleteDoor43Metadatas(e Engine, reason Door43MetadataDeleteReason, dms ...*Door43Metadata) (int64, error) {
+	ids := make([]int64, len(dms))
+	for i, dm := range dms {
+		ids[i] = dm.ID
+	}
+	count, err := e.In("id", ids).Delete(new(Door43Metadata))
+	if err != nil || count == 0 {
+		return count, err
+	}
+	if err := deleteDoor43MetadataRelations(e, dms...); err != nil {
+		return count, err
+	}
+	return count, addDoor43MetadataTombstones(e, reason, dms...)
 }
 
-// DeleteDoor43MetadataByRelease deletes a metadata from database by given release.
+// DeleteDoor43MetadataByRelease deletes a metadata from database by given release, leaving a tombstone as the release
+// was deleted.
 func DeleteDoor43MetadataByRelease(release *Release) error {
-	dm, err := GetDoor43MetadataByRepoIDAndReleaseID(release.RepoID, release.ID)
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	dm, err := getDoor43MetadataByRepoIDAndReleaseID(sess, release.RepoID, release.ID)
 	if err != nil {
 		if !IsErrDoor43MetadataNotExist(err) {
 			return err
 		}
 		return nil
 	}
-	_, err = x.ID(dm.ID).Delete(dm)
-	return err
+	if _, err := deleteDoor43Metadatas(sess, DeleteReasonReleaseDeleted, dm); err != nil {
+		return err
+	}
+	return sess.Commit()
 }
 
-// DeleteAllDoor43MetadatasByRepoID deletes all metadatas from database for a repo by given repo ID.
-func DeleteAllDoor43MetadatasByRepoID(repoID int64) (int64, error) {
-	return x.Delete(Door43Metadata{RepoID: repoID})
+// DeleteAllDoor43MetadatasByRepoID deletes all metadatas from database for a repo by given repo ID, leaving a tombstone
+// with the given reason for each.
+func DeleteAllDoor43MetadatasByRepoID(repoID int64, reason Door43MetadataDeleteReason) (int64, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return 0, err
+	}
+	dms := make([]*Door43Metadata, 0, 10)
+	if err := sess.Where("repo_id = ?", repoID).Find(&dms); err != nil {
+		return 0, err
+	}
+	if len(dms) == 0 {
+		return 0, nil
+	}
+	count, err := deleteDoor43Metadatas(sess, reason, dms...)
+	if err != nil {
+		return 0, err
+	}
+	return count, sess.Commit()
 }
 
 // GetReposForMetadata gets the IDs of all the repos to process for metadata
@@ -507,11 +694,11 @@ func GetRepoReleaseIDsForMetadata(repoID int64) ([]int64, error) {
 	//	"ORDER BY id ASC", r.ID, r.ID)
 	records, err := sess.Query("SELECT rel.id as id FROM `repository` r "+
 		"INNER JOIN `release` rel ON rel.repo_id = r.id "+
-		"WHERE rel.is_tag = 0 AND r.id=? "+
+		"WHERE rel.is_tag = ? AND r.id=? "+
 		"UNION "+
 		"SELECT 0 as id FROM `repository` r2 "+
 		"WHERE r2.id=? "+
-		"ORDER BY id ASC", repoID, repoID)
+		"ORDER BY id ASC", false, repoID, repoID)
 	log.Trace(sess.LastSQL())
 	if err != nil {
 		return nil, err
@@ -564,6 +751,59 @@ func (r *Repository) GetDefaultBranchMetadata() (*Door43Metadata, error) {
 	return dm, nil
 }
 
+// UpdateDoor43MetadataCatalogColumns updates only the catalog columns of a door43 metadata, without creating a repository notice
+func UpdateDoor43MetadataCatalogColumns(dm *Door43Metadata) error {
+	if _, err := x.ID(dm.ID).Cols(CatalogColumns...).Update(dm); err != nil {
+		return err
+	}
+	if err := updateDoor43MetadataRelations(x, dm); err != nil {
+		return err
+	}
+	return addDoor43MetadataChanges(x, Door43MetadataChangeUpdated, dm)
+}
+
+// GetDoor43MetadatasWithoutCatalogColumns gets up to limit door43 metadatas with an ID greater than afterID
+// that were created before the catalog columns existed
+func GetDoor43MetadatasWithoutCatalogColumns(afterID int64, limit int) ([]*Door43Metadata, error) {
+	dms := make([]*Door43Metadata, 0, limit)
+	return dms, x.
+		Where(builder.Gt{"id": afterID}).
+		And(builder.IsNull{"metadata_type"}.Or(builder.Eq{"metadata_type": ""})).
+		Asc("id").
+		Limit(limit).
+		Find(&dms)
+}
+
+// UpdateDoor43MetadataVersionKey updates only the version key of a door43 metadata, without creating a repository
+// notice or a catalog change as it is only used to sort and filter the catalog
+func UpdateDoor43MetadataVersionKey(dm *Door43Metadata) error {
+	_, err := x.ID(dm.ID).Cols("version_key").Update(dm)
+	return err
+}
+
+// GetDoor43MetadatasWithoutVersionKey gets up to limit door43 metadatas of releases with an ID greater than afterID
+// that were created before the version key existed, with their releases loaded
+func GetDoor43MetadatasWithoutVersionKey(afterID int64, limit int) ([]*Door43Metadata, error) {
+	dms := make([]*Door43Metadata, 0, limit)
+	if err := x.
+		Where(builder.Gt{"id": afterID}).
+		And(builder.Gt{"release_id": 0}).
+		And(builder.Eq{"version_key": ""}).
+		Asc("id").
+		Limit(limit).
+		Find(&dms); err != nil {
+		return nil, err
+	}
+	for _, dm := range dms {
+		rel, err := GetReleaseByID(dm.ReleaseID)
+		if err != nil && !IsErrReleaseNotExist(err) {
+			return nil, err
+		}
+		dm.Release = rel
+	}
+	return dms, nil
+}
+
 /*** Error Structs & Functions ***/
 
 // ErrDoor43MetadataAlreadyExist represents a "Door43MetadataAlreadyExist" kind of error.
@@ -626,6 +866,7 @@ const (
 	StagePreProd Stage = 1
 	StageDraft   Stage = 2
 	StageLatest  Stage = 3
+	StageBranch  Stage = 4 // other branches the repo has in the catalog besides the default branch
 )
 
 // StageMap map from string to Stage (int)
@@ -634,6 +875,7 @@ var StageMap = map[string]Stage{
 	"preprod": StagePreProd,
 	"draft":   StageDraft,
 	"latest":  StageLatest,
+	"branch":  StageBranch,
 }
 
 // StageToStringMap map from stage (int) to string
@@ -642,6 +884,7 @@ var StageToStringMap = map[Stage]string{
 	StagePreProd: "preprod",
 	StageDraft:   "draft",
 	StageLatest:  "latest",
+	StageBranch:  "branch",
 }
 
 // String returns string repensation of a Stage (int)
@@ -650,19 +893,3 @@ func (s *Stage) String() string {
 }
 
 /*** END Stage ***/
-
-/*** INIT DB ***/
-
-// InitDoor43Metadata does some db management
-func InitDoor43Metadata() error {
-	switch x.Dialect().URI().DBType {
-	case schemas.MYSQL:
-		_, err := x.Exec("ALTER TABLE `door43_metadata` MODIFY `metadata` JSON")
-		if err != nil {
-			return fmt.Errorf("Error changing door43_metadata metadata column type: %v", err)
-		}
-	}
-	return nil
-}
-
-/*** END INIT DB ***/
@@ -0,0 +1,157 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"math"
+	"sort"
+
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// Door43MetadataBookStats are the statistics of the USFM file of a book of the Bible in a repo's release or default
+// branch, kept so translators can see how complete a translation is whether or not it is in the catalog
+type Door43MetadataBookStats struct {
+	ID               int64  `xorm:"pk autoincr"`
+	RepoID           int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Ref              string `xorm:"UNIQUE(s) NOT NULL"`
+	ReleaseID        int64  `xorm:"INDEX"`
+	CommitSHA        string `xorm:"VARCHAR(40)"`
+	Book             string `xorm:"UNIQUE(s) NOT NULL"`
+	Path             string
+	Chapters         int                `xorm:"NOT NULL DEFAULT 0"`
+	ExpectedChapters int                `xorm:"NOT NULL DEFAULT 0"`
+	Verses           int                `xorm:"NOT NULL DEFAULT 0"`
+	ExpectedVerses   int                `xorm:"NOT NULL DEFAULT 0"`
+	Words            int                `xorm:"NOT NULL DEFAULT 0"`
+	AlignedWords     int                `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix      timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// percent returns n as a percentage of total rounded to one decimal, 0 if total is 0
+func percent(n, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 10
+}
+
+// VersePercent returns the percentage of the verses of the versification that have text
+func (s *Door43MetadataBookStats) VersePercent() float64 {
+	return percent(s.Verses, s.ExpectedVerses)
+}
+
+// AlignmentPercent returns the percentage of the words that are aligned to the original language
+func (s *Door43MetadataBookStats) AlignmentPercent() float64 {
+	return percent(s.AlignedWords, s.Words)
+}
+
+// Door43MetadataBookStatsList is a list of the statistics of the books of a repo's release or default branch
+type Door43MetadataBookStatsList []*Door43MetadataBookStats
+
+// Total returns the sums of the statistics of all the books
+func (list Door43MetadataBookStatsList) Total() *Door43MetadataBookStats {
+	total := &Door43MetadataBookStats{}
+	for _, s := range list {
+		total.Chapters += s.Chapters
+		total.ExpectedChapters += s.ExpectedChapters
+		total.Verses += s.Verses
+		total.ExpectedVerses += s.ExpectedVerses
+		total.Words += s.Words
+		total.AlignedWords += s.AlignedWords
+	}
+	return total
+}
+
+// GetDoor43MetadataBookStats returns the statistics of the books of the given repo ID and ref in canonical order
+func GetDoor43MetadataBookStats(repoID int64, ref string) (Door43MetadataBookStatsList, error) {
+	stats := make(Door43MetadataBookStatsList, 0, 66)
+	if err := x.Where("repo_id = ? AND ref = ?", repoID, ref).Find(&stats); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return dcs.BookNumber(stats[i].Book) < dcs.BookNumber(stats[j].Book)
+	})
+	return stats, nil
+}
+
+// GetDoor43MetadataBookStatsCommitSHA returns the SHA of the commit the statistics of the books of the given repo ID
+// and ref were computed from, "" if there are none
+func GetDoor43MetadataBookStatsCommitSHA(repoID int64, ref string) (string, error) {
+	s := &Door43MetadataBookStats{}
+	has, err := x.Where("repo_id = ? AND ref = ?", repoID, ref).Cols("commit_sha").Get(s)
+	if err != nil || !has {
+		return "", err
+	}
+	return s.CommitSHA, nil
+}
+
+// GetDoor43MetadataBookStatsRefs returns the refs of the given repo ID that have statistics of their books, the
+// default branch first and then the releases from the newest
+func GetDoor43MetadataBookStatsRefs(repoID int64) ([]string, error) {
+	stats := make([]*Door43MetadataBookStats, 0, 10)
+	if err := x.Where("repo_id = ?", repoID).
+		Cols("ref", "release_id").
+		GroupBy("ref, release_id").
+		Find(&stats); err != nil {
+		return nil, err
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].ReleaseID == 0) != (stats[j].ReleaseID == 0) {
+			return stats[i].ReleaseID == 0
+		}
+		return stats[i].ReleaseID > stats[j].ReleaseID
+	})
+	refs := make([]string, len(stats))
+	for i, s := range stats {
+		refs[i] = s.Ref
+	}
+	return refs, nil
+}
+
+// HasDoor43MetadataBookStats returns true if a release or the default branch of the repo has statistics of its books
+func (repo *Repository) HasDoor43MetadataBookStats() (bool, error) {
+	return x.Where("repo_id = ?", repo.ID).Exist(new(Door43MetadataBookStats))
+}
+
+// ReplaceDoor43MetadataBookStats replaces the statistics of the books of the given repo ID and ref, along with those
+// of the release of the given ID, if not 0, under a previous tag
+func ReplaceDoor43MetadataBookStats(repoID, releaseID int64, ref string, stats []*Door43MetadataBookStats) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	cond := builder.Eq{"ref": ref}.Or(builder.Eq{"release_id": releaseID}.And(builder.Gt{"release_id": 0}))
+	if _, err := sess.Where(builder.Eq{"repo_id": repoID}.And(cond)).Delete(new(Door43MetadataBookStats)); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		s.ID = 0
+		s.RepoID = repoID
+		s.ReleaseID = releaseID
+		s.Ref = ref
+		if _, err := sess.Insert(s); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// DeleteDoor43MetadataBookStats deletes the statistics of the books of the given repo ID and ref, if any
+func DeleteDoor43MetadataBookStats(repoID int64, ref string) error {
+	_, err := x.Where("repo_id = ? AND ref = ?", repoID, ref).Delete(new(Door43MetadataBookStats))
+	return err
+}
+
+// DeleteDoor43MetadataBookStatsOfRelease deletes the statistics of the books of a release, if any
+func DeleteDoor43MetadataBookStatsOfRelease(release *Release) error {
+	_, err := x.Where("repo_id = ? AND release_id = ?", release.RepoID, release.ID).Delete(new(Door43MetadataBookStats))
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceDoor43MetadataBookStats(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	has, err := repo.HasDoor43MetadataBookStats()
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	assert.NoError(t, ReplaceDoor43MetadataBookStats(repo.ID, 0, "master", []*Door43MetadataBookStats{
+		{Book: "rut", CommitSHA: "65f1bf27bc3bf70f64657658635e66094edbcb4d", Chapters: 2, ExpectedChapters: 4, Verses: 40, ExpectedVerses: 85, Words: 200, AlignedWords: 50},
+		{Book: "gen", CommitSHA: "65f1bf27bc3bf70f64657658635e66094edbcb4d", Chapters: 50, ExpectedChapters: 50, Verses: 1533, ExpectedVerses: 1533, Words: 1000},
+	}))
+	assert.NoError(t, ReplaceDoor43MetadataBookStats(repo.ID, 1, "v1.0", []*Door43MetadataBookStats{{Book: "rut"}}))
+	// The release was tagged again
+	assert.NoError(t, ReplaceDoor43MetadataBookStats(repo.ID, 1, "v1.1", []*Door43MetadataBookStats{{Book: "jon"}}))
+
+	has, err = repo.HasDoor43MetadataBookStats()
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	refs, err := GetDoor43MetadataBookStatsRefs(repo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"master", "v1.1"}, refs)
+
+	stats, err := GetDoor43MetadataBookStats(repo.ID, "master")
+	assert.NoError(t, err)
+	if assert.Len(t, stats, 2) {
+		assert.Equal(t, "gen", stats[0].Book)
+		assert.Equal(t, "rut", stats[1].Book)
+		assert.Equal(t, 47.1, stats[1].VersePercent())
+		assert.Equal(t, 25.0, stats[1].AlignmentPercent())
+	}
+	total := stats.Total()
+	assert.Equal(t, 1573, total.Verses)
+	assert.Equal(t, 1618, total.ExpectedVerses)
+
+	commitSHA, err := GetDoor43MetadataBookStatsCommitSHA(repo.ID, "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "65f1bf27bc3bf70f64657658635e66094edbcb4d", commitSHA)
+
+	assert.NoError(t, DeleteDoor43MetadataBookStatsOfRelease(&Release{ID: 1, RepoID: repo.ID}))
+	assert.NoError(t, DeleteDoor43MetadataBookStats(repo.ID, "master"))
+	has, err = repo.HasDoor43MetadataBookStats()
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
@@ -0,0 +1,257 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// Door43MetadataChangeType is the kind of change made to a catalog entry
+type Door43MetadataChangeType int
+
+// Door43MetadataChangeType values
+const (
+	Door43MetadataChangeCreated Door43MetadataChangeType = iota + 1
+	Door43MetadataChangeUpdated
+	Door43MetadataChangeDeleted
+)
+
+func (t Door43MetadataChangeType) String() string {
+	switch t {
+	case Door43MetadataChangeCreated:
+		return "created"
+	case Door43MetadataChangeUpdated:
+		return "updated"
+	case Door43MetadataChangeDeleted:
+		return "deleted"
+	}
+	return ""
+}
+
+// Door43MetadataChange is a change made to a catalog entry, kept so downstream apps can sync the catalog incrementally.
+// Its ID is the cursor of the change feed, and the names of the entry are copied so deleted entries can be identified.
+type Door43MetadataChange struct {
+	ID               int64                    `xorm:"pk autoincr"`
+	Door43MetadataID int64                    `xorm:"INDEX NOT NULL"`
+	Door43Metadata   *Door43Metadata          `xorm:"-"`
+	RepoID           int64                    `xorm:"INDEX NOT NULL"`
+	ReleaseID        int64                    `xorm:"NOT NULL DEFAULT 0"`
+	OwnerName        string                   `xorm:"NOT NULL"`
+	RepoName         string                   `xorm:"NOT NULL"`
+	BranchOrTag      string                   `xorm:"NOT NULL"`
+	Type             Door43MetadataChangeType `xorm:"NOT NULL"`
+	IsPrivate        bool                     `xorm:"NOT NULL DEFAULT false"` // not everyone could read the entry
+	CreatedUnix      timeutil.TimeStamp       `xorm:"INDEX created"`
+}
+
+// FullName returns the full name of the repo of the changed entry as it was when the change was made
+func (c *Door43MetadataChange) FullName() string {
+	return c.OwnerName + "/" + c.RepoName
+}
+
+// newDoor43MetadataChange returns the given kind of change of the door43 metadata, whose repo must be loaded
+func newDoor43MetadataChange(dm *Door43Metadata, changeType Door43MetadataChangeType, isPrivate bool) *Door43MetadataChange {
+	return &Door43MetadataChange{
+		Door43MetadataID: dm.ID,
+		RepoID:           dm.RepoID,
+		ReleaseID:        dm.ReleaseID,
+		OwnerName:        dm.Repo.OwnerName,
+		RepoName:         dm.Repo.Name,
+		BranchOrTag:      dm.BranchOrTag,
+		Type:             changeType,
+		IsPrivate:        isPrivate,
+	}
+}
+
+// addDoor43MetadataChanges records the given kind of change for each of the door43 metadatas
+func addDoor43MetadataChanges(e Engine, changeType Door43MetadataChangeType, dms ...*Door43Metadata) error {
+	changes := make([]*Door43MetadataChange, 0, len(dms))
+	for _, dm := range dms {
+		if err := dm.getRepo(e); err != nil {
+			if IsErrRepoNotExist(err) {
+				continue
+			}
+			return err
+		}
+		isPrivate, err := dm.Repo.isCatalogPrivate(e)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, newDoor43MetadataChange(dm, changeType, isPrivate))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err := e.Insert(changes)
+	return err
+}
+
+// updateDoor43MetadataVisibility records the catalog entries of a repo leaving or joining the public catalog when the
+// repo or its owner changes visibility, see updateOwnerDoor43MetadataVisibility for the latter. Once private, the
+// earlier changes and tombstones of the repo are hidden, and those who can't read the repo are given a deleted change
+// and a made_private tombstone for each entry, while those who can are given an updated change. Once public again,
+// everyone is given a created change for each entry.
+func updateDoor43MetadataVisibility(e Engine, repo *Repository) error {
+	isPrivate, err := repo.isCatalogPrivate(e)
+	if err != nil {
+		return err
+	}
+	// The latest change of the repo tells if its entries were public, so nothing is recorded if that didn't change.
+	// Without one, the entries were public, as changes are recorded for the entries of private repos.
+	last := new(Door43MetadataChange)
+	has, err := e.Where("repo_id = ?", repo.ID).Desc("id").Get(last)
+	if err != nil {
+		return err
+	}
+	if wasPrivate := has && last.IsPrivate; wasPrivate == isPrivate {
+		return nil
+	}
+
+	dms := make([]*Door43Metadata, 0, 10)
+	if err := e.Where("repo_id = ?", repo.ID).Asc("id").Find(&dms); err != nil {
+		return err
+	}
+	for _, dm := range dms {
+		dm.Repo = repo
+	}
+	if !isPrivate {
+		if err := addDoor43MetadataChanges(e, Door43MetadataChangeCreated, dms...); err != nil {
+			return err
+		}
+		return removeDoor43MetadataTombstones(e, dms...)
+	}
+
+	if _, err := e.Where("repo_id = ?", repo.ID).Cols("is_private").Update(&Door43MetadataChange{IsPrivate: true}); err != nil {
+		return err
+	}
+	if _, err := e.Where("repo_id = ?", repo.ID).Cols("is_private").Update(&Door43MetadataTombstone{IsPrivate: true}); err != nil {
+		return err
+	}
+	if len(dms) == 0 {
+		return nil
+	}
+	tombstones := make([]*Door43MetadataTombstone, len(dms))
+	changes := make([]*Door43MetadataChange, 0, 2*len(dms))
+	for i, dm := range dms {
+		tombstones[i] = newDoor43MetadataTombstone(dm, DeleteReasonMadePrivate, false)
+		changes = append(changes, newDoor43MetadataChange(dm, Door43MetadataChangeDeleted, false))
+	}
+	for _, dm := range dms {
+		changes = append(changes, newDoor43MetadataChange(dm, Door43MetadataChangeUpdated, true))
+	}
+	if _, err := e.Insert(tombstones); err != nil {
+		return err
+	}
+	_, err = e.Insert(changes)
+	return err
+}
+
+// isUserVisibilityChanged returns true if the visibility of the user differs from the one saved, to be checked before
+// saving it
+func isUserVisibilityChanged(e Engine, u *User) (bool, error) {
+	saved := new(User)
+	has, err := e.ID(u.ID).Cols("visibility").Get(saved)
+	if err != nil {
+		return false, err
+	}
+	return has && saved.Visibility != u.Visibility, nil
+}
+
+// updateOwnerDoor43MetadataVisibility records the catalog entries of the repos of an owner leaving or joining the
+// public catalog when the owner changes visibility, as updateDoor43MetadataVisibility does for a repo
+func updateOwnerDoor43MetadataVisibility(e Engine, owner *User) error {
+	repos := make([]*Repository, 0, 10)
+	if err := e.Where(builder.Eq{"owner_id": owner.ID}.And(builder.Or(
+		builder.In("id", builder.Select("repo_id").From("door43_metadata")),
+		builder.In("id", builder.Select("repo_id").From("door43_metadata_change")),
+	))).Asc("id").Find(&repos); err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		repo.Owner = owner
+		if err := updateDoor43MetadataVisibility(e, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Door43MetadataChangeCommitLag is how long a change is held back from those syncing by cursor. The ID of a change is
+// given when it is made rather than when its transaction is committed, so a change can become visible after one with
+// a greater ID, which a cursor already past it would skip. Holding changes back for longer than a transaction takes
+// lets every transaction commit before the cursor moves past its changes.
+const Door43MetadataChangeCommitLag = 10 * time.Second
+
+// FindDoor43MetadataChangesOptions are the options to find catalog changes
+type FindDoor43MetadataChangesOptions struct {
+	AfterID int64              // only changes after this cursor
+	Since   timeutil.TimeStamp // only changes made at or after this time
+	Before  timeutil.TimeStamp // only changes made before this time, such as Door43MetadataChangeCommitLag ago
+	Limit   int
+	Actor   *User // the changes the actor can read, public ones only if nil, all for admins
+}
+
+func (opts *FindDoor43MetadataChangesOptions) toConds() builder.Cond {
+	cond := catalogRecordAccessCond(opts.Actor)
+	if opts.AfterID > 0 {
+		cond = cond.And(builder.Gt{"id": opts.AfterID})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	return cond
+}
+
+// FindDoor43MetadataChanges returns the catalog changes matching the options in the order they were made, with the
+// entries of the ones that still exist loaded. Paging with AfterID set to the ID of the last change of the previous
+// page only doesn't skip changes committed out of order if Before holds back the changes of the transactions that may
+// still be committing, see Door43MetadataChangeCommitLag.
+func FindDoor43MetadataChanges(opts FindDoor43MetadataChangesOptions) ([]*Door43MetadataChange, error) {
+	sess := x.Where(opts.toConds()).Asc("id")
+	if opts.Limit > 0 {
+		sess = sess.Limit(opts.Limit)
+	}
+	changes := make([]*Door43MetadataChange, 0, opts.Limit)
+	if err := sess.Find(&changes); err != nil {
+		return nil, err
+	}
+
+	dmIDs := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		if change.Type != Door43MetadataChangeDeleted {
+			dmIDs = append(dmIDs, change.Door43MetadataID)
+		}
+	}
+	dms := make(map[int64]*Door43Metadata, len(dmIDs))
+	if len(dmIDs) > 0 {
+		if err := x.In("id", dmIDs).Find(&dms); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range changes {
+		if change.Type != Door43MetadataChangeDeleted {
+			change.Door43Metadata = dms[change.Door43MetadataID]
+		}
+	}
+	return changes, nil
+}
+
+// GetLatestDoor43MetadataChangeID returns the ID of the latest catalog change, the cursor to start syncing from
+// after a full download of the catalog, or 0 if there are none
+func GetLatestDoor43MetadataChangeID() (int64, error) {
+	change := &Door43MetadataChange{}
+	has, err := x.Desc("id").Get(change)
+	if err != nil || !has {
+		return 0, err
+	}
+	return change.ID, nil
+}
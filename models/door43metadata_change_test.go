@@ -0,0 +1,191 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoor43MetadataChanges(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	lastID, err := GetLatestDoor43MetadataChangeID()
+	assert.NoError(t, err)
+
+	dm := &Door43Metadata{
+		RepoID:          1,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+	}
+	assert.NoError(t, InsertDoor43Metadata(dm))
+
+	changes, err := FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{AfterID: lastID})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, Door43MetadataChangeCreated, changes[0].Type)
+		assert.Equal(t, "user2/repo1", changes[0].FullName())
+		assert.Equal(t, "master", changes[0].BranchOrTag)
+		if assert.NotNil(t, changes[0].Door43Metadata) {
+			assert.Equal(t, dm.ID, changes[0].Door43Metadata.ID)
+		}
+	}
+
+	dm.Title = "Updated"
+	assert.NoError(t, UpdateDoor43MetadataCols(dm, "title"))
+	assert.NoError(t, DeleteDoor43Metadata(dm, DeleteReasonInvalidManifest))
+
+	changes, err = FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{AfterID: lastID})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, Door43MetadataChangeUpdated, changes[1].Type)
+		assert.Equal(t, Door43MetadataChangeDeleted, changes[2].Type)
+		assert.Equal(t, dm.ID, changes[2].Door43MetadataID)
+		// the entry no longer exists so none of the changes have it
+		for _, change := range changes {
+			assert.Nil(t, change.Door43Metadata)
+		}
+
+		// paging by cursor
+		page, err := FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{AfterID: changes[0].ID, Limit: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, page, 1) {
+			assert.Equal(t, changes[1].ID, page[0].ID)
+		}
+
+		latestID, err := GetLatestDoor43MetadataChangeID()
+		assert.NoError(t, err)
+		assert.Equal(t, changes[2].ID, latestID)
+
+		// the changes of transactions that may still be committing are held back
+		before := timeutil.TimeStamp(time.Now().Add(-Door43MetadataChangeCommitLag).Unix())
+		held, err := FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{AfterID: lastID, Before: before})
+		assert.NoError(t, err)
+		assert.Len(t, held, 0)
+	}
+}
+
+func TestDoor43MetadataChanges_Visibility(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	dm := &Door43Metadata{
+		RepoID:          1,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+	}
+	assert.NoError(t, InsertDoor43Metadata(dm))
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	changeTypes := func(actor *User, afterID int64) []Door43MetadataChangeType {
+		return getDoor43MetadataChangeTypes(t, actor, afterID)
+	}
+	tombstoneReasons := func(actor *User) []Door43MetadataDeleteReason {
+		return getDoor43MetadataTombstoneReasons(t, actor)
+	}
+
+	// once private, everyone else is told the entry was withdrawn while the owner is told it was updated
+	repo.IsPrivate = true
+	assert.NoError(t, UpdateRepository(repo, true))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeDeleted}, changeTypes(nil, 0))
+	assert.Equal(t, []Door43MetadataDeleteReason{DeleteReasonMadePrivate}, tombstoneReasons(nil))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeCreated, Door43MetadataChangeDeleted,
+		Door43MetadataChangeUpdated}, changeTypes(owner, 0))
+
+	// nothing more is recorded if the visibility of the catalog didn't change
+	assert.NoError(t, UpdateRepository(repo, true))
+	assert.Len(t, changeTypes(owner, 0), 3)
+
+	// once public again, everyone is told the entry was created
+	lastID, err := GetLatestDoor43MetadataChangeID()
+	assert.NoError(t, err)
+	repo.IsPrivate = false
+	assert.NoError(t, UpdateRepository(repo, true))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeCreated}, changeTypes(nil, lastID))
+	assert.Empty(t, tombstoneReasons(nil))
+}
+
+func TestDoor43MetadataChanges_OwnerVisibility(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, InsertDoor43Metadata(&Door43Metadata{
+		RepoID:          32,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+	}))
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	member := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	// the entries of the org's repos are withdrawn from the public catalog, as when the repo is made private
+	org.Visibility = structs.VisibleTypePrivate
+	assert.NoError(t, UpdateUserCols(org, "visibility"))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeDeleted}, getDoor43MetadataChangeTypes(t, nil, 0))
+	assert.Equal(t, []Door43MetadataDeleteReason{DeleteReasonMadePrivate}, getDoor43MetadataTombstoneReasons(t, nil))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeCreated, Door43MetadataChangeDeleted,
+		Door43MetadataChangeUpdated}, getDoor43MetadataChangeTypes(t, member, 0))
+
+	lastID, err := GetLatestDoor43MetadataChangeID()
+	assert.NoError(t, err)
+	org.Visibility = structs.VisibleTypePublic
+	assert.NoError(t, UpdateUser(org))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeCreated}, getDoor43MetadataChangeTypes(t, nil, lastID))
+	assert.Empty(t, getDoor43MetadataTombstoneReasons(t, nil))
+}
+
+func TestDoor43MetadataChanges_VisibilityWithoutChanges(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// an entry made before its changes were recorded
+	_, err := x.Insert(&Door43Metadata{
+		RepoID:          1,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+	})
+	assert.NoError(t, err)
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo.IsPrivate = true
+	assert.NoError(t, UpdateRepository(repo, true))
+	assert.Equal(t, []Door43MetadataChangeType{Door43MetadataChangeDeleted}, getDoor43MetadataChangeTypes(t, nil, 0))
+	assert.Equal(t, []Door43MetadataDeleteReason{DeleteReasonMadePrivate}, getDoor43MetadataTombstoneReasons(t, nil))
+}
+
+// getDoor43MetadataChangeTypes returns the types of the changes after the ID the actor can read
+func getDoor43MetadataChangeTypes(t *testing.T, actor *User, afterID int64) []Door43MetadataChangeType {
+	changes, err := FindDoor43MetadataChanges(FindDoor43MetadataChangesOptions{Actor: actor, AfterID: afterID})
+	assert.NoError(t, err)
+	types := make([]Door43MetadataChangeType, len(changes))
+	for i, change := range changes {
+		types[i] = change.Type
+	}
+	return types
+}
+
+// getDoor43MetadataTombstoneReasons returns the reasons of the tombstones the actor can read
+func getDoor43MetadataTombstoneReasons(t *testing.T, actor *User) []Door43MetadataDeleteReason {
+	tombstones, _, err := FindDoor43MetadataTombstones(FindDoor43MetadataTombstonesOptions{Actor: actor})
+	assert.NoError(t, err)
+	reasons := make([]Door43MetadataDeleteReason, len(tombstones))
+	for i, tombstone := range tombstones {
+		reasons[i] = tombstone.Reason
+	}
+	return reasons
+}
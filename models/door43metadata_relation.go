@@ -0,0 +1,310 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/dcs"
+
+	"xorm.io/builder"
+)
+
+// Door43MetadataRelation is a resource that a door43 metadata relates to, such as the source text a translation
+// resource depends on, as listed in its metadata
+type Door43MetadataRelation struct {
+	ID               int64  `xorm:"pk autoincr"`
+	Door43MetadataID int64  `xorm:"INDEX NOT NULL"`
+	RepoID           int64  `xorm:"INDEX NOT NULL"`
+	Relation         string `xorm:"NOT NULL"`
+	Language         string
+	Identifier       string
+	// RepoName is the lower name of the repos of the resource, <language>_<identifier>
+	RepoName string `xorm:"INDEX NOT NULL"`
+	// Version is the version constraint of the release of the resource, empty for its latest release
+	Version string
+}
+
+// Door43MetadataRelationStatus is whether the relation of a door43 metadata resolves to a catalog entry
+type Door43MetadataRelationStatus string
+
+// Door43MetadataRelationStatus values
+const (
+	// RelationResolved is a relation to a production release in the catalog
+	RelationResolved Door43MetadataRelationStatus = "resolved"
+	// RelationUnpublished is a relation to an entry in the catalog that is only a prerelease, draft or default branch
+	RelationUnpublished Door43MetadataRelationStatus = "unpublished"
+	// RelationDangling is a relation to a resource that is not in the catalog
+	RelationDangling Door43MetadataRelationStatus = "dangling"
+)
+
+// ResolvedDoor43MetadataRelation is the relation of a door43 metadata with the catalog entry it resolves to, if any
+type ResolvedDoor43MetadataRelation struct {
+	*Door43MetadataRelation
+	Status Door43MetadataRelationStatus
+	Entry  *Door43Metadata
+}
+
+// newDoor43MetadataRelations returns the relations of a door43 metadata, skipping any that are not valid
+func newDoor43MetadataRelations(dm *Door43Metadata) []*Door43MetadataRelation {
+	relations := make([]*Door43MetadataRelation, 0, len(dm.Relations))
+	seen := make(map[string]bool)
+	for _, relation := range dm.Relations {
+		r := dcs.ParseRelation(relation)
+		if r == nil || seen[relation] {
+			continue
+		}
+		seen[relation] = true
+		relations = append(relations, &Door43MetadataRelation{
+			Door43MetadataID: dm.ID,
+			RepoID:           dm.RepoID,
+			Relation:         relation,
+			Language:         r.Language,
+			Identifier:       r.Identifier,
+			RepoName:         r.RepoName(),
+			Version:          r.Version,
+		})
+	}
+	return relations
+}
+
+// updateDoor43MetadataRelations replaces the relations of the door43 metadatas with those listed in their metadata
+func updateDoor43MetadataRelations(e Engine, dms ...*Door43Metadata) error {
+	if err := deleteDoor43MetadataRelations(e, dms...); err != nil {
+		return err
+	}
+	var relations []*Door43MetadataRelation
+	for _, dm := range dms {
+		relations = append(relations, newDoor43MetadataRelations(dm)...)
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+	_, err := e.Insert(relations)
+	return err
+}
+
+// deleteDoor43MetadataRelations deletes the relations of the door43 metadatas
+func deleteDoor43MetadataRelations(e Engine, dms ...*Door43Metadata) error {
+	ids := make([]int64, len(dms))
+	for i, dm := range dms {
+		ids[i] = dm.ID
+	}
+	_, err := e.In("door43_metadata_id", ids).Delete(&Door43MetadataRelation{})
+	return err
+}
+
+// UpdateDoor43MetadataRelations updates only the relations of a door43 metadata, without creating a repository notice
+// or a catalog change as they were only normalized from its metadata
+func UpdateDoor43MetadataRelations(dm *Door43Metadata) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if _, err := sess.ID(dm.ID).Cols("relations").Update(dm); err != nil {
+		return err
+	}
+	if err := updateDoor43MetadataRelations(sess, dm); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetDoor43MetadatasWithoutRelations gets up to limit door43 metadatas of RCs with an ID greater than afterID that
+// were created before their relations were normalized
+func GetDoor43MetadatasWithoutRelations(afterID int64, limit int) ([]*Door43Metadata, error) {
+	dms := make([]*Door43Metadata, 0, limit)
+	return dms, x.
+		Where(builder.Gt{"id": afterID}).
+		And(builder.Eq{"metadata_type": MetadataTypeRC}).
+		And(builder.IsNull{"relations"}).
+		Asc("id").
+		Limit(limit).
+		Find(&dms)
+}
+
+// GetDoor43MetadataRelations returns the relations of a door43 metadata, in the order they are listed in its metadata
+func GetDoor43MetadataRelations(dm *Door43Metadata) ([]*Door43MetadataRelation, error) {
+	relations := make([]*Door43MetadataRelation, 0, 10)
+	return relations, x.Where("door43_metadata_id = ?", dm.ID).Asc("id").Find(&relations)
+}
+
+// getRelationCandidates returns the catalog entries of the public repos with any of the lower names, with their repos
+// loaded, highest version first
+func getRelationCandidates(repoNames []string) ([]*Door43Metadata, error) {
+	dms := make([]*Door43Metadata, 0, 10)
+	if len(repoNames) == 0 {
+		return dms, nil
+	}
+	if err := x.
+		Join("INNER", "repository", "`repository`.id = `door43_metadata`.repo_id").
+		Where(builder.In("`repository`.lower_name", repoNames)).
+		And(builder.Eq{"`repository`.is_private": false}).
+		And(notArchivedCond()).
+		Desc("`door43_metadata`.version_key", "`door43_metadata`.release_date_unix", "`door43_metadata`.id").
+		Find(&dms); err != nil {
+		return nil, err
+	}
+	repoIDs := make([]int64, 0, len(dms))
+	for _, dm := range dms {
+		repoIDs = append(repoIDs, dm.RepoID)
+	}
+	repos, err := GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, dm := range dms {
+		dm.Repo = repos[dm.RepoID]
+	}
+	return dms, nil
+}
+
+// resolveCatalogEntry returns the first of the candidates, which are in order of preference, that is of the relation's
+// resource and matches, preferring those of the owner, nil if there is none
+func (r *Door43MetadataRelation) resolveCatalogEntry(ownerID int64, candidates []*Door43Metadata, match func(*Door43Metadata) bool) *Door43Metadata {
+	for _, isOwner := range []bool{true, false} {
+		for _, dm := range candidates {
+			if dm.Repo != nil && dm.Repo.LowerName == r.RepoName && (dm.Repo.OwnerID == ownerID) == isOwner && match(dm) {
+				return dm
+			}
+		}
+	}
+	return nil
+}
+
+// resolve resolves the relation of a door43 metadata of a repo of the owner among the candidates, which are the
+// catalog entries of its resource, highest version first, as given by getRelationCandidates
+func (r *Door43MetadataRelation) resolve(ownerID int64, candidates []*Door43Metadata) *ResolvedDoor43MetadataRelation {
+	matchVersion := func(dm *Door43Metadata) bool { return true }
+	if r.Version != "" {
+		if constraint, err := dcs.ParseVersionConstraint(r.Version); err == nil {
+			matchVersion = func(dm *Door43Metadata) bool { return constraint.CheckKey(dm.VersionKey) }
+		} else {
+			matchVersion = func(dm *Door43Metadata) bool { return dm.BranchOrTag == r.Version }
+		}
+	}
+
+	resolved := &ResolvedDoor43MetadataRelation{Door43MetadataRelation: r, Status: RelationDangling}
+	if entry := r.resolveCatalogEntry(ownerID, candidates, func(dm *Door43Metadata) bool {
+		return dm.Stage == StageProd && matchVersion(dm)
+	}); entry != nil {
+		resolved.Status, resolved.Entry = RelationResolved, entry
+		return resolved
+	}
+	if r.Version == "" {
+		// The default branch of an unversioned relation is only a fallback, so any version matches
+		matchVersion = func(dm *Door43Metadata) bool { return true }
+	}
+	if entry := r.resolveCatalogEntry(ownerID, candidates, func(dm *Door43Metadata) bool {
+		return dm.Stage != StageProd && matchVersion(dm)
+	}); entry != nil {
+		resolved.Status, resolved.Entry = RelationUnpublished, entry
+	}
+	return resolved
+}
+
+// Resolve resolves the relation of the door43 metadata to the production release in the catalog of the highest
+// version satisfying its version, preferring the repos of the door43 metadata's owner. If there is none, the relation
+// is unpublished if there is such a prerelease, draft or default branch in the catalog, and dangling if there is not.
+func (r *Door43MetadataRelation) Resolve(dm *Door43Metadata) (*ResolvedDoor43MetadataRelation, error) {
+	resolved, err := resolveDoor43MetadataRelations(dm, []*Door43MetadataRelation{r})
+	if err != nil {
+		return nil, err
+	}
+	return resolved[0], nil
+}
+
+// resolveDoor43MetadataRelations resolves the relations of the door43 metadata with the catalog entries of all their
+// resources loaded at once
+func resolveDoor43MetadataRelations(dm *Door43Metadata, relations []*Door43MetadataRelation) ([]*ResolvedDoor43MetadataRelation, error) {
+	if err := dm.GetRepo(); err != nil {
+		return nil, err
+	}
+	repoNames := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		repoNames = append(repoNames, relation.RepoName)
+	}
+	candidates, err := getRelationCandidates(repoNames)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]*ResolvedDoor43MetadataRelation, len(relations))
+	for i, relation := range relations {
+		resolved[i] = relation.resolve(dm.Repo.OwnerID, candidates)
+		if resolved[i].Entry != nil {
+			if err := resolved[i].Entry.LoadAttributes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// ResolveDoor43MetadataRelations returns the relations of a door43 metadata, each resolved to a catalog entry
+func ResolveDoor43MetadataRelations(dm *Door43Metadata) ([]*ResolvedDoor43MetadataRelation, error) {
+	relations, err := GetDoor43MetadataRelations(dm)
+	if err != nil {
+		return nil, err
+	}
+	return resolveDoor43MetadataRelations(dm, relations)
+}
+
+// GetDoor43MetadataDependents returns the catalog entries that have a relation that resolves to the door43 metadata,
+// which are the ones that would have a dangling relation if it were withdrawn. The relations to the resource of the
+// door43 metadata are resolved together, against the catalog entries of the resource loaded at once.
+func GetDoor43MetadataDependents(dm *Door43Metadata) ([]*Door43Metadata, error) {
+	if err := dm.GetRepo(); err != nil {
+		return nil, err
+	}
+	relations := make([]*Door43MetadataRelation, 0, 10)
+	if err := x.Where("repo_name = ?", dm.Repo.LowerName).Asc("door43_metadata_id").Find(&relations); err != nil {
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return []*Door43Metadata{}, nil
+	}
+
+	repoIDs := make([]int64, 0, len(relations))
+	for _, relation := range relations {
+		repoIDs = append(repoIDs, relation.RepoID)
+	}
+	repos, err := GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := getRelationCandidates([]string{dm.Repo.LowerName})
+	if err != nil {
+		return nil, err
+	}
+
+	dependentIDs := make([]int64, 0, len(relations))
+	seen := make(map[int64]bool)
+	for _, relation := range relations {
+		repo := repos[relation.RepoID]
+		if repo == nil || seen[relation.Door43MetadataID] {
+			continue
+		}
+		resolved := relation.resolve(repo.OwnerID, candidates)
+		if resolved.Entry != nil && resolved.Entry.ID == dm.ID {
+			seen[relation.Door43MetadataID] = true
+			dependentIDs = append(dependentIDs, relation.Door43MetadataID)
+		}
+	}
+
+	dependents := make([]*Door43Metadata, 0, len(dependentIDs))
+	if len(dependentIDs) == 0 {
+		return dependents, nil
+	}
+	if err := x.In("id", dependentIDs).Asc("id").Find(&dependents); err != nil {
+		return nil, err
+	}
+	for _, dependent := range dependents {
+		dependent.Repo = repos[dependent.RepoID]
+		if err := dependent.LoadAttributes(); err != nil {
+			return nil, err
+		}
+	}
+	return dependents, nil
+}
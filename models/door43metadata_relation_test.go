@@ -0,0 +1,77 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/dcs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoor43MetadataRelations(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	dependent := &Door43Metadata{
+		RepoID:          1,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+		Relations:       []string{"test/repo_12", "test/repo_12?v=2", "en/missing", "invalid"},
+	}
+	assert.NoError(t, InsertDoor43Metadata(dependent))
+
+	statuses := func() []Door43MetadataRelationStatus {
+		relations, err := ResolveDoor43MetadataRelations(dependent)
+		assert.NoError(t, err)
+		statuses := make([]Door43MetadataRelationStatus, len(relations))
+		for i, relation := range relations {
+			statuses[i] = relation.Status
+		}
+		return statuses
+	}
+	assert.Equal(t, []Door43MetadataRelationStatus{RelationDangling, RelationDangling, RelationDangling}, statuses())
+
+	branch := &Door43Metadata{
+		RepoID:          12,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageLatest,
+		BranchOrTag:     "master",
+	}
+	assert.NoError(t, InsertDoor43Metadata(branch))
+	assert.Equal(t, []Door43MetadataRelationStatus{RelationUnpublished, RelationDangling, RelationDangling}, statuses())
+
+	release := &Door43Metadata{
+		RepoID:          12,
+		ReleaseID:       1,
+		MetadataType:    MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           StageProd,
+		BranchOrTag:     "v2.1",
+		VersionKey:      dcs.GetTagVersionKey("v2.1"),
+	}
+	assert.NoError(t, InsertDoor43Metadata(release))
+	assert.Equal(t, []Door43MetadataRelationStatus{RelationResolved, RelationResolved, RelationDangling}, statuses())
+
+	dependents, err := GetDoor43MetadataDependents(release)
+	assert.NoError(t, err)
+	if assert.Len(t, dependents, 1) {
+		assert.Equal(t, dependent.ID, dependents[0].ID)
+	}
+	dependents, err = GetDoor43MetadataDependents(branch)
+	assert.NoError(t, err)
+	assert.Len(t, dependents, 0)
+
+	assert.NoError(t, DeleteDoor43Metadata(dependent, DeleteReasonRepoDeleted))
+	relations, err := GetDoor43MetadataRelations(dependent)
+	assert.NoError(t, err)
+	assert.Len(t, relations, 0)
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// Door43MetadataDeleteReason is why a catalog entry was withdrawn from the catalog
+type Door43MetadataDeleteReason string
+
+// Door43MetadataDeleteReason values
+const (
+	DeleteReasonRepoDeleted      Door43MetadataDeleteReason = "repo_deleted"
+	DeleteReasonMadePrivate      Door43MetadataDeleteReason = "made_private" // left the public catalog, the entries are kept for those who can read the repo
+	DeleteReasonArchived         Door43MetadataDeleteReason = "archived"
+	DeleteReasonInvalidManifest  Door43MetadataDeleteReason = "invalid_manifest"
+	DeleteReasonInvalidContainer Door43MetadataDeleteReason = "invalid_container"
+	DeleteReasonReleaseDeleted   Door43MetadataDeleteReason = "release_deleted"
+	DeleteReasonBranchRemoved    Door43MetadataDeleteReason = "branch_removed" // deleted or no longer a catalog branch of the repo
+)
+
+// Door43MetadataDeleteReasons are all the reasons a catalog entry can be withdrawn for
+var Door43MetadataDeleteReasons = []Door43MetadataDeleteReason{
+	DeleteReasonRepoDeleted,
+	DeleteReasonMadePrivate,
+	DeleteReasonArchived,
+	DeleteReasonInvalidManifest,
+	DeleteReasonInvalidContainer,
+	DeleteReasonReleaseDeleted,
+	DeleteReasonBranchRemoved,
+}
+
+// IsValid returns true if the reason is one of Door43MetadataDeleteReasons
+func (r Door43MetadataDeleteReason) IsValid() bool {
+	for _, reason := range Door43MetadataDeleteReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Door43MetadataTombstone is what is kept of a catalog entry once it is deleted, so downstream caches can tell it
+// was withdrawn and why. It is removed when an entry for the same repo and release is created again.
+type Door43MetadataTombstone struct {
+	ID               int64  `xorm:"pk autoincr"`
+	Door43MetadataID int64  `xorm:"INDEX NOT NULL"`
+	RepoID           int64  `xorm:"INDEX NOT NULL"`
+	ReleaseID        int64  `xorm:"NOT NULL DEFAULT 0"`
+	OwnerName        string `xorm:"INDEX NOT NULL"`
+	RepoName         string `xorm:"NOT NULL"`
+	BranchOrTag      string `xorm:"NOT NULL"`
+	MetadataType     string
+	MetadataVersion  string
+	Title            string
+	Subject          string
+	Language         string
+	Stage            Stage                      `xorm:"NOT NULL DEFAULT 0"`
+	Reason           Door43MetadataDeleteReason `xorm:"VARCHAR(50) INDEX NOT NULL"`
+	IsPrivate        bool                       `xorm:"NOT NULL DEFAULT false"` // not everyone could read the entry
+	DeletedUnix      timeutil.TimeStamp         `xorm:"INDEX created"`
+}
+
+// FullName returns the full name of the repo of the withdrawn entry as it was when it was withdrawn
+func (t *Door43MetadataTombstone) FullName() string {
+	return t.OwnerName + "/" + t.RepoName
+}
+
+// newDoor43MetadataTombstone returns the tombstone of the door43 metadata, whose repo must be loaded
+func newDoor43MetadataTombstone(dm *Door43Metadata, reason Door43MetadataDeleteReason, isPrivate bool) *Door43MetadataTombstone {
+	return &Door43MetadataTombstone{
+		Door43MetadataID: dm.ID,
+		RepoID:           dm.RepoID,
+		ReleaseID:        dm.ReleaseID,
+		OwnerName:        dm.Repo.OwnerName,
+		RepoName:         dm.Repo.Name,
+		BranchOrTag:      dm.BranchOrTag,
+		MetadataType:     dm.MetadataType,
+		MetadataVersion:  dm.MetadataVersion,
+		Title:            dm.Title,
+		Subject:          dm.Subject,
+		Language:         dm.Language,
+		Stage:            dm.Stage,
+		Reason:           reason,
+		IsPrivate:        isPrivate,
+	}
+}
+
+// addDoor43MetadataTombstones records a tombstone and a deleted change for each of the deleted door43 metadatas
+func addDoor43MetadataTombstones(e Engine, reason Door43MetadataDeleteReason, dms ...*Door43Metadata) error {
+	tombstones := make([]*Door43MetadataTombstone, 0, len(dms))
+	for _, dm := range dms {
+		if err := dm.getRepo(e); err != nil {
+			if IsErrRepoNotExist(err) {
+				continue
+			}
+			return err
+		}
+		isPrivate, err := dm.Repo.isCatalogPrivate(e)
+		if err != nil {
+			return err
+		}
+		tombstones = append(tombstones, newDoor43MetadataTombstone(dm, reason, isPrivate))
+	}
+	if len(tombstones) == 0 {
+		return nil
+	}
+	if _, err := e.Insert(tombstones); err != nil {
+		return err
+	}
+	return addDoor43MetadataChanges(e, Door43MetadataChangeDeleted, dms...)
+}
+
+// removeDoor43MetadataTombstones removes the tombstones of the repos' releases or branches the door43 metadatas are
+// for, as they are no longer withdrawn once created again. Branches all have a release ID of 0, so they are told apart
+// by their name.
+func removeDoor43MetadataTombstones(e Engine, dms ...*Door43Metadata) error {
+	for _, dm := range dms {
+		cond := builder.Eq{"repo_id": dm.RepoID, "release_id": dm.ReleaseID}
+		if dm.ReleaseID == 0 {
+			cond["branch_or_tag"] = dm.BranchOrTag
+		}
+		if _, err := e.Where(cond).Delete(new(Door43MetadataTombstone)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDoor43MetadataTombstonesOptions are the options to find the tombstones of withdrawn catalog entries
+type FindDoor43MetadataTombstonesOptions struct {
+	ListOptions
+	OwnerName string
+	RepoName  string
+	Reasons   []Door43MetadataDeleteReason
+	Since     timeutil.TimeStamp // only entries withdrawn at or after this time
+	Actor     *User              // the entries the actor can read, public ones only if nil, all for admins
+}
+
+func (opts *FindDoor43MetadataTombstonesOptions) toConds() builder.Cond {
+	cond := catalogRecordAccessCond(opts.Actor)
+	if opts.OwnerName != "" {
+		cond = cond.And(builder.Eq{"owner_name": opts.OwnerName})
+	}
+	if opts.RepoName != "" {
+		cond = cond.And(builder.Eq{"repo_name": opts.RepoName})
+	}
+	if len(opts.Reasons) > 0 {
+		cond = cond.And(builder.In("reason", opts.Reasons))
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"deleted_unix": opts.Since})
+	}
+	return cond
+}
+
+// FindDoor43MetadataTombstones returns the tombstones of withdrawn catalog entries matching the options, the most
+// recently withdrawn first, and the total count of them
+func FindDoor43MetadataTombstones(opts FindDoor43MetadataTombstonesOptions) ([]*Door43MetadataTombstone, int64, error) {
+	sess := opts.setSessionPagination(x.Where(opts.toConds()).Desc("deleted_unix", "id"))
+	tombstones := make([]*Door43MetadataTombstone, 0, opts.PageSize)
+	count, err := sess.FindAndCount(&tombstones)
+	return tombstones, count, err
+}
+
+// DeleteOldDoor43MetadataTombstones purges the tombstones of catalog entries withdrawn more than olderThan ago
+func DeleteOldDoor43MetadataTombstones(ctx context.Context, olderThan time.Duration) error {
+	log.Trace("Doing: DeleteOldDoor43MetadataTombstones")
+
+	deleteBefore := time.Now().Add(-olderThan)
+	count, err := x.Where("deleted_unix < ?", deleteBefore.Unix()).Delete(new(Door43MetadataTombstone))
+	if err != nil {
+		return err
+	}
+
+	log.Trace("Finished: DeleteOldDoor43MetadataTombstones: %d tombstones purged", count)
+	return nil
+}
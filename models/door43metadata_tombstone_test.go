@@ -0,0 +1,106 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoor43MetadataTombstones(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	newDoor43Metadata := func() *Door43Metadata {
+		return &Door43Metadata{
+			RepoID:          1,
+			MetadataType:    MetadataTypeRC,
+			MetadataVersion: "rc0.2",
+			Metadata:        &map[string]interface{}{},
+			Title:           "Repo 1",
+			Stage:           StageLatest,
+			BranchOrTag:     "master",
+		}
+	}
+	findOpts := FindDoor43MetadataTombstonesOptions{OwnerName: "user2", RepoName: "repo1"}
+
+	dm := newDoor43Metadata()
+	assert.NoError(t, InsertDoor43Metadata(dm))
+	count, err := DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonArchived)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	tombstones, total, err := FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, tombstones, 1) {
+		assert.Equal(t, dm.ID, tombstones[0].Door43MetadataID)
+		assert.Equal(t, DeleteReasonArchived, tombstones[0].Reason)
+		assert.Equal(t, "Repo 1", tombstones[0].Title)
+		assert.Equal(t, "user2/repo1", tombstones[0].FullName())
+	}
+
+	tombstones, _, err = FindDoor43MetadataTombstones(FindDoor43MetadataTombstonesOptions{
+		OwnerName: "user2",
+		Reasons:   []Door43MetadataDeleteReason{DeleteReasonRepoDeleted},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tombstones, 0)
+
+	// the entry is no longer withdrawn once created again
+	assert.NoError(t, InsertDoor43Metadata(newDoor43Metadata()))
+	_, total, err = FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	_, err = DeleteAllDoor43MetadatasByRepoID(1, DeleteReasonMadePrivate)
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteOldDoor43MetadataTombstones(context.Background(), time.Hour))
+	_, total, err = FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	assert.NoError(t, DeleteOldDoor43MetadataTombstones(context.Background(), -time.Hour))
+	_, total, err = FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+}
+
+func TestDoor43MetadataTombstones_Branches(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	newBranchMetadata := func(branch string) *Door43Metadata {
+		return &Door43Metadata{
+			RepoID:          1,
+			MetadataType:    MetadataTypeRC,
+			MetadataVersion: "rc0.2",
+			Metadata:        &map[string]interface{}{},
+			Title:           "Repo 1",
+			Stage:           StageBranch,
+			BranchOrTag:     branch,
+		}
+	}
+	findOpts := FindDoor43MetadataTombstonesOptions{OwnerName: "user2", RepoName: "repo1"}
+
+	for _, branch := range []string{"dev", "next"} {
+		dm := newBranchMetadata(branch)
+		assert.NoError(t, InsertDoor43Metadata(dm))
+		assert.NoError(t, DeleteDoor43Metadata(dm, DeleteReasonBranchRemoved))
+	}
+	_, total, err := FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	// only the branch created again is no longer withdrawn
+	assert.NoError(t, InsertDoor43Metadata(newBranchMetadata("dev")))
+	tombstones, _, err := FindDoor43MetadataTombstones(findOpts)
+	assert.NoError(t, err)
+	if assert.Len(t, tombstones, 1) {
+		assert.Equal(t, "next", tombstones[0].BranchOrTag)
+		assert.Equal(t, DeleteReasonBranchRemoved, tombstones[0].Reason)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// Door43MetadataValidation is the outcome of validating the metadata file of a repo's release or default branch
+// against the schema of its format, and of linting the files it lists, kept so repo owners can see why a ref is or
+// isn't in the catalog
+type Door43MetadataValidation struct {
+	ID           int64  `xorm:"pk autoincr"`
+	RepoID       int64  `xorm:"UNIQUE(s) NOT NULL"`
+	Ref          string `xorm:"UNIQUE(s) NOT NULL"`
+	RefType      string `xorm:"UNIQUE(s) VARCHAR(10) NOT NULL DEFAULT ''"` // "branch" or "tag", as a branch and a tag can have the same name
+	ReleaseID    int64  `xorm:"INDEX"`
+	CommitSHA    string `xorm:"VARCHAR(40)"`
+	MetadataType string
+	Filename     string
+	Schema       string
+	IsValid      bool
+	Errors       []*Door43MetadataValidationError `xorm:"TEXT JSON"`
+	Findings     []*Door43MetadataFinding         `xorm:"TEXT JSON"`
+	CreatedUnix  timeutil.TimeStamp               `xorm:"INDEX created"`
+	UpdatedUnix  timeutil.TimeStamp               `xorm:"INDEX updated"`
+}
+
+// getFindings returns the findings of the given severity
+func (v *Door43MetadataValidation) getFindings(severity Door43MetadataFindingSeverity) []*Door43MetadataFinding {
+	findings := make([]*Door43MetadataFinding, 0, len(v.Findings))
+	for _, finding := range v.Findings {
+		if finding.Severity == severity {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// ErrorFindings returns the findings that keep the ref out of the catalog
+func (v *Door43MetadataValidation) ErrorFindings() []*Door43MetadataFinding {
+	return v.getFindings(FindingSeverityError)
+}
+
+// WarningFindings returns the findings that are only flagged
+func (v *Door43MetadataValidation) WarningFindings() []*Door43MetadataFinding {
+	return v.getFindings(FindingSeverityWarning)
+}
+
+// IsPublishable returns true if the metadata file is valid and no finding keeps the ref out of the catalog
+func (v *Door43MetadataValidation) IsPublishable() bool {
+	return v.IsValid && len(v.ErrorFindings()) == 0
+}
+
+// Door43MetadataValidationError is one of the schema errors of a metadata file
+type Door43MetadataValidationError struct {
+	Field       string      `json:"field"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`
+}
+
+// Door43MetadataFindingSeverity is whether a finding of linting the files of a release keeps it out of the catalog
+type Door43MetadataFindingSeverity string
+
+// Door43MetadataFindingSeverity values
+const (
+	FindingSeverityError   Door43MetadataFindingSeverity = "error"
+	FindingSeverityWarning Door43MetadataFindingSeverity = "warning"
+)
+
+// Door43MetadataFinding is a problem found with the files listed in a metadata file, such as a missing project file
+type Door43MetadataFinding struct {
+	Check    string                        `json:"check"`
+	Severity Door43MetadataFindingSeverity `json:"severity"`
+	Path     string                        `json:"path"`
+	Message  string                        `json:"message"`
+}
+
+// Door43MetadataValidation ref types
+const (
+	ValidationRefTypeBranch = "branch"
+	ValidationRefTypeTag    = "tag"
+)
+
+// Door43MetadataValidationStatusContext is the context of the commit status posted for a metadata validation
+const Door43MetadataValidationStatusContext = "door43/metadata"
+
+// GetDoor43MetadataValidation returns the metadata validation of the given repo ID, ref type and ref
+func GetDoor43MetadataValidation(repoID int64, refType, ref string) (*Door43MetadataValidation, error) {
+	return getDoor43MetadataValidation(x, repoID, refType, ref)
+}
+
+func getDoor43MetadataValidation(e Engine, repoID int64, refType, ref string) (*Door43MetadataValidation, error) {
+	v := &Door43MetadataValidation{}
+	has, err := e.Where("repo_id = ? AND ref_type = ? AND ref = ?", repoID, refType, ref).Get(v)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrDoor43MetadataValidationNotExist{repoID, refType, ref}
+	}
+	return v, nil
+}
+
+// UpsertDoor43MetadataValidation saves the metadata validation, replacing the previous one of its repo and ref
+func UpsertDoor43MetadataValidation(v *Door43MetadataValidation) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	existing, err := getDoor43MetadataValidation(sess, v.RepoID, v.RefType, v.Ref)
+	if err != nil && !IsErrDoor43MetadataValidationNotExist(err) {
+		return err
+	}
+	if existing == nil {
+		if _, err := sess.Insert(v); err != nil {
+			return err
+		}
+	} else {
+		v.ID = existing.ID
+		if _, err := sess.ID(v.ID).AllCols().Update(v); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// DeleteDoor43MetadataValidation deletes the metadata validation of the given repo ID, ref type and ref, if any
+func DeleteDoor43MetadataValidation(repoID int64, refType, ref string) error {
+	_, err := x.Where("repo_id = ? AND ref_type = ? AND ref = ?", repoID, refType, ref).Delete(new(Door43MetadataValidation))
+	return err
+}
+
+// SetDoor43MetadataValidationRefTypes sets the ref types of the metadata validations saved before they had one, which
+// are of a tag if they are of a release and of a branch if not
+func SetDoor43MetadataValidationRefTypes() error {
+	if _, err := x.Where("ref_type = '' AND release_id > 0").Cols("ref_type").
+		Update(&Door43MetadataValidation{RefType: ValidationRefTypeTag}); err != nil {
+		return err
+	}
+	_, err := x.Where("ref_type = ''").Cols("ref_type").Update(&Door43MetadataValidation{RefType: ValidationRefTypeBranch})
+	return err
+}
+
+// ErrDoor43MetadataValidationNotExist represents a "Door43MetadataValidationNotExist" kind of error.
+type ErrDoor43MetadataValidationNotExist struct {
+	RepoID  int64
+	RefType string
+	Ref     string
+}
+
+// IsErrDoor43MetadataValidationNotExist checks if an error is a ErrDoor43MetadataValidationNotExist.
+func IsErrDoor43MetadataValidationNotExist(err error) bool {
+	_, ok := err.(ErrDoor43MetadataValidationNotExist)
+	return ok
+}
+
+func (err ErrDoor43MetadataValidationNotExist) Error() string {
+	return fmt.Sprintf("metadata validation does not exist [repo_id: %d, ref_type: %s, ref: %s]", err.RepoID, err.RefType, err.Ref)
+}
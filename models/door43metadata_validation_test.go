@@ -0,0 +1,63 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoor43MetadataValidation(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// a branch and a tag of the same name each have their own validation
+	branch := &Door43MetadataValidation{RepoID: 1, Ref: "v1", RefType: ValidationRefTypeBranch, IsValid: true}
+	tag := &Door43MetadataValidation{RepoID: 1, Ref: "v1", RefType: ValidationRefTypeTag, ReleaseID: 1}
+	assert.NoError(t, UpsertDoor43MetadataValidation(branch))
+	assert.NoError(t, UpsertDoor43MetadataValidation(tag))
+	assert.NotEqual(t, branch.ID, tag.ID)
+
+	v, err := GetDoor43MetadataValidation(1, ValidationRefTypeBranch, "v1")
+	assert.NoError(t, err)
+	assert.True(t, v.IsValid)
+	v, err = GetDoor43MetadataValidation(1, ValidationRefTypeTag, "v1")
+	assert.NoError(t, err)
+	assert.False(t, v.IsValid)
+
+	// saving it again replaces it
+	tag = &Door43MetadataValidation{RepoID: 1, Ref: "v1", RefType: ValidationRefTypeTag, ReleaseID: 1, IsValid: true}
+	assert.NoError(t, UpsertDoor43MetadataValidation(tag))
+	v, err = GetDoor43MetadataValidation(1, ValidationRefTypeTag, "v1")
+	assert.NoError(t, err)
+	assert.True(t, v.IsValid)
+	assert.Equal(t, v.ID, tag.ID)
+
+	assert.NoError(t, DeleteDoor43MetadataValidation(1, ValidationRefTypeBranch, "v1"))
+	_, err = GetDoor43MetadataValidation(1, ValidationRefTypeBranch, "v1")
+	assert.True(t, IsErrDoor43MetadataValidationNotExist(err))
+	_, err = GetDoor43MetadataValidation(1, ValidationRefTypeTag, "v1")
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteDoor43MetadataValidation(1, ValidationRefTypeTag, "v1"))
+}
+
+func TestSetDoor43MetadataValidationRefTypes(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// saved before they had a ref type
+	_, err := x.Insert(&Door43MetadataValidation{RepoID: 1, Ref: "v1", ReleaseID: 1})
+	assert.NoError(t, err)
+	_, err = x.Insert(&Door43MetadataValidation{RepoID: 1, Ref: "master"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, SetDoor43MetadataValidationRefTypes())
+	_, err = GetDoor43MetadataValidation(1, ValidationRefTypeTag, "v1")
+	assert.NoError(t, err)
+	_, err = GetDoor43MetadataValidation(1, ValidationRefTypeBranch, "master")
+	assert.NoError(t, err)
+
+	assert.NoError(t, DeleteDoor43MetadataValidation(1, ValidationRefTypeTag, "v1"))
+	assert.NoError(t, DeleteDoor43MetadataValidation(1, ValidationRefTypeBranch, "master"))
+}
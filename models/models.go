@@ -129,6 +129,16 @@ func init() {
 		new(LanguageStat),
 		new(EmailHash),
 		new(Door43Metadata),
+		new(Door43MetadataValidation),
+		new(Door43MetadataBookStats),
+		new(Door43MetadataChange),
+		new(Door43MetadataTombstone),
+		new(Door43MetadataRelation),
+		new(DCSSubject),
+		new(DCSLanguage),
+		new(RepoScrub),
+		new(ReleaseBundle),
+		new(ReleaseBundleMember),
 		new(UserRedirect),
 		new(Project),
 		new(ProjectBoard),
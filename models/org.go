@@ -300,6 +300,12 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	/*** DCS Customizations ***/
+	if err := deleteReleaseBundlesOfOwner(e, u.ID); err != nil {
+		return fmt.Errorf("deleteReleaseBundlesOfOwner: %v", err)
+	}
+	/*** END DCS Customizations ***/
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
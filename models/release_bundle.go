@@ -0,0 +1,205 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ReleaseBundle is a release of a set of repos of an org made together with the same tag, such as all the resources of
+// a gateway language. It is kept when the releases of its members are deleted.
+type ReleaseBundle struct {
+	ID           int64                  `xorm:"pk autoincr"`
+	OwnerID      int64                  `xorm:"INDEX NOT NULL"`
+	Owner        *User                  `xorm:"-"`
+	Name         string                 `xorm:"NOT NULL"`
+	TagName      string                 `xorm:"INDEX NOT NULL"`
+	Title        string                 `xorm:"NOT NULL"`
+	Note         string                 `xorm:"TEXT"`
+	IsPrerelease bool                   `xorm:"NOT NULL DEFAULT false"`
+	PublisherID  int64                  `xorm:"INDEX NOT NULL"`
+	Publisher    *User                  `xorm:"-"`
+	Members      []*ReleaseBundleMember `xorm:"-"`
+	CreatedUnix  timeutil.TimeStamp     `xorm:"INDEX created"`
+}
+
+// ReleaseBundleMember is the release of a repo made by a release bundle. It records the full name the repo had as the
+// repo and its release can be deleted.
+type ReleaseBundleMember struct {
+	ID           int64       `xorm:"pk autoincr"`
+	BundleID     int64       `xorm:"INDEX NOT NULL"`
+	RepoID       int64       `xorm:"INDEX NOT NULL"`
+	Repo         *Repository `xorm:"-"`
+	RepoFullName string
+	ReleaseID    int64 `xorm:"INDEX NOT NULL"`
+}
+
+// LoadAttributes loads the owner, publisher and members of the bundle along with the repos of the members, nil if they
+// have been deleted
+func (b *ReleaseBundle) LoadAttributes() error {
+	return b.loadAttributes(x)
+}
+
+func (b *ReleaseBundle) loadAttributes(e Engine) error {
+	if b.Owner == nil {
+		owner, err := getUserByID(e, b.OwnerID)
+		if err != nil {
+			return err
+		}
+		b.Owner = owner
+	}
+	if b.Publisher == nil {
+		publisher, err := getUserByID(e, b.PublisherID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			publisher = NewGhostUser()
+		}
+		b.Publisher = publisher
+	}
+	if b.Members == nil {
+		b.Members = make([]*ReleaseBundleMember, 0, 10)
+		if err := e.Where("bundle_id = ?", b.ID).Asc("id").Find(&b.Members); err != nil {
+			return err
+		}
+		for _, member := range b.Members {
+			repo, err := getRepositoryByID(e, member.RepoID)
+			if err != nil && !IsErrRepoNotExist(err) {
+				return err
+			}
+			member.Repo = repo
+		}
+	}
+	return nil
+}
+
+// GetDoor43Metadata returns the catalog entry of the release of the member, nil if the release is not in the catalog
+func (m *ReleaseBundleMember) GetDoor43Metadata() (*Door43Metadata, error) {
+	if m.Repo == nil {
+		return nil, nil
+	}
+	dm, err := GetDoor43MetadataByRepoIDAndReleaseID(m.RepoID, m.ReleaseID)
+	if err != nil {
+		if IsErrDoor43MetadataNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	dm.Repo = m.Repo
+	return dm, dm.LoadAttributes()
+}
+
+// CreateReleaseBundle records a bundle along with its members
+func CreateReleaseBundle(b *ReleaseBundle, members []*ReleaseBundleMember) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(b); err != nil {
+		return err
+	}
+	for _, member := range members {
+		member.BundleID = b.ID
+	}
+	if len(members) > 0 {
+		if _, err := sess.Insert(&members); err != nil {
+			return err
+		}
+	}
+	b.Members = members
+	return sess.Commit()
+}
+
+// GetReleaseBundleByID returns the bundle of the ID with its attributes loaded
+func GetReleaseBundleByID(id int64) (*ReleaseBundle, error) {
+	b := &ReleaseBundle{}
+	has, err := x.ID(id).Get(b)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrReleaseBundleNotExist{id}
+	}
+	return b, b.LoadAttributes()
+}
+
+// FindReleaseBundlesOptions options for listing release bundles
+type FindReleaseBundlesOptions struct {
+	ListOptions
+	// OwnerID lists the bundles of an org, 0 for those of every org
+	OwnerID int64
+	// TagName lists the bundles with the tag, "" for those with any tag
+	TagName string
+	// Actor lists the bundles with a repo the actor can read, those with a public repo if nil, all for admins
+	Actor *User
+}
+
+func (opts *FindReleaseBundlesOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.Actor == nil || !opts.Actor.IsAdmin {
+		cond = cond.And(builder.In("id", builder.Select("bundle_id").From("release_bundle_member").
+			Where(builder.In("repo_id", AccessibleRepoIDsQuery(opts.Actor)))))
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.TagName != "" {
+		cond = cond.And(builder.Eq{"tag_name": opts.TagName})
+	}
+	return cond
+}
+
+// FindReleaseBundles returns the bundles with their attributes loaded, most recent first, with the total count
+func FindReleaseBundles(opts *FindReleaseBundlesOptions) ([]*ReleaseBundle, int64, error) {
+	cond := opts.toConds()
+
+	count, err := x.Where(cond).Count(new(ReleaseBundle))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.setDefaultValues()
+	bundles := make([]*ReleaseBundle, 0, opts.PageSize)
+	if err := x.Where(cond).Desc("id").Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&bundles); err != nil {
+		return nil, 0, err
+	}
+	for _, b := range bundles {
+		if err := b.LoadAttributes(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return bundles, count, nil
+}
+
+// deleteReleaseBundlesOfOwner deletes the bundles of a deleted org along with their members
+func deleteReleaseBundlesOfOwner(e Engine, ownerID int64) error {
+	if _, err := e.Where(builder.In("bundle_id", builder.Select("id").From("release_bundle").Where(builder.Eq{"owner_id": ownerID}))).
+		Delete(new(ReleaseBundleMember)); err != nil {
+		return err
+	}
+	_, err := e.Delete(&ReleaseBundle{OwnerID: ownerID})
+	return err
+}
+
+// ErrReleaseBundleNotExist represents a "ReleaseBundleNotExist" kind of error.
+type ErrReleaseBundleNotExist struct {
+	ID int64
+}
+
+// IsErrReleaseBundleNotExist checks if an error is a ErrReleaseBundleNotExist.
+func IsErrReleaseBundleNotExist(err error) bool {
+	_, ok := err.(ErrReleaseBundleNotExist)
+	return ok
+}
+
+func (err ErrReleaseBundleNotExist) Error() string {
+	return fmt.Sprintf("release bundle does not exist [id: %d]", err.ID)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseBundles(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	publicBundle := &ReleaseBundle{OwnerID: 2, Name: "public", TagName: "v1", Title: "v1", PublisherID: 2}
+	assert.NoError(t, CreateReleaseBundle(publicBundle, []*ReleaseBundleMember{
+		{RepoID: 1, RepoFullName: "user2/repo1", ReleaseID: 1},
+		{RepoID: 2, RepoFullName: "user2/repo2", ReleaseID: NonexistentID},
+	}))
+	privateBundle := &ReleaseBundle{OwnerID: 2, Name: "private", TagName: "v2", Title: "v2", PublisherID: NonexistentID}
+	assert.NoError(t, CreateReleaseBundle(privateBundle, []*ReleaseBundleMember{
+		{RepoID: 2, RepoFullName: "user2/repo2", ReleaseID: NonexistentID},
+	}))
+
+	b, err := GetReleaseBundleByID(publicBundle.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", b.Owner.Name)
+	if assert.Len(t, b.Members, 2) {
+		assert.EqualValues(t, 1, b.Members[0].Repo.ID)
+		assert.Equal(t, "user2/repo2", b.Members[1].RepoFullName)
+	}
+
+	// The publisher was deleted
+	b, err = GetReleaseBundleByID(privateBundle.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, -1, b.Publisher.ID)
+
+	_, err = GetReleaseBundleByID(NonexistentID)
+	assert.True(t, IsErrReleaseBundleNotExist(err))
+
+	// Without a user, only the bundle with a public repo is listed
+	bundles, count, err := FindReleaseBundles(&FindReleaseBundlesOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, bundles, 1) {
+		assert.Equal(t, publicBundle.ID, bundles[0].ID)
+	}
+
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	bundles, count, err = FindReleaseBundles(&FindReleaseBundlesOptions{OwnerID: 2, Actor: owner})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, bundles, 2) {
+		assert.Equal(t, privateBundle.ID, bundles[0].ID)
+	}
+
+	bundles, _, err = FindReleaseBundles(&FindReleaseBundlesOptions{TagName: "v2", Actor: owner})
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 1)
+}
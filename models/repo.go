@@ -35,6 +35,7 @@ import (
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 
+	"github.com/gobwas/glob"
 	"xorm.io/builder"
 )
 
@@ -248,7 +249,9 @@ type Repository struct {
 	Avatar string `xorm:"VARCHAR(64)"`
 
 	/*** DCS Customizations ***/
-	Metadata *map[string]interface{} `xorm:-`
+	Metadata               *map[string]interface{} `xorm:"-"`
+	ValidateMetadataOnPush bool                    `xorm:"NOT NULL DEFAULT false"`
+	CatalogBranches        string                  `xorm:"TEXT"`
 	/*** DCS Customizations ***/
 
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
@@ -465,6 +468,52 @@ func (repo *Repository) GetOwner() error {
 	return repo.getOwner(x)
 }
 
+/*** DCS Customizations ***/
+
+// IsMetadataValidatedOnPush returns true if pushes to protected branches and tags of the repository must have valid
+// metadata, either because the repository enables it or because its organization enables it for all its repositories
+func (repo *Repository) IsMetadataValidatedOnPush() (bool, error) {
+	if repo.ValidateMetadataOnPush {
+		return true, nil
+	}
+	if err := repo.GetOwner(); err != nil {
+		return false, err
+	}
+	return repo.Owner.IsOrganization() && repo.Owner.ValidateMetadataOnPush, nil
+}
+
+// GetCatalogBranchPatterns parses the semicolon separated list of the names or glob patterns of the branches the
+// repository has in the catalog besides its default branch and returns a glob.Glob slice
+func (repo *Repository) GetCatalogBranchPatterns() []glob.Glob {
+	patterns := make([]glob.Glob, 0, 5)
+	for _, expr := range strings.Split(repo.CatalogBranches, ";") {
+		expr = strings.TrimSpace(expr)
+		if expr != "" {
+			if g, err := glob.Compile(expr, '/'); err != nil {
+				log.Info("Invalid glob expression '%s' (skipped): %v", expr, err)
+			} else {
+				patterns = append(patterns, g)
+			}
+		}
+	}
+	return patterns
+}
+
+// IsCatalogBranch returns true if the branch is one the repository has in the catalog besides its default branch
+func (repo *Repository) IsCatalogBranch(branch string) bool {
+	if branch == repo.DefaultBranch {
+		return false
+	}
+	for _, pattern := range repo.GetCatalogBranchPatterns() {
+		if pattern.Match(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+/*** END DCS Customizations ***/
+
 func (repo *Repository) mustOwner(e Engine) *User {
 	if err := repo.getOwner(e); err != nil {
 		return &User{
@@ -1313,6 +1362,12 @@ func updateRepository(e Engine, repo *Repository, visibilityChanged bool) (err e
 			}
 		}
 
+		/*** DCS Customizations ***/
+		if err = updateDoor43MetadataVisibility(e, repo); err != nil {
+			return fmt.Errorf("updateDoor43MetadataVisibility: %v", err)
+		}
+		/*** END DCS Customizations ***/
+
 		// Create/Remove git-daemon-export-ok for git-daemon...
 		daemonExportFile := path.Join(repo.RepoPath(), `git-daemon-export-ok`)
 		isExist, err := util.IsExist(daemonExportFile)
@@ -1496,6 +1551,8 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Comment{RefRepoID: repoID},
 		&CommitStatus{RepoID: repoID},
 		&DeletedBranch{RepoID: repoID},
+		&Door43MetadataBookStats{RepoID: repoID},  // DCS Customizations
+		&Door43MetadataValidation{RepoID: repoID}, // DCS Customizations
 		&HookTask{RepoID: repoID},
 		&LFSLock{RepoID: repoID},
 		&LanguageStat{RepoID: repoID},
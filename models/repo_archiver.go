@@ -28,6 +28,7 @@ type RepoArchiver struct {
 	Type        git.ArchiveType `xorm:"unique(s)"`
 	Status      RepoArchiverStatus
 	CommitID    string             `xorm:"VARCHAR(40) unique(s)"`
+	Subset      string             `xorm:"VARCHAR(40) unique(s) NOT NULL DEFAULT ''"` // DCS Customizations
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
 }
 
@@ -57,13 +58,27 @@ func (archiver *RepoArchiver) RelativePath() (string, error) {
 		return "", err
 	}
 
+	/*** DCS Customizations ***/
+	if archiver.Subset != "" {
+		return fmt.Sprintf("%s/%s/%s-%s.%s", repo.FullName(), archiver.CommitID[:2], archiver.CommitID, archiver.Subset, archiver.Type.String()), nil
+	}
+	/*** END DCS Customizations ***/
+
 	return fmt.Sprintf("%s/%s/%s.%s", repo.FullName(), archiver.CommitID[:2], archiver.CommitID, archiver.Type.String()), nil
 }
 
 // GetRepoArchiver get an archiver
 func GetRepoArchiver(ctx DBContext, repoID int64, tp git.ArchiveType, commitID string) (*RepoArchiver, error) {
+	return GetRepoArchiverOfSubset(ctx, repoID, tp, commitID, "") // DCS Customizations
+}
+
+/*** DCS Customizations ***/
+
+// GetRepoArchiverOfSubset get an archiver of a subset of the files of a commit, or of all of them if subset is empty
+func GetRepoArchiverOfSubset(ctx DBContext, repoID int64, tp git.ArchiveType, commitID, subset string) (*RepoArchiver, error) {
+	/*** END DCS Customizations ***/
 	var archiver RepoArchiver
-	has, err := ctx.e.Where("repo_id=?", repoID).And("`type`=?", tp).And("commit_id=?", commitID).Get(&archiver)
+	has, err := ctx.e.Where("repo_id=?", repoID).And("`type`=?", tp).And("commit_id=?", commitID).And("subset=?", subset).Get(&archiver)
 	if err != nil {
 		return nil, err
 	}
@@ -13,7 +13,6 @@ import (
 
 	"xorm.io/builder"
 	"xorm.io/xorm"
-	"xorm.io/xorm/schemas"
 )
 
 // RepositoryListDefaultPageSize is the default number of repositories
@@ -344,20 +343,7 @@ func SearchRepositoryCondition(opts *SearchRepoOptions) builder.Cond {
 					likes = likes.Or(builder.Like{"LOWER(`repository`.description)", strings.ToLower(v)})
 				}
 				/*** DCS Customizations ***/
-				switch x.Dialect().URI().DBType {
-				case schemas.MYSQL:
-					likes = likes.Or(builder.Like{"LOWER(JSON_UNQUOTE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.title')))", strings.ToLower(v)})
-					likes = likes.Or(builder.Like{"LOWER(JSON_UNQUOTE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.subject')))", strings.ToLower(v)})
-					if opts.IncludeMetadata {
-						likes = likes.Or(builder.Expr("JSON_SEARCH(LOWER(`door43_metadata`.metadata), 'one', ?) IS NOT NULL", "%"+strings.ToLower(v)+"%"))
-					}
-				default:
-					likes = likes.Or(builder.Like{"`door43_metadata`.metadata", `"title": "%` + strings.ToLower(v) + `%"`})
-					likes = likes.Or(builder.Like{"`door43_metadata`.metadata", `"subject": "%` + strings.ToLower(v) + `%"`})
-					if opts.IncludeMetadata {
-						likes = likes.Or(builder.Like{"`door43_metadata`.metadata", `": "%` + strings.ToLower(v) + `%"`})
-					}
-				}
+				likes = likes.Or(GetKeywordMetadataCond(v, opts.IncludeMetadata))
 				/*** END DCS Customizations ***/
 			}
 			keywordCond = keywordCond.Or(likes)
@@ -456,7 +442,7 @@ func searchRepositoryByCondition(opts *SearchRepoOptions, cond builder.Cond) (*x
 		var err error
 		count, err = sess.
 			Join("INNER", "user", "`user`.id = `repository`.owner_id").
-			Join("LEFT", "door43_metadata", "`door43_metadata`.repo_id = `repository`.id AND `door43_metadata`.release_id = 0").
+			Join("LEFT", "door43_metadata", fmt.Sprintf("`door43_metadata`.repo_id = `repository`.id AND `door43_metadata`.release_id = 0 AND `door43_metadata`.stage = %d", StageLatest)).
 			Where(cond).
 			Count(new(Repository))
 		if err != nil {
@@ -467,7 +453,7 @@ func searchRepositoryByCondition(opts *SearchRepoOptions, cond builder.Cond) (*x
 
 	sess.
 		Join("INNER", "user", "`user`.id = `repository`.owner_id").
-		Join("LEFT", "door43_metadata", "`door43_metadata`.repo_id = `repository`.id AND `door43_metadata`.release_id = 0").
+		Join("LEFT", "door43_metadata", fmt.Sprintf("`door43_metadata`.repo_id = `repository`.id AND `door43_metadata`.release_id = 0 AND `door43_metadata`.stage = %d", StageLatest)).
 		Where(cond).
 		OrderBy("`repository`." + opts.OrderBy.String())
 	if opts.PageSize > 0 {
@@ -0,0 +1,123 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// RepoScrub is the audit record of scrubbing the history of a repo, or previewing it with a dry run. It is kept when
+// the repo is deleted, so it records the full name the repo had.
+type RepoScrub struct {
+	ID           int64       `xorm:"pk autoincr"`
+	RepoID       int64       `xorm:"INDEX NOT NULL"`
+	Repo         *Repository `xorm:"-"`
+	RepoFullName string
+	DoerID       int64 `xorm:"INDEX NOT NULL"`
+	Doer         *User `xorm:"-"`
+	IsDryRun     bool  `xorm:"NOT NULL DEFAULT false"`
+	// Rules is the JSON of the rules that were applied
+	Rules string `xorm:"TEXT"`
+	// Identity is the "name <email>" that replaced the authors and committers, "" if they were kept
+	Identity   string
+	NumRefs    int
+	NumCommits int
+	NumFiles   int
+	// Report is the JSON of the refs, commits and files that were scrubbed
+	Report      string             `xorm:"LONGTEXT"`
+	Error       string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// LoadAttributes loads the repo, nil if it has been deleted, and the doer of the scrub
+func (s *RepoScrub) LoadAttributes() error {
+	if s.Repo == nil {
+		repo, err := GetRepositoryByID(s.RepoID)
+		if err != nil && !IsErrRepoNotExist(err) {
+			return err
+		}
+		s.Repo = repo
+	}
+	if s.Doer == nil {
+		doer, err := GetUserByID(s.DoerID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			doer = NewGhostUser()
+		}
+		s.Doer = doer
+	}
+	return nil
+}
+
+// CreateRepoScrub records a scrub
+func CreateRepoScrub(s *RepoScrub) error {
+	_, err := x.Insert(s)
+	return err
+}
+
+// GetRepoScrubByID returns the scrub of the ID
+func GetRepoScrubByID(id int64) (*RepoScrub, error) {
+	s := &RepoScrub{}
+	has, err := x.ID(id).Get(s)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRepoScrubNotExist{id}
+	}
+	return s, s.LoadAttributes()
+}
+
+// FindRepoScrubsOptions options for listing scrubs
+type FindRepoScrubsOptions struct {
+	ListOptions
+	// RepoID lists the scrubs of a repo, 0 for those of every repo
+	RepoID int64
+}
+
+// FindRepoScrubs returns the scrubs, most recent first, with the total count
+func FindRepoScrubs(opts *FindRepoScrubsOptions) ([]*RepoScrub, int64, error) {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+
+	count, err := x.Where(cond).Count(new(RepoScrub))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.setDefaultValues()
+	scrubs := make([]*RepoScrub, 0, opts.PageSize)
+	if err := x.Where(cond).Desc("id").Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&scrubs); err != nil {
+		return nil, 0, err
+	}
+	for _, s := range scrubs {
+		if err := s.LoadAttributes(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return scrubs, count, nil
+}
+
+// ErrRepoScrubNotExist represents a "RepoScrubNotExist" kind of error.
+type ErrRepoScrubNotExist struct {
+	ID int64
+}
+
+// IsErrRepoScrubNotExist checks if an error is a ErrRepoScrubNotExist.
+func IsErrRepoScrubNotExist(err error) bool {
+	_, ok := err.(ErrRepoScrubNotExist)
+	return ok
+}
+
+func (err ErrRepoScrubNotExist) Error() string {
+	return fmt.Sprintf("repo scrub does not exist [id: %d]", err.ID)
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoScrubs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	assert.NoError(t, CreateRepoScrub(&RepoScrub{RepoID: repo.ID, RepoFullName: repo.FullName(), DoerID: 1, IsDryRun: true}))
+	assert.NoError(t, CreateRepoScrub(&RepoScrub{RepoID: repo.ID, RepoFullName: repo.FullName(), DoerID: 1, NumRefs: 2}))
+	assert.NoError(t, CreateRepoScrub(&RepoScrub{RepoID: 2, RepoFullName: "user2/repo2", DoerID: NonexistentID}))
+
+	scrubs, count, err := FindRepoScrubs(&FindRepoScrubsOptions{RepoID: repo.ID})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, scrubs, 2) {
+		assert.False(t, scrubs[0].IsDryRun)
+		assert.True(t, scrubs[1].IsDryRun)
+		assert.Equal(t, repo.ID, scrubs[0].Repo.ID)
+		assert.EqualValues(t, 1, scrubs[0].Doer.ID)
+	}
+
+	scrubs, count, err = FindRepoScrubs(&FindRepoScrubsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.Len(t, scrubs, 3)
+	// The doer of the scrub was deleted
+	assert.EqualValues(t, -1, scrubs[0].Doer.ID)
+
+	s, err := GetRepoScrubByID(scrubs[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2/repo2", s.RepoFullName)
+
+	_, err = GetRepoScrubByID(NonexistentID)
+	assert.True(t, IsErrRepoScrubNotExist(err))
+}
@@ -221,3 +221,18 @@ func TestRepoGetReviewerTeams(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, teams, 2)
 }
+
+func TestRepoIsCatalogBranch(t *testing.T) {
+	repo := &Repository{DefaultBranch: "master", CatalogBranches: "review; checking;release/*;;[invalid"}
+	assert.Len(t, repo.GetCatalogBranchPatterns(), 3)
+	assert.True(t, repo.IsCatalogBranch("review"))
+	assert.True(t, repo.IsCatalogBranch("checking"))
+	assert.True(t, repo.IsCatalogBranch("release/v2"))
+	assert.False(t, repo.IsCatalogBranch("release/v2/fix"))
+	assert.False(t, repo.IsCatalogBranch("reviews"))
+	assert.False(t, repo.IsCatalogBranch("master"))
+
+	repo.CatalogBranches = "*"
+	assert.True(t, repo.IsCatalogBranch("develop"))
+	assert.False(t, repo.IsCatalogBranch("master"))
+}
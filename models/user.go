@@ -157,6 +157,7 @@ type User struct {
 	MembersIsPublic           map[int64]bool      `xorm:"-"`
 	Visibility                structs.VisibleType `xorm:"NOT NULL DEFAULT 0"`
 	RepoAdminChangeTeamAccess bool                `xorm:"NOT NULL DEFAULT false"`
+	ValidateMetadataOnPush    bool                `xorm:"NOT NULL DEFAULT false"` // DCS Customizations
 
 	// Preferences
 	DiffViewStyle       string `xorm:"NOT NULL DEFAULT ''"`
@@ -1077,18 +1078,53 @@ func updateUser(e Engine, u *User) error {
 		return err
 	}
 
-	_, err := e.ID(u.ID).AllCols().Update(u)
-	return err
+	/*** DCS Customizations ***/
+	visibilityChanged, err := isUserVisibilityChanged(e, u)
+	if err != nil {
+		return err
+	}
+	/*** END DCS Customizations ***/
+
+	if _, err = e.ID(u.ID).AllCols().Update(u); err != nil {
+		return err
+	}
+
+	/*** DCS Customizations ***/
+	if visibilityChanged {
+		return updateOwnerDoor43MetadataVisibility(e, u)
+	}
+	/*** END DCS Customizations ***/
+	return nil
 }
 
 // UpdateUser updates user's information.
 func UpdateUser(u *User) error {
-	return updateUser(x, u)
+	/*** DCS Customizations - The catalog entries of the user's repos are updated too if its visibility changes ***/
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if err := updateUser(sess, u); err != nil {
+		return err
+	}
+	return sess.Commit()
+	/*** END DCS Customizations ***/
 }
 
 // UpdateUserCols update user according special columns
 func UpdateUserCols(u *User, cols ...string) error {
-	return updateUserCols(x, u, cols...)
+	/*** DCS Customizations - The catalog entries of the user's repos are updated too if its visibility changes ***/
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if err := updateUserCols(sess, u, cols...); err != nil {
+		return err
+	}
+	return sess.Commit()
+	/*** END DCS Customizations ***/
 }
 
 func updateUserCols(e Engine, u *User, cols ...string) error {
@@ -1096,8 +1132,26 @@ func updateUserCols(e Engine, u *User, cols ...string) error {
 		return err
 	}
 
-	_, err := e.ID(u.ID).Cols(cols...).Update(u)
-	return err
+	/*** DCS Customizations ***/
+	visibilityChanged := false
+	if util.IsStringInSlice("visibility", cols, true) {
+		var err error
+		if visibilityChanged, err = isUserVisibilityChanged(e, u); err != nil {
+			return err
+		}
+	}
+	/*** END DCS Customizations ***/
+
+	if _, err := e.ID(u.ID).Cols(cols...).Update(u); err != nil {
+		return err
+	}
+
+	/*** DCS Customizations ***/
+	if visibilityChanged {
+		return updateOwnerDoor43MetadataVisibility(e, u)
+	}
+	/*** END DCS Customizations ***/
+	return nil
 }
 
 // UpdateUserSetting updates user's settings.
@@ -1685,16 +1739,10 @@ func SearchUsers(opts *SearchUserOptions) (users []*User, _ int64, _ error) {
 	}
 
 	if len(opts.RepoLanguages) > 0 {
-		var langCond = builder.NewCond()
-		for _, lang := range opts.RepoLanguages {
-			for _, v := range strings.Split(lang, ",") {
-				langCond = langCond.Or(builder.Eq{"LOWER(REPLACE(JSON_EXTRACT(`door43_metadata`.metadata, '$.dublin_core.language.identifier'), '\"', ''))": strings.ToLower(v)})
-			}
-		}
 		metadataSelect := builder.Select("owner_id").
 			From("repository").
 			Join("INNER", "`door43_metadata`", "repo_id = `repository`.id").
-			Where(langCond)
+			Where(GetLanguageCond(opts.RepoLanguages))
 		sess.In("`user`.id", metadataSelect)
 	}
 
@@ -35,7 +35,7 @@ func (u *User) GetRepoLanguages() []string {
 			if dm, err := repo.GetDefaultBranchMetadata(); err != nil {
 				log.Error("Error GetDefaultBranchMetadata: %v", err)
 			} else if dm != nil {
-				lang = (*dm.Metadata)["dublin_core"].(map[string]interface{})["language"].(map[string]interface{})["identifier"].(string)
+				lang = dm.Language
 				if lang != "" && !contains(languages, lang) {
 					languages = append(languages, lang)
 				}
@@ -56,7 +56,7 @@ func (u *User) GetRepoSubjects() []string {
 			if dm, err := repo.GetDefaultBranchMetadata(); err != nil {
 				log.Error("Error GetDefaultBranchMetadata: %v", err)
 			} else if dm != nil {
-				subject := (*dm.Metadata)["dublin_core"].(map[string]interface{})["subject"].(string)
+				subject := dm.Subject
 				if subject != "" && !contains(subjects, subject) {
 					subjects = append(subjects, subject)
 				}
@@ -97,6 +97,7 @@ type HookEvents struct {
 	PullRequestSync      bool `json:"pull_request_sync"`
 	Repository           bool `json:"repository"`
 	Release              bool `json:"release"`
+	Catalog              bool `json:"catalog"` // DCS Customizations
 }
 
 // HookEvent represents events that will delivery hook.
@@ -293,6 +294,12 @@ func (w *Webhook) HasReleaseEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Release)
 }
 
+// HasCatalogEvent returns if hook enabled catalog event.
+func (w *Webhook) HasCatalogEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.Catalog)
+}
+
 // HasRepositoryEvent returns if hook enabled repository event.
 func (w *Webhook) HasRepositoryEvent() bool {
 	return w.SendEverything ||
@@ -328,6 +335,7 @@ func (w *Webhook) EventCheckers() []struct {
 		{w.HasPullRequestSyncEvent, HookEventPullRequestSync},
 		{w.HasRepositoryEvent, HookEventRepository},
 		{w.HasReleaseEvent, HookEventRelease},
+		{w.HasCatalogEvent, HookEventCatalog}, // DCS Customizations
 	}
 }
 
@@ -597,6 +605,7 @@ const (
 	HookEventPullRequestSync           HookEventType = "pull_request_sync"
 	HookEventRepository                HookEventType = "repository"
 	HookEventRelease                   HookEventType = "release"
+	HookEventCatalog                   HookEventType = "catalog" // DCS Customizations
 )
 
 // Event returns the HookEventType as an event string
@@ -627,6 +636,8 @@ func (h HookEventType) Event() string {
 		return "repository"
 	case HookEventRelease:
 		return "release"
+	case HookEventCatalog: // DCS Customizations
+		return "catalog"
 	}
 	return ""
 }
@@ -9,9 +9,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"strings"
+	"sync"
 
+	"code.gitea.io/gitea/modules/dcs"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/options"
@@ -109,39 +110,70 @@ func StringifyValidationErrors(result *gojsonschema.Result) string {
 
 // ValidateBlobByRC020Schema Validates a blob by the RC v0.2.0 schema and returns the result
 func ValidateBlobByRC020Schema(manifest *map[string]interface{}) (*gojsonschema.Result, error) {
-	schema, err := GetRC020Schema()
+	schema, err := dcs.RCSchemaRegistry.Value()
+	if err != nil {
+		return nil, err
+	}
+	return schema.(*gojsonschema.Schema).Validate(gojsonschema.NewGoLoader(manifest))
+}
+
+// GetRC020Schema Returns the schema for RC v0.2 from the options dir, or the refreshed copy if there is one
+func GetRC020Schema() ([]byte, error) {
+	return dcs.RCSchemaRegistry.Data()
+}
+
+// ValidateMetadataBySBSchema Validates Scripture Burrito metadata by the local subset of the SB schema the catalog
+// depends on and returns the result
+func ValidateMetadataBySBSchema(metadata *map[string]interface{}) (*gojsonschema.Result, error) {
+	return ValidateMetadataBySchema("sb.schema.json", metadata)
+}
+
+// ValidateMetadataBySchema Validates metadata by the named schema in the options dir and returns the result
+func ValidateMetadataBySchema(schemaName string, metadata *map[string]interface{}) (*gojsonschema.Result, error) {
+	schema, err := GetSchema(schemaName)
 	if err != nil {
 		return nil, err
 	}
 	schemaLoader := gojsonschema.NewBytesLoader(schema)
-	documentLoader := gojsonschema.NewGoLoader(manifest)
+	documentLoader := gojsonschema.NewGoLoader(metadata)
 
 	return gojsonschema.Validate(schemaLoader, documentLoader)
 }
 
-var rc02Schema []byte
+var (
+	schemas     = map[string][]byte{}
+	schemasLock sync.RWMutex
+)
 
-// GetRC020Schema Returns the schema for RC v0.2, first trying the online URL, then from file if not already done
-func GetRC020Schema() ([]byte, error) {
-	rc02SchmeFileName := "rc.schema.json"
-	schemaOnlineURL := "https://raw.githubusercontent.com/unfoldingWord/rc-schema/master/" + rc02SchmeFileName
-	if rc02Schema == nil {
-		var err error
-		if res, err := http.Get(schemaOnlineURL); err == nil {
-			defer res.Body.Close()
-			// read all
-			if body, err := ioutil.ReadAll(res.Body); err == nil {
-				rc02Schema = body
-			}
-		}
-		if rc02Schema == nil {
-			// Failed to get schema online, falling back to the one in the options dir
-			if rc02Schema, err = options.Schemas(rc02SchmeFileName); err != nil {
-				return nil, err
-			}
-		}
+// GetSchema Returns the named schema from the options dir if not already loaded
+func GetSchema(schemaName string) ([]byte, error) {
+	if schemaName == dcs.RCSchemaRegistry.Name {
+		return dcs.RCSchemaRegistry.Data()
+	}
+	schemasLock.RLock()
+	schema, ok := schemas[schemaName]
+	schemasLock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	schema, err := options.Schemas(schemaName)
+	if err != nil {
+		return nil, err
 	}
-	return rc02Schema, nil
+	schemasLock.Lock()
+	schemas[schemaName] = schema
+	schemasLock.Unlock()
+	return schema, nil
+}
+
+// GetSchemaVersion Returns the name of the schema with the checksum of its content, e.g. "rc.schema.json@0123456789",
+// to tell which copy of a refreshable schema something was validated by
+func GetSchemaVersion(schemaName string) (string, error) {
+	schema, err := GetSchema(schemaName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s@%s", schemaName, EncodeSha1(string(schema))[:10]), nil
 }
 
 // ReadYAMLFromBlob reads a yaml file from a blob and unmarshals it
@@ -162,6 +194,24 @@ func ReadYAMLFromBlob(blob *git.Blob) (*map[string]interface{}, error) {
 	return result, nil
 }
 
+// ReadJSONFromBlob reads a json file from a blob and unmarshals it
+func ReadJSONFromBlob(blob *git.Blob) (*map[string]interface{}, error) {
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		log.Warn("DataAsync Error: %v\n", err)
+		return nil, err
+	}
+	defer dataRc.Close()
+	content, _ := ioutil.ReadAll(dataRc)
+
+	var result *map[string]interface{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		log.Error("json.Unmarshal: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
 // ValidateJSONFromBlob reads a json file from a blob and unmarshals it returning any errors
 func ValidateJSONFromBlob(blob *git.Blob) error {
 	dataRc, err := blob.DataAsync()
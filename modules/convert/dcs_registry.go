@@ -0,0 +1,33 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToDCSSubject converts a models.DCSSubject to api.DCSSubject
+func ToDCSSubject(s *models.DCSSubject) *api.DCSSubject {
+	return &api.DCSSubject{
+		ResourceID: s.ResourceID,
+		Title:      s.Title,
+		AliasOf:    s.AliasOf,
+		Deprecated: s.IsDeprecated,
+		Builtin:    s.IsBuiltin,
+	}
+}
+
+// ToDCSLanguage converts a models.DCSLanguage to api.DCSLanguage
+func ToDCSLanguage(l *models.DCSLanguage) *api.DCSLanguage {
+	return &api.DCSLanguage{
+		Code:           l.Code,
+		Name:           l.Name,
+		AnglicizedName: l.AnglicizedName,
+		Direction:      l.Direction,
+		Gateway:        l.IsGateway,
+		Overridden:     l.IsOverridden,
+	}
+}
@@ -26,9 +26,9 @@ func ToDoor43MetadataV4(dm *models.Door43Metadata, mode models.AccessMode) *api.
 		ReleaseURL:             dm.GetReleaseURL(),
 		TarballURL:             dm.GetTarballURL(),
 		ZipballURL:             dm.GetZipballURL(),
-		Language:               (*dm.Metadata)["dublin_core"].(map[string]interface{})["language"].(map[string]interface{})["identifier"].(string),
-		Subject:                (*dm.Metadata)["dublin_core"].(map[string]interface{})["subject"].(string),
-		Title:                  (*dm.Metadata)["dublin_core"].(map[string]interface{})["title"].(string),
+		Language:               dm.Language,
+		Subject:                dm.Subject,
+		Title:                  dm.Title,
 		Books:                  dm.GetBooks(),
 		BranchOrTag:            dm.BranchOrTag,
 		Stage:                  dm.Stage.String(),
@@ -37,7 +37,7 @@ func ToDoor43MetadataV4(dm *models.Door43Metadata, mode models.AccessMode) *api.
 		MetadataURL:            dm.GetMetadataURL(),
 		MetadataJSONURL:        dm.GetMetadataJSONURL(),
 		MetadataAPIContentsURL: dm.GetMetadataAPIContentsURL(),
-		Ingredients:            (*dm.Metadata)["projects"].([]interface{}),
+		Ingredients:            dm.Ingredients,
 	}
 }
 
@@ -66,17 +66,69 @@ func ToDoor43MetadataV5(dm *models.Door43Metadata, mode models.AccessMode) *api.
 		Release:                release,
 		TarballURL:             dm.GetTarballURL(),
 		ZipballURL:             dm.GetZipballURL(),
-		Language:               (*dm.Metadata)["dublin_core"].(map[string]interface{})["language"].(map[string]interface{})["identifier"].(string),
-		Subject:                (*dm.Metadata)["dublin_core"].(map[string]interface{})["subject"].(string),
-		Title:                  (*dm.Metadata)["dublin_core"].(map[string]interface{})["title"].(string),
+		Language:               dm.Language,
+		Subject:                dm.Subject,
+		Title:                  dm.Title,
 		Books:                  dm.GetBooks(),
 		BranchOrTag:            dm.BranchOrTag,
 		Stage:                  dm.Stage.String(),
 		Released:               dm.GetReleaseDateTime(),
+		MetadataType:           dm.MetadataType,
 		MetadataVersion:        dm.MetadataVersion,
 		MetadataURL:            dm.GetMetadataURL(),
 		MetadataJSONURL:        dm.GetMetadataJSONURL(),
 		MetadataAPIContentsURL: dm.GetMetadataAPIContentsURL(),
-		Ingredients:            (*dm.Metadata)["projects"].([]interface{}),
+		Ingredients:            dm.Ingredients,
+	}
+}
+
+// ToDoor43MetadataValidation converts a Door43MetadataValidation to api.Door43MetadataValidation
+func ToDoor43MetadataValidation(v *models.Door43MetadataValidation) *api.Door43MetadataValidation {
+	errors := make([]*api.Door43MetadataValidationError, len(v.Errors))
+	for i, e := range v.Errors {
+		errors[i] = &api.Door43MetadataValidationError{
+			Field:       e.Field,
+			Type:        e.Type,
+			Description: e.Description,
+			Value:       e.Value,
+		}
+	}
+	findings := make([]*api.Door43MetadataFinding, len(v.Findings))
+	for i, f := range v.Findings {
+		findings[i] = &api.Door43MetadataFinding{
+			Check:    f.Check,
+			Severity: string(f.Severity),
+			Path:     f.Path,
+			Message:  f.Message,
+		}
+	}
+	return &api.Door43MetadataValidation{
+		Ref:           v.Ref,
+		RefType:       v.RefType,
+		CommitSHA:     v.CommitSHA,
+		MetadataType:  v.MetadataType,
+		Filename:      v.Filename,
+		Schema:        v.Schema,
+		IsValid:       v.IsValid,
+		Errors:        errors,
+		Findings:      findings,
+		IsPublishable: v.IsPublishable(),
+		Validated:     v.UpdatedUnix.AsTime(),
+	}
+}
+
+// ToDoor43MetadataBookStats converts a Door43MetadataBookStats to api.Door43MetadataBookStats
+func ToDoor43MetadataBookStats(s *models.Door43MetadataBookStats) *api.Door43MetadataBookStats {
+	return &api.Door43MetadataBookStats{
+		Book:             s.Book,
+		Path:             s.Path,
+		Chapters:         s.Chapters,
+		ExpectedChapters: s.ExpectedChapters,
+		Verses:           s.Verses,
+		ExpectedVerses:   s.ExpectedVerses,
+		VersePercent:     s.VersePercent(),
+		Words:            s.Words,
+		AlignedWords:     s.AlignedWords,
+		AlignmentPercent: s.AlignmentPercent(),
 	}
 }
@@ -0,0 +1,34 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToReleaseBundle converts a models.ReleaseBundle with its attributes loaded to api.ReleaseBundle, without the
+// catalog entries of its members
+func ToReleaseBundle(b *models.ReleaseBundle, doer *models.User) *api.ReleaseBundle {
+	result := &api.ReleaseBundle{
+		ID:           b.ID,
+		Owner:        b.Owner.Name,
+		Name:         b.Name,
+		TagName:      b.TagName,
+		Title:        b.Title,
+		Note:         b.Note,
+		IsPrerelease: b.IsPrerelease,
+		Publisher:    ToUser(b.Publisher, doer),
+		Members:      make([]*api.ReleaseBundleMember, len(b.Members)),
+		Created:      b.CreatedUnix.AsTime(),
+	}
+	for i, member := range b.Members {
+		result.Members[i] = &api.ReleaseBundleMember{
+			Repo:      member.RepoFullName,
+			ReleaseID: member.ReleaseID,
+		}
+	}
+	return result
+}
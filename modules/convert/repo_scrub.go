@@ -0,0 +1,37 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"encoding/json"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToRepoScrub converts a models.RepoScrub to api.RepoScrub
+func ToRepoScrub(s *models.RepoScrub, doer *models.User) *api.RepoScrub {
+	result := &api.RepoScrub{
+		ID:         s.ID,
+		Repository: s.RepoFullName,
+		Doer:       ToUser(s.Doer, doer),
+		DryRun:     s.IsDryRun,
+		Identity:   s.Identity,
+		Error:      s.Error,
+		Created:    s.CreatedUnix.AsTime(),
+	}
+	if s.Rules != "" {
+		if err := json.Unmarshal([]byte(s.Rules), &result.Rules); err != nil {
+			log.Error("Unable to unmarshal the rules of repo scrub %d: %v", s.ID, err)
+		}
+	}
+	if s.Report != "" {
+		if err := json.Unmarshal([]byte(s.Report), &result.Report); err != nil {
+			log.Error("Unable to unmarshal the report of repo scrub %d: %v", s.ID, err)
+		}
+	}
+	return result
+}
@@ -5,6 +5,8 @@
 package convert
 
 import (
+	"strconv"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/dcs"
 	"code.gitea.io/gitea/modules/log"
@@ -112,11 +114,13 @@ func innerToRepo(repo *models.Repository, mode models.AccessMode, isParent bool)
 	var language, title, subject, checkingLevel string
 	var books []string
 	if metadata != nil {
-		language = (*metadata.Metadata)["dublin_core"].(map[string]interface{})["language"].(map[string]interface{})["identifier"].(string)
-		title = (*metadata.Metadata)["dublin_core"].(map[string]interface{})["title"].(string)
-		subject = (*metadata.Metadata)["dublin_core"].(map[string]interface{})["subject"].(string)
+		language = metadata.Language
+		title = metadata.Title
+		subject = metadata.Subject
 		books = metadata.GetBooks()
-		checkingLevel = (*metadata.Metadata)["checking"].(map[string]interface{})["checking_level"].(string)
+		if metadata.CheckingLevel > 0 {
+			checkingLevel = strconv.Itoa(metadata.CheckingLevel)
+		}
 	} else {
 		language = dcs.GetLanguageFromRepoName(repo.LowerName)
 		subject = dcs.GetSubjectFromRepoName(repo.LowerName)
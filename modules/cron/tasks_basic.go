@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/dcs"
 	metadata_service "code.gitea.io/gitea/modules/door43metadata"
 	"code.gitea.io/gitea/modules/migrations"
 	repository_service "code.gitea.io/gitea/modules/repository"
@@ -37,6 +38,37 @@ func registerUpdateDoor43MetadataTask() {
 	})
 }
 
+func registerDoor43MetadataTombstonesCleanup() {
+	RegisterTaskFatal("door43_metadata_tombstones_cleanup", &OlderThanConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+		},
+		OlderThan: 90 * 24 * time.Hour,
+	}, func(ctx context.Context, _ *models.User, config Config) error {
+		realConfig := config.(*OlderThanConfig)
+		return models.DeleteOldDoor43MetadataTombstones(ctx, realConfig.OlderThan)
+	})
+}
+
+func registerRefreshDCSRegistriesTask() {
+	// The bundled langnames.json is only a seed of ISO 639-3 codes without the gateway language flags and the
+	// languages tD adds, so the registries are refreshed from the start
+	RegisterTaskFatal("refresh_dcs_registries", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: true,
+		Schedule:   "@every 24h",
+	}, func(ctx context.Context, _ *models.User, _ Config) error {
+		err := dcs.RefreshRegistries(ctx)
+		// Languages are synced even if some registry could not be refreshed, as langnames.json may have been
+		if syncErr := models.SyncDCSLanguages(); syncErr != nil && err == nil {
+			err = syncErr
+		}
+		return err
+	})
+}
+
 func registerRepoHealthCheck() {
 	type RepoHealthCheckConfig struct {
 		BaseConfig
@@ -144,6 +176,8 @@ func initBasicTasks() {
 	registerSyncExternalUsers()
 	registerDeletedBranchesCleanup()
 	registerUpdateDoor43MetadataTask()
+	registerDoor43MetadataTombstonesCleanup()
+	registerRefreshDCSRegistriesTask()
 	if !setting.Repository.DisableMigrations {
 		registerUpdateMigrationPosterID()
 	}
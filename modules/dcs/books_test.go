@@ -0,0 +1,31 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookVerseCounts(t *testing.T) {
+	total := 0
+	for _, bookID := range BookIDs {
+		assert.Len(t, BookVerseCounts(bookID), bookChapterCounts[bookID], bookID)
+		total += BookVerseCount(bookID)
+	}
+	assert.Equal(t, 31102, total)
+	assert.Equal(t, 2461, BookVerseCount("PSA"))
+	assert.Equal(t, 176, BookVerseCounts("psa")[118])
+	assert.Nil(t, BookVerseCounts("obs"))
+	assert.Equal(t, 0, BookVerseCount("obs"))
+}
+
+func TestBookMinChapterCount(t *testing.T) {
+	assert.Equal(t, 50, BookMinChapterCount("GEN"))
+	assert.Equal(t, 3, BookMinChapterCount("jol"))
+	assert.Equal(t, 3, BookMinChapterCount("mal"))
+	assert.Equal(t, 0, BookMinChapterCount("obs"))
+}
@@ -6,33 +6,141 @@ package dcs
 
 import (
 	"encoding/json"
-	"net/http"
+	"fmt"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/options"
+	"code.gitea.io/gitea/modules/setting"
 )
 
-var langNames = map[string]interface{}{}
+// LangNamesRegistry is the langnames.json export of tD. The copy bundled in options/languages is only a seed of the
+// ISO 639-3 codes, without gateway language flags or the languages tD adds such as el-x-koine, until it is refreshed.
+var LangNamesRegistry = RegisterRegistry(&Registry{
+	Name:  "langnames.json",
+	Dir:   "languages",
+	URL:   func() string { return setting.DCS.LangNamesURL },
+	Load:  options.Languages,
+	Parse: parseLangNames,
+})
 
-// GetLangNames returns the langnames.json file from tD in a keyed map, loads from tD if not already loaded
-func GetLangNames() map[string]interface{} {
-	myClient := &http.Client{Timeout: 10 * time.Second}
+// parseLangNames parses a langnames.json array into a map keyed by language code
+func parseLangNames(data []byte) (interface{}, error) {
+	var langNamesArr []map[string]interface{}
+	if err := json.Unmarshal(data, &langNamesArr); err != nil {
+		return nil, err
+	}
+	langNames := make(map[string]interface{}, len(langNamesArr))
+	for _, value := range langNamesArr {
+		lc, ok := value["lc"].(string)
+		if !ok || lc == "" {
+			return nil, fmt.Errorf("language without a language code: %v", value)
+		}
+		langNames[lc] = value
+	}
 	if len(langNames) == 0 {
-		url := "https://td.unfoldingword.org/exports/langnames.json"
-		response, err := myClient.Get(url)
-		if err == nil {
-			defer response.Body.Close()
-			langNamesArr := &[]map[string]interface{}{}
-			if err := json.NewDecoder(response.Body).Decode(langNamesArr); err != nil {
-				log.Error("Unable to decode langnames.json from tD: %v", err)
-			}
-			for _, value := range *langNamesArr {
-				langNames[value["lc"].(string)] = value
-			}
+		return nil, fmt.Errorf("no languages")
+	}
+	return langNames, nil
+}
+
+// GetLangNames returns the langnames.json file from tD in a keyed map, loads from the options dir if not already loaded
+func GetLangNames() map[string]interface{} {
+	value, err := LangNamesRegistry.Value()
+	if err != nil {
+		log.Error("Unable to load langnames.json: %v", err)
+		return map[string]interface{}{}
+	}
+	return value.(map[string]interface{})
+}
+
+// Language is a language of the language registry
+type Language struct {
+	Code           string
+	Name           string
+	AnglicizedName string
+	Direction      string
+	IsGateway      bool
+}
+
+var languageRegistry struct {
+	lock      sync.RWMutex
+	languages map[string]*Language
+}
+
+// GetBuiltinLanguages returns the languages of langnames.json sorted by code, which seed the language registry in
+// the database
+func GetBuiltinLanguages() []*Language {
+	langNames := GetLangNames()
+	languages := make([]*Language, 0, len(langNames))
+	for lc, value := range langNames {
+		languages = append(languages, langNameToLanguage(lc, value))
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Code < languages[j].Code })
+	return languages
+}
+
+// langNameToLanguage converts an entry of langnames.json to a Language
+func langNameToLanguage(lc string, value interface{}) *Language {
+	l := &Language{Code: lc, Direction: "ltr"}
+	if m, ok := value.(map[string]interface{}); ok {
+		l.Name, _ = m["ln"].(string)
+		l.AnglicizedName, _ = m["ang"].(string)
+		if ld, ok := m["ld"].(string); ok && ld != "" {
+			l.Direction = ld
+		}
+		l.IsGateway, _ = m["gw"].(bool)
+	}
+	return l
+}
+
+// SetLanguages replaces the language registry, e.g. with the one loaded from the database
+func SetLanguages(languages []*Language) {
+	m := make(map[string]*Language, len(languages))
+	for _, l := range languages {
+		m[l.Code] = l
+	}
+	languageRegistry.lock.Lock()
+	languageRegistry.languages = m
+	languageRegistry.lock.Unlock()
+}
+
+// GetLanguage returns the language of the code from the language registry, which is langnames.json until one is set,
+// nil if there is none
+func GetLanguage(code string) *Language {
+	languageRegistry.lock.RLock()
+	languages := languageRegistry.languages
+	languageRegistry.lock.RUnlock()
+	if languages != nil {
+		return languages[code]
+	}
+	if value, ok := GetLangNames()[code]; ok {
+		return langNameToLanguage(code, value)
+	}
+	return nil
+}
+
+// GetGatewayLanguageCodes returns the codes of the gateway languages of the language registry
+func GetGatewayLanguageCodes() []string {
+	languageRegistry.lock.RLock()
+	languages := languageRegistry.languages
+	languageRegistry.lock.RUnlock()
+	if languages == nil {
+		languages = make(map[string]*Language)
+		for _, l := range GetBuiltinLanguages() {
+			languages[l.Code] = l
+		}
+	}
+	codes := []string{}
+	for code, l := range languages {
+		if l.IsGateway {
+			codes = append(codes, code)
 		}
 	}
-	return langNames
+	sort.Strings(codes)
+	return codes
 }
 
 // GetLanguageFromRepoName determines the language of a repo by its repo name
@@ -46,7 +154,5 @@ func GetLanguageFromRepoName(repoName string) string {
 
 // IsValidLanguage returns true if string is a valid language code
 func IsValidLanguage(lang string) bool {
-	ln := GetLangNames()
-	_, ok := ln[lang]
-	return ok
+	return GetLanguage(lang) != nil
 }
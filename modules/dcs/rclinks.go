@@ -0,0 +1,70 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RCLinkRegexp matches the rc:// links in text, e.g. rc://*/ta/man/translate/figs-metaphor, without the punctuation
+// that ends a sentence
+var RCLinkRegexp = regexp.MustCompile(`rc://[^\s\[\]()<>"']*[^\s\[\]()<>"'.,;:!?]`)
+
+// RCLink is a link to a file of a resource container, rc://<language>/<resource>/<type>/<path>, where the language
+// can be * for the language of the resource that links to it
+type RCLink struct {
+	Language string
+	Resource string
+	Type     string
+	Path     string
+}
+
+// ParseRCLink parses an rc:// link, returning nil if it is invalid
+func ParseRCLink(link string) *RCLink {
+	if !strings.HasPrefix(link, "rc://") {
+		return nil
+	}
+	parts := strings.SplitN(strings.Trim(strings.TrimPrefix(link, "rc://"), "/"), "/", 4)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil
+	}
+	l := &RCLink{
+		Language: parts[0],
+		Resource: strings.ToLower(parts[1]),
+	}
+	if len(parts) > 2 {
+		l.Type = parts[2]
+	}
+	if len(parts) > 3 && parts[3] != "" {
+		l.Path = path.Clean(parts[3])
+	}
+	return l
+}
+
+// RepoName returns the name of the repos of the linked resource, <language>_<resource> in lower case, using the
+// given language if the link's language is *
+func (l *RCLink) RepoName(lang string) string {
+	if l.Language != "*" {
+		lang = l.Language
+	}
+	return strings.ToLower(lang + "_" + l.Resource)
+}
+
+// FilePath returns the path of the linked file in the repo, which is the 01.md of a translationAcademy article and the
+// markdown file of a translationWords entry
+func (l *RCLink) FilePath() string {
+	switch {
+	case l.Path == "":
+		return ""
+	case l.Resource == "ta" && l.Type == "man":
+		return l.Path + "/01.md"
+	case l.Resource == "tw" && l.Type == "dict":
+		return l.Path + ".md"
+	default:
+		return l.Path
+	}
+}
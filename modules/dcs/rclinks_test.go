@@ -0,0 +1,32 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRCLink(t *testing.T) {
+	l := ParseRCLink("rc://*/ta/man/translate/figs-metaphor")
+	assert.Equal(t, &RCLink{Language: "*", Resource: "ta", Type: "man", Path: "translate/figs-metaphor"}, l)
+	assert.Equal(t, "fr_ta", l.RepoName("fr"))
+	assert.Equal(t, "translate/figs-metaphor/01.md", l.FilePath())
+
+	l = ParseRCLink("rc://en/TW/dict/bible/kt/god")
+	assert.Equal(t, "en_tw", l.RepoName("fr"))
+	assert.Equal(t, "bible/kt/god.md", l.FilePath())
+
+	l = ParseRCLink("rc://en/ult/book/gen")
+	assert.Equal(t, "gen", l.FilePath())
+	assert.Equal(t, "", ParseRCLink("rc://en/ult").FilePath())
+
+	assert.Nil(t, ParseRCLink("rc://en"))
+	assert.Nil(t, ParseRCLink("https://door43.org/en/ult"))
+
+	assert.Equal(t, []string{"rc://*/ta/man/translate/figs-metaphor", "rc://en/tw/dict/bible/kt/god"},
+		RCLinkRegexp.FindAllString("See [[rc://*/ta/man/translate/figs-metaphor]] and [God](rc://en/tw/dict/bible/kt/god).", -1))
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// Registry is a data file DCS depends on, such as a schema or the language names, that is bundled in options/
+// so it works without network access. It can be refreshed from its source URL, in which case the fetched copy is
+// saved in the custom options dir so it overrides the bundled one from then on.
+type Registry struct {
+	Name  string                                 // file name, e.g. "langnames.json"
+	Dir   string                                 // options subdirectory, e.g. "languages"
+	URL   func() string                          // source URL to refresh from, "" if it can't be refreshed
+	Load  func(name string) ([]byte, error)      // reads the file from the options dir, e.g. options.Languages
+	Parse func(data []byte) (interface{}, error) // parses and validates the data, nil to keep it as bytes
+
+	lock          sync.RWMutex
+	data          []byte
+	value         interface{}
+	refreshedUnix timeutil.TimeStamp
+	loaded        bool
+}
+
+// RegistryStatus is the status of a registry for display
+type RegistryStatus struct {
+	Name          string
+	URL           string
+	Size          int64
+	IsBundled     bool
+	RefreshedUnix timeutil.TimeStamp
+}
+
+var registries []*Registry
+
+// RegisterRegistry registers a registry so it is refreshed by RefreshRegistries and listed by GetRegistryStatuses
+func RegisterRegistry(r *Registry) *Registry {
+	registries = append(registries, r)
+	return r
+}
+
+// customPath returns the path the refreshed copy of the registry is saved to
+func (r *Registry) customPath() string {
+	return path.Join(setting.CustomPath, "options", r.Dir, r.Name)
+}
+
+// Data returns the content of the registry, loading it from the options dir if not already loaded
+func (r *Registry) Data() ([]byte, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.data, nil
+}
+
+// Value returns the content of the registry as parsed by its Parse function, loading it if not already loaded
+func (r *Registry) Value() (interface{}, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.value, nil
+}
+
+// parse parses the data with the registry's Parse function if it has one
+func (r *Registry) parse(data []byte) (interface{}, error) {
+	if r.Parse == nil {
+		return nil, nil
+	}
+	return r.Parse(data)
+}
+
+// load loads the registry from the options dir, preferring the refreshed copy in the custom dir, if not already loaded
+func (r *Registry) load() error {
+	r.lock.RLock()
+	loaded := r.loaded
+	r.lock.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.loaded {
+		return nil
+	}
+	data, err := r.Load(r.Name)
+	if err != nil {
+		return err
+	}
+	value, err := r.parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", r.Name, err)
+	}
+	r.data = data
+	r.value = value
+	r.refreshedUnix = 0
+	if fi, err := os.Stat(r.customPath()); err == nil {
+		r.refreshedUnix = timeutil.TimeStamp(fi.ModTime().Unix())
+	}
+	r.loaded = true
+	return nil
+}
+
+// Refresh fetches the registry from its source URL and, if valid, saves it and makes it the current copy
+func (r *Registry) Refresh(ctx context.Context) error {
+	url := ""
+	if r.URL != nil {
+		url = r.URL()
+	}
+	if url == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to fetch %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch %s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", url, err)
+	}
+	value, err := r.parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s from %s: %v", r.Name, url, err)
+	}
+
+	customPath := r.customPath()
+	if err := os.MkdirAll(path.Dir(customPath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(customPath, data, 0644); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	r.data = data
+	r.value = value
+	r.refreshedUnix = timeutil.TimeStampNow()
+	r.loaded = true
+	r.lock.Unlock()
+	log.Info("Refreshed %s from %s", r.Name, url)
+	return nil
+}
+
+// Status returns the status of the registry
+func (r *Registry) Status() RegistryStatus {
+	if err := r.load(); err != nil {
+		log.Error("Unable to load %s: %v", r.Name, err)
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	status := RegistryStatus{
+		Name:          r.Name,
+		Size:          int64(len(r.data)),
+		IsBundled:     r.refreshedUnix == 0,
+		RefreshedUnix: r.refreshedUnix,
+	}
+	if r.URL != nil {
+		status.URL = r.URL()
+	}
+	return status
+}
+
+// RefreshRegistries refreshes all the registries from their source URLs, continuing past any that fail
+func RefreshRegistries(ctx context.Context) error {
+	var errs []string
+	for _, r := range registries {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("aborted refreshing registries")
+		default:
+		}
+		if err := r.Refresh(ctx); err != nil {
+			log.Error("Refresh %s: %v", r.Name, err)
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to refresh %d of %d registries: %v", len(errs), len(registries), errs)
+	}
+	return nil
+}
+
+// GetRegistryStatuses returns the status of all the registries
+func GetRegistryStatuses() []RegistryStatus {
+	statuses := make([]RegistryStatus, 0, len(registries))
+	for _, r := range registries {
+		statuses = append(statuses, r.Status())
+	}
+	return statuses
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"code.gitea.io/gitea/modules/options"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	customPath, err := ioutil.TempDir("", "dcs-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(customPath)
+	setting.CustomPath = customPath
+	setting.StaticRootPath = "../.."
+
+	body := `not json`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	r := &Registry{
+		Name:  "langnames.json",
+		Dir:   "languages",
+		URL:   func() string { return server.URL },
+		Load:  options.Languages,
+		Parse: parseLangNames,
+	}
+
+	// The bundled copy is used without network access
+	value, err := r.Value()
+	assert.NoError(t, err)
+	assert.Contains(t, value, "en")
+	assert.True(t, r.Status().IsBundled)
+
+	// An invalid refresh keeps the current copy
+	assert.Error(t, r.Refresh(context.Background()))
+	value, err = r.Value()
+	assert.NoError(t, err)
+	assert.Contains(t, value, "en")
+	assert.True(t, r.Status().IsBundled)
+
+	body = `[{"lc": "xyz", "ln": "Test", "ang": "Test", "ld": "ltr"}]`
+	assert.NoError(t, r.Refresh(context.Background()))
+	value, err = r.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"xyz"}, keys(value.(map[string]interface{})))
+	assert.False(t, r.Status().IsBundled)
+	data, err := ioutil.ReadFile(path.Join(customPath, "options", "languages", "langnames.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	// No URL disables refreshing
+	r.URL = func() string { return "" }
+	assert.NoError(t, r.Refresh(context.Background()))
+	assert.Equal(t, "", r.Status().URL)
+}
+
+func keys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Relation is a resource that a resource container relates to, as listed in the dublin_core.relation of its
+// manifest, e.g. "en/ult" or "en/tw?v=21"
+type Relation struct {
+	Language   string
+	Identifier string
+	// Version is the version of the resource that is related to, empty for its latest version
+	Version string
+}
+
+// ParseRelation parses a relation of the form <language>/<identifier>[?v=<version>], returning nil if it is invalid
+func ParseRelation(s string) *Relation {
+	s = strings.TrimSpace(s)
+	var query string
+	if i := strings.Index(s, "?"); i >= 0 {
+		s, query = s[:i], s[i+1:]
+	}
+	parts := strings.Split(strings.Trim(s, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil
+	}
+	r := &Relation{
+		Language:   parts[0],
+		Identifier: strings.ToLower(parts[1]),
+	}
+	if values, err := url.ParseQuery(query); err == nil {
+		r.Version = strings.TrimSpace(values.Get("v"))
+	}
+	return r
+}
+
+// RepoName returns the name of the repos of the resource, <language>_<identifier> in lower case
+func (r *Relation) RepoName() string {
+	return strings.ToLower(r.Language + "_" + r.Identifier)
+}
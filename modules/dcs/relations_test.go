@@ -0,0 +1,21 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRelation(t *testing.T) {
+	assert.Equal(t, &Relation{Language: "en", Identifier: "ult"}, ParseRelation("en/ult"))
+	assert.Equal(t, &Relation{Language: "en", Identifier: "tw", Version: "21"}, ParseRelation("en/tw?v=21"))
+	assert.Equal(t, &Relation{Language: "el-x-koine", Identifier: "ugnt", Version: "0.14"}, ParseRelation(" el-x-koine/UGNT?v=0.14 "))
+	assert.Equal(t, "el-x-koine_ugnt", ParseRelation("el-x-koine/UGNT").RepoName())
+	assert.Nil(t, ParseRelation("ult"))
+	assert.Nil(t, ParseRelation("en/ult/extra"))
+	assert.Nil(t, ParseRelation(""))
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"code.gitea.io/gitea/modules/options"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// RCSchemaRegistry is the Resource Container v0.2 schema, bundled in options/schema
+var RCSchemaRegistry = RegisterRegistry(&Registry{
+	Name:  "rc.schema.json",
+	Dir:   "schema",
+	URL:   func() string { return setting.DCS.RCSchemaURL },
+	Load:  options.Schemas,
+	Parse: parseSchema,
+})
+
+// parseSchema compiles a JSON schema so only a valid one replaces the current copy
+func parseSchema(data []byte) (interface{}, error) {
+	return gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+}
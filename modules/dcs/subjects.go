@@ -5,11 +5,15 @@
 package dcs
 
 import (
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
-// Subjects are the valid subjects keyed by their resource ID
-var Subjects = map[string]string{
+// BuiltinSubjects are the subjects known out of the box keyed by their resource ID. They seed the subject registry in
+// the database, which is what is used once it is loaded.
+var BuiltinSubjects = map[string]string{
 	"obs-sn":      "OBS Study Notes",
 	"obs-sq":      "OBS Study Questions",
 	"obs-tn":      "OBS Translation Notes",
@@ -35,17 +39,116 @@ var Subjects = map[string]string{
 	"obs-twl-tsv": "TSV OBS Translation Words Links",
 }
 
+// ResourceIDRegexp matches a valid resource ID of a subject, e.g. obs-twl
+var ResourceIDRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Subject is a subject of the subject registry
+type Subject struct {
+	ResourceID string
+	Title      string
+	// AliasOf is the resource ID of the subject this is another name for, "" if it is not an alias
+	AliasOf string
+	// IsDeprecated is true if repos are no longer recognized as being of this subject by their name
+	IsDeprecated bool
+}
+
+var subjectRegistry struct {
+	lock     sync.RWMutex
+	subjects map[string]*Subject
+}
+
+// SetSubjects replaces the subject registry, e.g. with the one loaded from the database
+func SetSubjects(subjects []*Subject) {
+	m := make(map[string]*Subject, len(subjects))
+	for _, s := range subjects {
+		m[s.ResourceID] = s
+	}
+	subjectRegistry.lock.Lock()
+	subjectRegistry.subjects = m
+	subjectRegistry.lock.Unlock()
+}
+
+// getSubjects returns the subject registry, which is the built-in subjects until one is set
+func getSubjects() map[string]*Subject {
+	subjectRegistry.lock.RLock()
+	defer subjectRegistry.lock.RUnlock()
+	if subjectRegistry.subjects != nil {
+		return subjectRegistry.subjects
+	}
+	subjects := make(map[string]*Subject, len(BuiltinSubjects))
+	for resourceID, title := range BuiltinSubjects {
+		subjects[resourceID] = &Subject{ResourceID: resourceID, Title: title}
+	}
+	return subjects
+}
+
+// GetSubjects returns the subjects of the subject registry sorted by resource ID
+func GetSubjects() []*Subject {
+	subjects := make([]*Subject, 0, len(getSubjects()))
+	for _, s := range getSubjects() {
+		subjects = append(subjects, s)
+	}
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].ResourceID < subjects[j].ResourceID })
+	return subjects
+}
+
+// GetSubject returns the subject of the resource ID, or the subject it is an alias of, nil if there is none
+func GetSubject(resourceID string) *Subject {
+	subjects := getSubjects()
+	s := subjects[strings.ToLower(resourceID)]
+	if s != nil && s.AliasOf != "" {
+		if target, ok := subjects[s.AliasOf]; ok {
+			return target
+		}
+	}
+	return s
+}
+
+// GetSubjectTitles returns the titles a subject, given by its resource ID or title, is known by in metadata: its own
+// title and the titles of its aliases. It is just the given subject if it is not in the registry.
+func GetSubjectTitles(subject string) []string {
+	subjects := getSubjects()
+	var canonical *Subject
+	for _, s := range subjects {
+		if strings.EqualFold(s.ResourceID, subject) || strings.EqualFold(s.Title, subject) {
+			canonical = GetSubject(s.ResourceID)
+			if s.AliasOf == "" {
+				break
+			}
+		}
+	}
+	if canonical == nil {
+		return []string{subject}
+	}
+	titles := []string{canonical.Title}
+	for _, s := range subjects {
+		if s.AliasOf == canonical.ResourceID && s.Title != "" && !containsFold(titles, s.Title) {
+			titles = append(titles, s.Title)
+		}
+	}
+	return titles
+}
+
+func containsFold(items []string, item string) bool {
+	for _, i := range items {
+		if strings.EqualFold(i, item) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSubjectFromRepoName determines the subject of a repo by its repo name
 func GetSubjectFromRepoName(repoName string) string {
 	parts := strings.Split(repoName, "_")
 	if len(parts) == 2 && IsValidSubject(parts[1]) && IsValidLanguage(parts[0]) {
-		return Subjects[parts[1]]
+		return GetSubject(parts[1]).Title
 	}
 	return ""
 }
 
-// IsValidSubject returns true if it is a valid subject
+// IsValidSubject returns true if it is the resource ID of a subject that is not deprecated
 func IsValidSubject(subject string) bool {
-	_, ok := Subjects[subject]
-	return ok
+	s, ok := getSubjects()[subject]
+	return ok && !s.IsDeprecated
 }
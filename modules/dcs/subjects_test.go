@@ -0,0 +1,44 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectRegistry(t *testing.T) {
+	setting.StaticRootPath = "../.."
+	defer SetSubjects(nil)
+	defer SetLanguages(nil)
+
+	// The built-in subjects and languages are used until the registries are loaded
+	assert.Equal(t, "Translation Notes", GetSubjectFromRepoName("en_tn"))
+	assert.Equal(t, "en", GetLanguageFromRepoName("en_tn"))
+	assert.Empty(t, GetSubjectFromRepoName("en_unknown"))
+	assert.Equal(t, []string{"Translation Notes"}, GetSubjectTitles("tn"))
+	assert.Equal(t, []string{"Unknown"}, GetSubjectTitles("Unknown"))
+
+	SetSubjects([]*Subject{
+		{ResourceID: "tn", Title: "Translation Notes"},
+		{ResourceID: "tn-tsv", Title: "TSV Translation Notes", AliasOf: "tn"},
+		{ResourceID: "obs", Title: "Open Bible Stories", IsDeprecated: true},
+	})
+	SetLanguages([]*Language{
+		{Code: "en", Name: "English", Direction: "ltr", IsGateway: true},
+		{Code: "xyz", Name: "Test", Direction: "rtl"},
+	})
+
+	assert.Equal(t, "Translation Notes", GetSubjectFromRepoName("xyz_tn-tsv"))
+	assert.Empty(t, GetSubjectFromRepoName("en_obs"))
+	assert.Empty(t, GetLanguageFromRepoName("fr_tn"))
+	assert.ElementsMatch(t, []string{"Translation Notes", "TSV Translation Notes"}, GetSubjectTitles("TSV Translation Notes"))
+	assert.ElementsMatch(t, []string{"Translation Notes", "TSV Translation Notes"}, GetSubjectTitles("tn"))
+	assert.Equal(t, []string{"en"}, GetGatewayLanguageCodes())
+	assert.Equal(t, "rtl", GetLanguage("xyz").Direction)
+}
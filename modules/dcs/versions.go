@@ -0,0 +1,291 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	semverTagRegexp = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	dateTagRegexp   = regexp.MustCompile(`^[vV]?(\d{4})-?(\d{2})-?(\d{2})$`)
+	partialRegexp   = regexp.MustCompile(`^[vV]?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?$`)
+	nonAlnumRegexp  = regexp.MustCompile(`[^0-9a-z]+`)
+)
+
+// versionKeyPartWidth is the number of digits each of the major, minor and patch numbers are padded to in a version key
+const versionKeyPartWidth = 10
+
+// maxVersionKeyPart is the largest number of versionKeyPartWidth digits, which larger major, minor and patch numbers
+// are clamped to in a version key so it keeps its width and sorts with the keys of other versions
+const maxVersionKeyPart = 9999999999
+
+// UnknownVersionKey is the version key of tags that are not a version, sorting before all versions
+var UnknownVersionKey = strings.Repeat("0", 3*versionKeyPartWidth) + "a"
+
+// TagVersion is the version of a release's tag, either a semantic version (e.g. "v41.2.1", "v12.1") or a date (e.g.
+// "2021-05-18" or "20210518", which is 2021.5.18)
+type TagVersion struct {
+	Major      int64
+	Minor      int64
+	Patch      int64
+	Prerelease string
+}
+
+// ParseTagVersion parses the version of a release's tag, returning nil if it is neither a semantic version nor a date
+func ParseTagVersion(tag string) *TagVersion {
+	tag = strings.TrimSpace(tag)
+	if m := dateTagRegexp.FindStringSubmatch(tag); m != nil {
+		return &TagVersion{Major: atoi64(m[1]), Minor: atoi64(m[2]), Patch: atoi64(m[3])}
+	}
+	if m := semverTagRegexp.FindStringSubmatch(tag); m != nil {
+		return &TagVersion{Major: atoi64(m[1]), Minor: atoi64(m[2]), Patch: atoi64(m[3]), Prerelease: m[4]}
+	}
+	return nil
+}
+
+// Key returns the key of the version that sorts the same as the version, as a string of only digits and lower case
+// letters so it sorts the same with any database collation. A prerelease sorts before its release.
+func (v *TagVersion) Key() string {
+	key := v.numbersKey()
+	if v.Prerelease == "" {
+		return key + "z"
+	}
+	key += "a" + nonAlnumRegexp.ReplaceAllString(strings.ToLower(v.Prerelease), "")
+	if len(key) > 100 {
+		key = key[:100]
+	}
+	return key
+}
+
+// numbersKey returns the start of the key of the version, before all its prereleases
+func (v *TagVersion) numbersKey() string {
+	return fmt.Sprintf("%0*d%0*d%0*d", versionKeyPartWidth, clampVersionKeyPart(v.Major), versionKeyPartWidth,
+		clampVersionKeyPart(v.Minor), versionKeyPartWidth, clampVersionKeyPart(v.Patch))
+}
+
+// clampVersionKeyPart returns the number as it is in a version key, clamped to maxVersionKeyPart. A negative number
+// can only be the largest int64 overflowed by the range of a constraint, so is clamped too.
+func clampVersionKeyPart(n int64) int64 {
+	if n < 0 || n > maxVersionKeyPart {
+		return maxVersionKeyPart
+	}
+	return n
+}
+
+func (v *TagVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// GetTagVersionKey returns the version key of a release's tag, or UnknownVersionKey if it is not a version
+func GetTagVersionKey(tag string) string {
+	if v := ParseTagVersion(tag); v != nil {
+		return v.Key()
+	}
+	return UnknownVersionKey
+}
+
+// VersionComparator compares a version key with Key using Op, one of "=", ">", ">=", "<" and "<="
+type VersionComparator struct {
+	Op  string
+	Key string
+}
+
+// Check returns true if the version key satisfies the comparator
+func (c *VersionComparator) Check(key string) bool {
+	switch c.Op {
+	case "=":
+		return key == c.Key
+	case ">":
+		return key > c.Key
+	case ">=":
+		return key >= c.Key
+	case "<":
+		return key < c.Key
+	case "<=":
+		return key <= c.Key
+	}
+	return false
+}
+
+// VersionConstraint is a version range constraint such as "~41", "41.x", "^41.2", ">=40 <42" or ">=40 <42 || 45",
+// as alternatives of comparators all of which must be satisfied. A nil constraint, from "latest", "*" or "",
+// is satisfied by any version.
+type VersionConstraint [][]*VersionComparator
+
+// ParseVersionConstraint parses a version range constraint, with partial versions matching all the versions
+// starting with them
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "*" || strings.EqualFold(s, "latest") {
+		return nil, nil
+	}
+	var constraint VersionConstraint
+	for _, alternative := range strings.Split(s, "||") {
+		// Allows a space between an operator and its version, e.g. ">= 40"
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint: \"%s\"", s)
+		}
+		comparators := make([]*VersionComparator, 0, len(fields))
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+				i++
+				field += fields[i]
+			}
+			cs, err := parseVersionRange(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, cs...)
+		}
+		constraint = append(constraint, comparators)
+	}
+	return constraint, nil
+}
+
+// parseVersionRange parses a single operator and partial version into the comparators of the range it matches
+func parseVersionRange(s string) ([]*VersionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			break
+		}
+	}
+	version := strings.TrimPrefix(s, op)
+
+	// parts is how many of major, minor and patch are given, the rest being wildcards
+	var v *TagVersion
+	parts := 3
+	if m := dateTagRegexp.FindStringSubmatch(version); m != nil {
+		v = &TagVersion{Major: atoi64(m[1]), Minor: atoi64(m[2]), Patch: atoi64(m[3])}
+	} else if m := partialRegexp.FindStringSubmatch(version); m != nil {
+		v = &TagVersion{Major: atoi64(m[1]), Minor: atoi64(m[2]), Patch: atoi64(m[3]), Prerelease: m[4]}
+		for i, p := range m[1:4] {
+			if p == "" || strings.ContainsAny(p, "xX*") {
+				parts = i
+				break
+			}
+		}
+		if parts < 3 {
+			v.Patch, v.Prerelease = 0, ""
+		}
+		if parts < 2 {
+			v.Minor = 0
+		}
+	} else {
+		return nil, fmt.Errorf("invalid version constraint: \"%s\"", s)
+	}
+	lower := &VersionComparator{">=", v.numbersKey()}
+	if v.Prerelease != "" {
+		lower.Key = v.Key()
+	}
+
+	// next returns the start of the version after the versions starting with the given number of parts of v
+	next := func(parts int) *VersionComparator {
+		n := &TagVersion{Major: v.Major + 1}
+		if parts == 2 {
+			n = &TagVersion{Major: v.Major, Minor: v.Minor + 1}
+		} else if parts == 3 {
+			n = &TagVersion{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+		}
+		return &VersionComparator{"<", n.numbersKey()}
+	}
+
+	switch op {
+	case "", "=":
+		switch parts {
+		case 0:
+			return nil, nil
+		case 3:
+			return []*VersionComparator{{"=", v.Key()}}, nil
+		}
+		return []*VersionComparator{lower, next(parts)}, nil
+	case ">=":
+		if parts == 0 {
+			return nil, nil
+		}
+		return []*VersionComparator{lower}, nil
+	case ">":
+		if parts == 3 {
+			return []*VersionComparator{{">", v.Key()}}, nil
+		} else if parts > 0 {
+			after := next(parts)
+			after.Op = ">="
+			return []*VersionComparator{after}, nil
+		}
+	case "<":
+		if parts > 0 {
+			lower.Op = "<"
+			return []*VersionComparator{lower}, nil
+		}
+	case "<=":
+		if parts == 3 {
+			return []*VersionComparator{{"<=", v.Key()}}, nil
+		} else if parts > 0 {
+			return []*VersionComparator{next(parts)}, nil
+		}
+	case "~":
+		if parts == 1 {
+			return []*VersionComparator{lower, next(1)}, nil
+		} else if parts > 1 {
+			return []*VersionComparator{lower, next(2)}, nil
+		}
+	case "^":
+		if parts == 0 {
+			break
+		} else if v.Major > 0 || parts == 1 {
+			return []*VersionComparator{lower, next(1)}, nil
+		} else if v.Minor > 0 || parts == 2 {
+			return []*VersionComparator{lower, next(2)}, nil
+		}
+		return []*VersionComparator{lower, next(3)}, nil
+	}
+	return nil, fmt.Errorf("invalid version constraint: \"%s\"", s)
+}
+
+// Check returns true if the version of a release's tag satisfies the constraint. Tags that are not a version never do
+// unless the constraint is nil.
+func (c VersionConstraint) Check(tag string) bool {
+	return c.CheckKey(GetTagVersionKey(tag))
+}
+
+// CheckKey returns true if a version key, such as the one stored with a catalog entry, satisfies the constraint. The
+// key of a tag that is not a version never does unless the constraint is nil.
+func (c VersionConstraint) CheckKey(key string) bool {
+	if c == nil {
+		return true
+	}
+	if key == UnknownVersionKey {
+		return false
+	}
+	for _, comparators := range c {
+		ok := true
+		for _, comparator := range comparators {
+			if !comparator.Check(key) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func atoi64(s string) int64 {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dcs
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTagVersion(t *testing.T) {
+	assert.Equal(t, &TagVersion{Major: 12, Minor: 10}, ParseTagVersion("v12.10"))
+	assert.Equal(t, &TagVersion{Major: 41, Minor: 2, Patch: 1, Prerelease: "rc.1"}, ParseTagVersion("41.2.1-rc.1+build.5"))
+	assert.Equal(t, &TagVersion{Major: 2021, Minor: 5, Patch: 18}, ParseTagVersion("2021-05-18"))
+	assert.Equal(t, &TagVersion{Major: 2021, Minor: 5, Patch: 18}, ParseTagVersion("20210518"))
+	assert.Nil(t, ParseTagVersion("master"))
+	assert.Nil(t, ParseTagVersion("release-one"))
+	assert.Equal(t, UnknownVersionKey, GetTagVersionKey("master"))
+}
+
+func TestTagVersionKeySort(t *testing.T) {
+	tags := []string{"v12.10", "v12.2", "v12.1", "v2", "v12.1.0-rc1", "v12", "draft"}
+	sort.Slice(tags, func(i, j int) bool { return GetTagVersionKey(tags[i]) < GetTagVersionKey(tags[j]) })
+	assert.Equal(t, []string{"draft", "v2", "v12", "v12.1.0-rc1", "v12.1", "v12.2", "v12.10"}, tags)
+}
+
+func TestTagVersionKey_LongParts(t *testing.T) {
+	// parts of more than versionKeyPartWidth digits are clamped, keeping the key's width
+	assert.Len(t, GetTagVersionKey("v123456789012.1"), len(GetTagVersionKey("v1.1")))
+	assert.Equal(t, GetTagVersionKey("v9999999999.1"), GetTagVersionKey("v123456789012.1"))
+	assert.Equal(t, GetTagVersionKey("v9999999999.1"), GetTagVersionKey("v99999999999999999999.1"))
+	assert.True(t, GetTagVersionKey("v2.123456789012") < GetTagVersionKey("v3"))
+	assert.True(t, GetTagVersionKey("v2.123456789012") > GetTagVersionKey("v2.9"))
+
+	c, err := ParseVersionConstraint("~2")
+	assert.NoError(t, err)
+	assert.True(t, c.Check("v2.123456789012"))
+	assert.False(t, c.Check("v123456789012"))
+}
+
+func TestVersionConstraint(t *testing.T) {
+	kases := []struct {
+		constraint string
+		matches    []string
+		nonMatches []string
+	}{
+		{"latest", []string{"v1", "master"}, nil},
+		{"~41", []string{"v41", "v41.9.9", "41.0.0-rc1"}, []string{"v40.9", "v42", "master"}},
+		{"41.x", []string{"v41", "v41.3"}, []string{"v42"}},
+		{"~41.2", []string{"v41.2", "v41.2.9"}, []string{"v41.3", "v41.1"}},
+		{">=40 <42", []string{"v40", "v41.5"}, []string{"v39.9", "v42", "v42.0.0-rc1"}},
+		{">= 40 < 42", []string{"v40"}, []string{"v42"}},
+		{">41", []string{"v42"}, []string{"v41.9"}},
+		{">41.2.3", []string{"v41.2.4"}, []string{"v41.2.3"}},
+		{"<=41", []string{"v41.9"}, []string{"v42"}},
+		{"^41.2", []string{"v41.2", "v41.9"}, []string{"v41.1", "v42"}},
+		{"^0.2", []string{"v0.2.5"}, []string{"v0.3"}},
+		{"=41.2.3", []string{"v41.2.3"}, []string{"v41.2.4"}},
+		{"40 || 42", []string{"v40.1", "v42"}, []string{"v41"}},
+		{">=2021-01-01", []string{"2021-05-18"}, []string{"2020-12-31"}},
+	}
+	for _, kase := range kases {
+		c, err := ParseVersionConstraint(kase.constraint)
+		assert.NoError(t, err, kase.constraint)
+		for _, tag := range kase.matches {
+			assert.True(t, c.Check(tag), "%s should match %s", kase.constraint, tag)
+		}
+		for _, tag := range kase.nonMatches {
+			assert.False(t, c.Check(tag), "%s should not match %s", kase.constraint, tag)
+		}
+	}
+
+	for _, invalid := range []string{"foo", ">", "^*", "41 ||"} {
+		_, err := ParseVersionConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
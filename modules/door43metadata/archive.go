@@ -0,0 +1,89 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+)
+
+// archiveRootFiles are the files at the root of a repo that are archived with any of its books, along with its
+// metadata file, if they exist
+var archiveRootFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "media.yaml"}
+
+// ErrBookNotInEntry represents a "BookNotInEntry" kind of error.
+type ErrBookNotInEntry struct {
+	Book string
+}
+
+// IsErrBookNotInEntry checks if an error is a ErrBookNotInEntry.
+func IsErrBookNotInEntry(err error) bool {
+	_, ok := err.(ErrBookNotInEntry)
+	return ok
+}
+
+func (err ErrBookNotInEntry) Error() string {
+	return fmt.Sprintf("book is not in the catalog entry: \"%s\"", err.Book)
+}
+
+// GetBookPaths returns the paths of the ingredients of the given books of the entry, in the order of the books
+func GetBookPaths(dm *models.Door43Metadata, books []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, book := range books {
+		book = strings.ToLower(strings.TrimSpace(book))
+		found := false
+		for _, i := range dm.Ingredients {
+			ingredient, ok := i.(map[string]interface{})
+			if !ok || !strings.EqualFold(getString(ingredient, "identifier"), book) {
+				continue
+			}
+			p := strings.Trim(path.Clean(getString(ingredient, "path")), "/")
+			if p == "" || p == "." || strings.HasPrefix(p, "..") {
+				continue
+			}
+			found = true
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+		if !found {
+			return nil, ErrBookNotInEntry{Book: book}
+		}
+	}
+	return paths, nil
+}
+
+// GetArchivePaths returns the paths of the commit to archive for the given books of the entry: those of their
+// ingredients along with the metadata file and the root files, such as the license, that exist in the commit
+func GetArchivePaths(dm *models.Door43Metadata, commit *git.Commit, books []string) ([]string, error) {
+	paths, err := GetBookPaths(dm, books)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		if _, err := commit.GetTreeEntryByPath(p); err != nil {
+			if git.IsErrNotExist(err) {
+				return nil, fmt.Errorf("ingredient of the catalog entry does not exist: \"%s\"", p)
+			}
+			return nil, err
+		}
+	}
+	for _, p := range append([]string{dm.GetMetadataFilename()}, archiveRootFiles...) {
+		if _, err := commit.GetTreeEntryByPath(p); err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
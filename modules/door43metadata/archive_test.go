@@ -0,0 +1,38 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBookPaths(t *testing.T) {
+	dm := &models.Door43Metadata{
+		Ingredients: []interface{}{
+			map[string]interface{}{"identifier": "gen", "path": "./01-GEN.usfm"},
+			map[string]interface{}{"identifier": "rut", "path": "./08-RUT.usfm"},
+			map[string]interface{}{"identifier": "tit", "path": "./content/tit/"},
+			map[string]interface{}{"identifier": "all", "path": "./"},
+		},
+	}
+
+	paths, err := GetBookPaths(dm, []string{"RUT", "gen", "tit"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"08-RUT.usfm", "01-GEN.usfm", "content/tit"}, paths)
+
+	paths, err = GetBookPaths(dm, []string{"gen", "gen"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"01-GEN.usfm"}, paths)
+
+	_, err = GetBookPaths(dm, []string{"gen", "exo"})
+	assert.True(t, IsErrBookNotInEntry(err))
+
+	_, err = GetBookPaths(dm, []string{"all"})
+	assert.True(t, IsErrBookNotInEntry(err))
+}
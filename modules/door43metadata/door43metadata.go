@@ -9,7 +9,8 @@ import (
 	"reflect"
 
 	"code.gitea.io/gitea/models"
-	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/dcs"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/structs"
@@ -27,17 +28,17 @@ func GenerateDoor43Metadata(x *xorm.Engine) error {
 
 	// Query to find repos that need processing, either having releases that
 	// haven't been processed, or their default branch hasn't been processed.
-	records, err := sess.Query("SELECT rel.id as release_id, r.id as repo_id  FROM `repository` r " +
-		"  JOIN `release` rel ON rel.repo_id = r.id " +
-		"  LEFT JOIN `door43_metadata` dm ON r.id = dm.repo_id " +
-		"  AND rel.id = dm.release_id " +
-		"  WHERE dm.id IS NULL AND rel.is_tag = 0 " +
-		"UNION " +
-		"SELECT 0 as `release_id`, r2.id as repo_id FROM `repository` r2 " +
-		"  LEFT JOIN `door43_metadata` dm2 ON r2.id = dm2.repo_id " +
-		"  AND dm2.release_id = 0 " +
-		"  WHERE dm2.id IS NULL " +
-		"ORDER BY repo_id ASC, release_id ASC")
+	records, err := sess.Query("SELECT rel.id as release_id, r.id as repo_id  FROM `repository` r "+
+		"  JOIN `release` rel ON rel.repo_id = r.id "+
+		"  LEFT JOIN `door43_metadata` dm ON r.id = dm.repo_id "+
+		"  AND rel.id = dm.release_id "+
+		"  WHERE dm.id IS NULL AND rel.is_tag = ? "+
+		"UNION "+
+		"SELECT 0 as `release_id`, r2.id as repo_id FROM `repository` r2 "+
+		"  LEFT JOIN `door43_metadata` dm2 ON r2.id = dm2.repo_id "+
+		"  AND dm2.release_id = 0 AND dm2.stage = ? "+
+		"  WHERE dm2.id IS NULL "+
+		"ORDER BY repo_id ASC, release_id ASC", false, models.StageLatest)
 	if err != nil {
 		return err
 	}
@@ -71,6 +72,90 @@ func GenerateDoor43Metadata(x *xorm.Engine) error {
 	return nil
 }
 
+// Init seeds and loads the subject and language registries, sets the ref types of the metadata validations saved
+// before they had one, normalizes the catalog columns of the door43 metadatas created before they existed, all of which
+// were RCs, sets the version keys of the ones of releases created before it existed, and normalizes the relations of
+// the RCs created before they were
+func Init() error {
+	if err := models.InitDCSRegistries(); err != nil {
+		return err
+	}
+	if err := models.SetDoor43MetadataValidationRefTypes(); err != nil {
+		return err
+	}
+
+	format := GetFormat(models.MetadataTypeRC)
+	var lastID int64
+	for {
+		dms, err := models.GetDoor43MetadatasWithoutCatalogColumns(lastID, models.Door43MetadataListDefaultPageSize)
+		if err != nil {
+			return err
+		}
+		if len(dms) == 0 {
+			break
+		}
+		for _, dm := range dms {
+			lastID = dm.ID
+			if err := format.Normalize(dm); err != nil {
+				return err
+			}
+			if err := models.UpdateDoor43MetadataCatalogColumns(dm); err != nil {
+				return err
+			}
+		}
+	}
+
+	lastID = 0
+	for {
+		dms, err := models.GetDoor43MetadatasWithoutVersionKey(lastID, models.Door43MetadataListDefaultPageSize)
+		if err != nil {
+			return err
+		}
+		if len(dms) == 0 {
+			break
+		}
+		for _, dm := range dms {
+			lastID = dm.ID
+			dm.VersionKey = getVersionKey(dm.Release)
+			if dm.VersionKey == "" {
+				// The release no longer exists, so keep it from being found again
+				dm.VersionKey = dcs.UnknownVersionKey
+			}
+			if err := models.UpdateDoor43MetadataVersionKey(dm); err != nil {
+				return err
+			}
+		}
+	}
+
+	lastID = 0
+	for {
+		dms, err := models.GetDoor43MetadatasWithoutRelations(lastID, models.Door43MetadataListDefaultPageSize)
+		if err != nil {
+			return err
+		}
+		if len(dms) == 0 {
+			return nil
+		}
+		for _, dm := range dms {
+			lastID = dm.ID
+			if err := format.Normalize(dm); err != nil {
+				return err
+			}
+			if err := models.UpdateDoor43MetadataRelations(dm); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// getVersionKey returns the version key of the door43 metadata of a release, or of a default branch if release is nil
+func getVersionKey(release *models.Release) string {
+	if release == nil {
+		return ""
+	}
+	return dcs.GetTagVersionKey(release.TagName)
+}
+
 // ConvertGenericMapToRC020Manifest converts a generic map to a RC020Manifest object
 func ConvertGenericMapToRC020Manifest(manifest *map[string]interface{}) (*structs.RC020Manifest, error) {
 	var rc020manifest structs.RC020Manifest
@@ -130,10 +215,10 @@ func ProcessDoor43MetadataForRepo(repo *models.Repository) error {
 		return fmt.Errorf("no repository provided")
 	}
 
-	if repo.IsArchived || repo.IsPrivate {
-		_, err := models.DeleteAllDoor43MetadatasByRepoID(repo.ID)
+	if repo.IsArchived {
+		err := DeleteDoor43MetadatasOfRepo(repo, models.DeleteReasonArchived)
 		if err != nil {
-			log.Error("DeleteAllDoor43MetadatasByRepoID: %v", err)
+			log.Error("DeleteDoor43MetadatasOfRepo: %v", err)
 		}
 		return err
 	}
@@ -162,10 +247,54 @@ func ProcessDoor43MetadataForRepo(repo *models.Repository) error {
 			log.Info("Processed Metadata for repo %s (%d), %s (%d)\n", repo.Name, repo.ID, releaseRef, releaseID)
 		}
 	}
+
+	branches, err := getCatalogBranches(repo)
+	if err != nil {
+		log.Error("getCatalogBranches: %v", err)
+		return err
+	}
+	for _, branch := range branches {
+		log.Info("Processing Metadata for repo %s (%d), branch %s\n", repo.Name, repo.ID, branch)
+		if err = ProcessDoor43MetadataForRepoBranch(repo, branch); err != nil {
+			log.Warn("Error processing metadata for repo %s (%d), branch %s: %v\n", repo.Name, repo.ID, branch, err)
+		}
+	}
 	return nil
 }
 
-// ProcessDoor43MetadataForRepoRelease handles the metadata for a given repo by release based on if the container is a valid RC or not
+// getCatalogBranches returns the branches of a repo other than its default branch that are, or were until now, in the
+// catalog: the ones matching its catalog branch patterns and the ones it has entries of
+func getCatalogBranches(repo *models.Repository) ([]string, error) {
+	branches, err := models.GetDoor43MetadataBranchNames(repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(repo.GetCatalogBranchPatterns()) == 0 {
+		return branches, nil
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+	names, _, err := gitRepo.GetBranches(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(branches))
+	for _, branch := range branches {
+		seen[branch] = true
+	}
+	for _, name := range names {
+		if !seen[name] && repo.IsCatalogBranch(name) {
+			branches = append(branches, name)
+		}
+	}
+	return branches, nil
+}
+
+// ProcessDoor43MetadataForRepoRelease handles the metadata for a given repo by release based on if it has a valid metadata file of one of the registered formats
 func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *models.Release) error {
 	if repo == nil {
 		return fmt.Errorf("no repository provided")
@@ -173,6 +302,52 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 	if release != nil && release.IsTag {
 		return fmt.Errorf("release can only be a release, not a tag")
 	}
+	return processDoor43Metadata(repo, release, "")
+}
+
+// ProcessDoor43MetadataForRepoBranch handles the metadata for a branch of a repo other than its default branch, which is
+// in the catalog if the repo has it in its catalog branches and it has a valid metadata file, deleting its entry if the
+// branch was deleted or no longer is one of the repo's catalog branches
+func ProcessDoor43MetadataForRepoBranch(repo *models.Repository, branch string) error {
+	if repo == nil {
+		return fmt.Errorf("no repository provided")
+	}
+	if !repo.IsCatalogBranch(branch) || !git.IsBranchExist(repo.RepoPath(), branch) {
+		if branch != repo.DefaultBranch {
+			if err := models.DeleteDoor43MetadataBookStats(repo.ID, branch); err != nil {
+				return err
+			}
+			if err := models.DeleteDoor43MetadataValidation(repo.ID, models.ValidationRefTypeBranch, branch); err != nil {
+				return err
+			}
+		}
+		return deleteDoor43MetadataOfBranch(repo, branch)
+	}
+	return processDoor43Metadata(repo, nil, branch)
+}
+
+// deleteDoor43MetadataOfBranch deletes the door43 metadata of a branch the repo had in the catalog besides its default
+// branch, if any
+func deleteDoor43MetadataOfBranch(repo *models.Repository, branch string) error {
+	dm, err := models.GetDoor43MetadataByRepoIDAndBranch(repo.ID, branch)
+	if err != nil {
+		if models.IsErrDoor43MetadataNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteDoor43Metadatas(repo, []*models.Door43Metadata{dm}, func() error {
+		return models.DeleteDoor43Metadata(dm, models.DeleteReasonBranchRemoved)
+	})
+}
+
+// processDoor43Metadata handles the metadata of a repo's release, or of the branch if release is nil, which is its
+// default branch if branch is empty
+func processDoor43Metadata(repo *models.Repository, release *models.Release, branch string) error {
+	ref := branch
+	if ref == "" {
+		ref = getValidationRef(repo, release)
+	}
 
 	gitRepo, err := git.OpenRepository(repo.RepoPath())
 	if err != nil {
@@ -183,7 +358,7 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 
 	var commit *git.Commit
 	if release == nil {
-		commit, err = gitRepo.GetBranchCommit(repo.DefaultBranch)
+		commit, err = gitRepo.GetBranchCommit(ref)
 		if err != nil {
 			log.Error("GetBranchCommit: %v\n", err)
 			return err
@@ -202,23 +377,37 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 		}
 	}
 
-	blob, err := commit.GetBlobByPath("manifest.yaml")
-	if err != nil && !git.IsErrNotExist(err) {
+	format, metadata, err := DetectFormat(commit)
+	if err != nil {
 		return err
 	}
-	if blob == nil {
-		return nil
+	if format == nil {
+		if err := models.DeleteDoor43MetadataBookStats(repo.ID, ref); err != nil {
+			return err
+		}
+		return clearValidation(repo, release, ref)
 	}
 
-	manifest, err := base.ReadYAMLFromBlob(blob)
+	result, err := format.Validate(metadata)
 	if err != nil {
 		return err
 	}
-
-	result, err := base.ValidateBlobByRC020Schema(manifest)
+	var findings []*models.Door43MetadataFinding
+	if linter, ok := format.(Linter); ok && result.Valid() {
+		if findings, err = linter.Lint(commit, metadata); err != nil {
+			return err
+		}
+	}
+	validation, err := newValidation(repo, release, ref, commit, format, result, findings)
 	if err != nil {
 		return err
 	}
+	if err := recordValidation(repo, release, validation); err != nil {
+		log.Error("recordValidation: %v", err)
+	}
+	if err := recordBookStats(repo, release, ref, commit, format, metadata); err != nil {
+		log.Error("recordBookStats: %v", err)
+	}
 
 	var releaseID int64
 	var stage models.Stage
@@ -231,11 +420,18 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 		} else {
 			stage = models.StageProd
 		}
+	} else if branch != "" {
+		stage = models.StageBranch
 	} else {
 		stage = models.StageLatest
 	}
 
-	dm, err := models.GetDoor43MetadataByRepoIDAndReleaseID(repo.ID, releaseID)
+	var dm *models.Door43Metadata
+	if branch != "" {
+		dm, err = models.GetDoor43MetadataByRepoIDAndBranch(repo.ID, branch)
+	} else {
+		dm, err = models.GetDoor43MetadataByRepoIDAndReleaseID(repo.ID, releaseID)
+	}
 	if err != nil && !models.IsErrDoor43MetadataNotExist(err) {
 		return err
 	}
@@ -255,32 +451,56 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 		if release != nil {
 			branchOrTag = release.Target
 		} else {
-			branchOrTag = repo.DefaultBranch
+			branchOrTag = ref
 		}
 	}
 
+	versionKey := getVersionKey(release)
+
 	if dm == nil ||
 		releaseDateUnix != dm.ReleaseDateUnix ||
 		dm.Stage != stage ||
 		dm.BranchOrTag != branchOrTag ||
-		!reflect.DeepEqual(dm.Metadata, manifest) {
+		dm.VersionKey != versionKey ||
+		dm.MetadataType != format.Type() ||
+		!reflect.DeepEqual(dm.Metadata, metadata) ||
+		!validation.IsPublishable() {
+		filename := models.MetadataTypeFilenames[format.Type()]
 		if !result.Valid() {
-			log.Warn("%s/%s: manifest.yaml is not valid. see errors:", repo.FullName(), branchOrTag)
+			log.Warn("%s/%s: %s is not valid. see errors:", repo.FullName(), branchOrTag, filename)
 			log.Warn("REPO ID: %d, RELEASE ID: %d", repo.ID, releaseID)
 			if release != nil {
 				log.Warn("RELEASE: %v", release.TagName)
 			} else {
-				log.Warn("BRANCH: %s", repo.DefaultBranch)
+				log.Warn("BRANCH: %s", ref)
 			}
 			for _, desc := range result.Errors() {
 				log.Warn("- %s", desc.Description())
 				log.Warn("- %s = %s", desc.Field(), desc.Value())
 			}
 			if dm != nil {
-				return models.DeleteDoor43Metadata(dm)
+				return deleteDoor43Metadatas(repo, []*models.Door43Metadata{dm}, func() error {
+					return models.DeleteDoor43Metadata(dm, models.DeleteReasonInvalidManifest)
+				})
+			}
+		} else if !validation.IsPublishable() {
+			log.Warn("%s/%s: the files of %s have errors:", repo.FullName(), branchOrTag, filename)
+			for _, finding := range validation.ErrorFindings() {
+				log.Warn("- %s: %s", finding.Path, finding.Message)
+			}
+			if dm != nil {
+				return deleteDoor43Metadatas(repo, []*models.Door43Metadata{dm}, func() error {
+					return models.DeleteDoor43Metadata(dm, models.DeleteReasonInvalidContainer)
+				})
 			}
 		} else {
-			log.Warn("%s/%s: manifest.yaml is valid.", repo.FullName(), branchOrTag)
+			log.Warn("%s/%s: %s is valid.", repo.FullName(), branchOrTag, filename)
+			if release == nil && branch == "" {
+				// The default branch may have been one of the other branches of the repo in the catalog
+				if err := deleteDoor43MetadataOfBranch(repo, ref); err != nil {
+					return err
+				}
+			}
 			if dm == nil {
 				dm = &models.Door43Metadata{
 					RepoID:          repo.ID,
@@ -288,18 +508,33 @@ func ProcessDoor43MetadataForRepoRelease(repo *models.Repository, release *model
 					ReleaseID:       releaseID,
 					Release:         release,
 					ReleaseDateUnix: releaseDateUnix,
-					MetadataVersion: "rc0.2",
-					Metadata:        manifest,
+					Metadata:        metadata,
 					Stage:           stage,
 					BranchOrTag:     branchOrTag,
+					VersionKey:      versionKey,
+				}
+				if err := format.Normalize(dm); err != nil {
+					return err
 				}
-				return models.InsertDoor43Metadata(dm)
+				if err := models.InsertDoor43Metadata(dm); err != nil {
+					return err
+				}
+				sendCatalogHook(repo, convert.ToDoor43MetadataV5(dm, models.AccessModeRead), structs.HookCatalogCreated)
+				return nil
+			}
+			dm.Metadata = metadata
+			if err := format.Normalize(dm); err != nil {
+				return err
 			}
-			dm.Metadata = manifest
 			dm.ReleaseDateUnix = releaseDateUnix
 			dm.Stage = stage
 			dm.BranchOrTag = branchOrTag
-			return models.UpdateDoor43MetadataCols(dm, "metadata", "release_date_unix", "stage", "branch_or_tag")
+			dm.VersionKey = versionKey
+			if err := models.UpdateDoor43MetadataCols(dm, "metadata", "release_date_unix", "stage", "branch_or_tag", "version_key"); err != nil {
+				return err
+			}
+			sendCatalogHook(repo, convert.ToDoor43MetadataV5(dm, models.AccessModeRead), structs.HookCatalogUpdated)
+			return nil
 		}
 	}
 
@@ -0,0 +1,91 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Format is a type of metadata file that can put a repo's release or default branch into the catalog
+type Format interface {
+	// Type returns the metadata type stored with the entries of this format, e.g. "rc"
+	Type() string
+	// ReadMetadata reads the metadata file of this format from the commit, returning nil if the commit doesn't have one
+	ReadMetadata(commit *git.Commit) (*map[string]interface{}, error)
+	// SchemaName returns the name of the schema in options/schema this format is validated by
+	SchemaName() string
+	// Validate validates the metadata against the schema of this format
+	Validate(metadata *map[string]interface{}) (*gojsonschema.Result, error)
+	// Normalize sets the metadata version and the catalog columns of the entry from its metadata
+	Normalize(dm *models.Door43Metadata) error
+}
+
+var formats []Format
+
+// RegisterFormat registers a metadata format. Formats are detected in the order they were registered.
+func RegisterFormat(format Format) {
+	formats = append(formats, format)
+}
+
+// GetFormat returns the registered format of the given metadata type, nil if there is none
+func GetFormat(metadataType string) Format {
+	for _, format := range formats {
+		if format.Type() == metadataType {
+			return format
+		}
+	}
+	return nil
+}
+
+// DetectFormat returns the first registered format that has a metadata file in the commit along with its metadata,
+// or nil if the commit has none
+func DetectFormat(commit *git.Commit) (Format, *map[string]interface{}, error) {
+	for _, format := range formats {
+		metadata, err := format.ReadMetadata(commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		if metadata != nil {
+			return format, metadata, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func init() {
+	RegisterFormat(&rcFormat{})
+	RegisterFormat(&sbFormat{})
+	RegisterFormat(&projectFormat{metadataType: models.MetadataTypeTC})
+	RegisterFormat(&projectFormat{metadataType: models.MetadataTypeTS})
+}
+
+// readBlob returns the blob at the given path of the commit, nil if it doesn't exist
+func readBlob(commit *git.Commit, path string) (*git.Blob, error) {
+	blob, err := commit.GetBlobByPath(path)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return blob, nil
+}
+
+// getString returns the string value at the given path of keys in a generic map, "" if not found
+func getString(m map[string]interface{}, keys ...string) string {
+	var value interface{} = m
+	for _, key := range keys {
+		mm, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = mm[key]
+	}
+	str, _ := value.(string)
+	return str
+}
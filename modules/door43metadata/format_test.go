@@ -0,0 +1,178 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sbMetadata = `{
+  "format": "scripture burrito",
+  "meta": {"version": "1.0.0", "category": "source", "defaultLocale": "en"},
+  "identification": {"name": {"en": "Swahili Unlocked Literal Bible", "sw": "Biblia"}},
+  "languages": [{"tag": "sw", "name": {"en": "Kiswahili"}, "scriptDirection": "ltr"}],
+  "type": {"flavorType": {"name": "scripture", "flavor": {"name": "textTranslation"}, "currentScope": {"RUT": [], "GEN": []}}},
+  "localizedNames": {"book-gen": {"short": {"en": "Genesis"}}, "book-rut": {"short": {"en": "Ruth"}}},
+  "ingredients": {
+    "ingredients/RUT.usfm": {"checksum": {"md5": "0123456789abcdef0123456789abcdef"}, "mimeType": "text/x-usfm", "size": 10, "scope": {"RUT": []}},
+    "ingredients/GEN.usfm": {"checksum": {"md5": "0123456789abcdef0123456789abcdef"}, "mimeType": "text/x-usfm", "size": 20, "scope": {"GEN": []}},
+    "ingredients/LICENSE.md": {"checksum": {"md5": "0123456789abcdef0123456789abcdef"}, "mimeType": "text/markdown", "size": 5}
+  }
+}`
+
+func TestSBFormat(t *testing.T) {
+	setting.StaticRootPath = "../.."
+
+	var metadata *map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(sbMetadata), &metadata))
+
+	format := GetFormat(models.MetadataTypeSB)
+	if !assert.NotNil(t, format) {
+		return
+	}
+	result, err := format.Validate(metadata)
+	assert.NoError(t, err)
+	assert.True(t, result.Valid(), "%v", result.Errors())
+
+	dm := &models.Door43Metadata{Metadata: metadata}
+	assert.NoError(t, format.Normalize(dm))
+	assert.Equal(t, models.MetadataTypeSB, dm.MetadataType)
+	assert.Equal(t, "sb1.0.0", dm.MetadataVersion)
+	assert.Equal(t, "Swahili Unlocked Literal Bible", dm.Title)
+	assert.Equal(t, "Bible", dm.Subject)
+	assert.Equal(t, "sw", dm.Language)
+	assert.Equal(t, "Kiswahili", dm.LanguageTitle)
+	assert.Equal(t, "ltr", dm.LanguageDir)
+	assert.Equal(t, []string{"gen", "rut"}, dm.Books)
+	if assert.Len(t, dm.Ingredients, 2) {
+		assert.Equal(t, "./ingredients/GEN.usfm", dm.Ingredients[0].(map[string]interface{})["path"])
+		assert.Equal(t, "Ruth", dm.Ingredients[1].(map[string]interface{})["title"])
+	}
+
+	delete(*metadata, "languages")
+	result, err = format.Validate(metadata)
+	assert.NoError(t, err)
+	assert.False(t, result.Valid())
+}
+
+func TestRCFormat_Normalize(t *testing.T) {
+	dm := &models.Door43Metadata{
+		Metadata: &map[string]interface{}{
+			"dublin_core": map[string]interface{}{
+				"conformsto": "rc0.2",
+				"title":      "unfoldingWord Literal Text",
+				"subject":    "Aligned Bible",
+				"language": map[string]interface{}{
+					"identifier": "en",
+					"title":      "English",
+					"direction":  "ltr",
+				},
+				"relation": []interface{}{"en/ult", "hbo/uhb?v=2.1"},
+			},
+			"checking": map[string]interface{}{
+				"checking_level": "3",
+			},
+			"projects": []interface{}{
+				map[string]interface{}{"identifier": "GEN", "path": "./01-GEN.usfm"},
+				map[string]interface{}{"identifier": "exo", "path": "./02-EXO.usfm"},
+			},
+		},
+	}
+	assert.NoError(t, GetFormat(models.MetadataTypeRC).Normalize(dm))
+	assert.Equal(t, models.MetadataTypeRC, dm.MetadataType)
+	assert.Equal(t, "rc0.2", dm.MetadataVersion)
+	assert.Equal(t, "unfoldingWord Literal Text", dm.Title)
+	assert.Equal(t, "Aligned Bible", dm.Subject)
+	assert.Equal(t, "en", dm.Language)
+	assert.Equal(t, "English", dm.LanguageTitle)
+	assert.Equal(t, 3, dm.CheckingLevel)
+	assert.Equal(t, []string{"gen", "exo"}, dm.Books)
+	assert.Len(t, dm.Ingredients, 2)
+	assert.Equal(t, []string{"en/ult", "hbo/uhb?v=2.1"}, dm.Relations)
+
+	// Missing fields must not panic
+	dm.Metadata = &map[string]interface{}{}
+	assert.NoError(t, GetFormat(models.MetadataTypeRC).Normalize(dm))
+	assert.Empty(t, dm.Title)
+	assert.Empty(t, dm.Books)
+	assert.Equal(t, []string{}, dm.Relations)
+}
+
+func TestProjectFormats(t *testing.T) {
+	setting.StaticRootPath = "../.."
+
+	tsManifest := &map[string]interface{}{
+		"package_version": float64(7),
+		"target_language": map[string]interface{}{"id": "es-419", "name": "Español Latin America", "direction": "ltr"},
+		"project":         map[string]interface{}{"id": "mrk", "name": "Mark"},
+		"type":            map[string]interface{}{"id": "text", "name": "Text"},
+		"resource":        map[string]interface{}{"id": "reg", "name": "Regular"},
+	}
+	tcManifest := &map[string]interface{}{
+		"tc_version":      float64(7),
+		"target_language": map[string]interface{}{"id": "en", "name": "English", "direction": "ltr"},
+		"project":         map[string]interface{}{"id": "tit", "name": "Titus"},
+	}
+
+	ts := GetFormat(models.MetadataTypeTS)
+	result, err := ts.Validate(tsManifest)
+	assert.NoError(t, err)
+	assert.True(t, result.Valid(), "%v", result.Errors())
+	dm := &models.Door43Metadata{Metadata: tsManifest}
+	assert.NoError(t, ts.Normalize(dm))
+	assert.Equal(t, models.MetadataTypeTS, dm.MetadataType)
+	assert.Equal(t, "ts", dm.MetadataVersion)
+	assert.Equal(t, "Mark", dm.Title)
+	assert.Equal(t, "Bible", dm.Subject)
+	assert.Equal(t, "es-419", dm.Language)
+	assert.Equal(t, []string{"mrk"}, dm.Books)
+	assert.Len(t, dm.Ingredients, 1)
+
+	tc := GetFormat(models.MetadataTypeTC)
+	result, err = tc.Validate(tcManifest)
+	assert.NoError(t, err)
+	assert.True(t, result.Valid(), "%v", result.Errors())
+	dm = &models.Door43Metadata{Metadata: tcManifest}
+	assert.NoError(t, tc.Normalize(dm))
+	assert.Equal(t, "tc", dm.MetadataVersion)
+	assert.Equal(t, "Aligned Bible", dm.Subject)
+	assert.Equal(t, []string{"tit"}, dm.Books)
+
+	// A translationStudio manifest is not a valid translationCore one
+	result, err = tc.Validate(tsManifest)
+	assert.NoError(t, err)
+	assert.False(t, result.Valid())
+}
+
+func TestValidateMetadataContent(t *testing.T) {
+	setting.StaticRootPath = "../.."
+
+	sb := GetFormat(models.MetadataTypeSB)
+	message, err := validateMetadataContent(sb, []byte(sbMetadata))
+	assert.NoError(t, err)
+	assert.Empty(t, message)
+
+	message, err = validateMetadataContent(sb, []byte(`{"format": "scripture burrito"}`))
+	assert.NoError(t, err)
+	assert.Contains(t, message, "meta is required")
+
+	message, err = validateMetadataContent(sb, []byte(`{"format": `))
+	assert.NoError(t, err)
+	assert.Contains(t, message, "unexpected end of JSON input")
+
+	message, err = validateMetadataContent(GetFormat(models.MetadataTypeRC), []byte("dublin_core: [\n"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, message)
+
+	message, err = validateMetadataContent(GetFormat(models.MetadataTypeRC), []byte(""))
+	assert.NoError(t, err)
+	assert.Contains(t, message, "empty")
+}
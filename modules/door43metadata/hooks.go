@@ -0,0 +1,85 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	webhook_services "code.gitea.io/gitea/services/webhook"
+)
+
+// sendCatalogHook sends the catalog webhook event of a created, updated or deleted catalog entry. Deleted entries
+// must be converted before they are deleted as their release may be gone by the time the hook is sent.
+func sendCatalogHook(repo *models.Repository, entry *api.Door43MetadataV5, action api.HookCatalogAction) {
+	if entry == nil {
+		return
+	}
+	if err := webhook_services.PrepareWebhooks(repo, models.HookEventCatalog, &api.CatalogPayload{
+		Action:     action,
+		Entry:      entry,
+		Repository: convert.ToRepo(repo, models.AccessModeRead),
+	}); err != nil {
+		log.Error("PrepareWebhooks: %v", err)
+	}
+}
+
+// deleteDoor43Metadatas deletes the door43 metadatas of a repo with the given delete function, sending the
+// catalog webhook event for each of them once it succeeds
+func deleteDoor43Metadatas(repo *models.Repository, dms []*models.Door43Metadata, deleteFunc func() error) error {
+	entries := make([]*api.Door43MetadataV5, 0, len(dms))
+	for _, dm := range dms {
+		dm.Repo = repo
+		entries = append(entries, convert.ToDoor43MetadataV5(dm, models.AccessModeRead))
+	}
+	if err := deleteFunc(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		sendCatalogHook(repo, entry, api.HookCatalogDeleted)
+	}
+	return nil
+}
+
+// DeleteDoor43MetadataOfRelease deletes the door43 metadata and book statistics of a release, if any, such as when it
+// is deleted
+func DeleteDoor43MetadataOfRelease(release *models.Release) error {
+	if err := models.DeleteDoor43MetadataBookStatsOfRelease(release); err != nil {
+		return err
+	}
+	dm, err := models.GetDoor43MetadataByRepoIDAndReleaseID(release.RepoID, release.ID)
+	if err != nil {
+		if models.IsErrDoor43MetadataNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if release.Repo == nil {
+		if release.Repo, err = models.GetRepositoryByID(release.RepoID); err != nil {
+			return err
+		}
+	}
+	dm.Release = release
+	return deleteDoor43Metadatas(release.Repo, []*models.Door43Metadata{dm}, func() error {
+		return models.DeleteDoor43MetadataByRelease(release)
+	})
+}
+
+// DeleteDoor43MetadatasOfRepo deletes all the door43 metadatas of a repo, such as when it is deleted or archived,
+// leaving a tombstone with the given reason for each
+func DeleteDoor43MetadatasOfRepo(repo *models.Repository, reason models.Door43MetadataDeleteReason) error {
+	dms, err := models.GetDoor43MetadatasByRepoID(repo.ID, models.FindDoor43MetadatasOptions{})
+	if err != nil {
+		return err
+	}
+	if len(dms) == 0 {
+		return nil
+	}
+	return deleteDoor43Metadatas(repo, dms, func() error {
+		_, err := models.DeleteAllDoor43MetadatasByRepoID(repo.ID, reason)
+		return err
+	})
+}
@@ -0,0 +1,491 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup/tsv"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/ghodss/yaml"
+)
+
+// Checks of the RC linter, whose findings keep a release out of the catalog if they are in RC_LINT_BLOCKING_CHECKS
+const (
+	LintCheckProjectPath    = "project_path"
+	LintCheckProjectFormat  = "project_format"
+	LintCheckUSFMID         = "usfm_id"
+	LintCheckUSFMChapters   = "usfm_chapters"
+	LintCheckTSVHeader      = "tsv_header"
+	LintCheckTSVColumns     = "tsv_columns"
+	LintCheckMarkdownLayout = "markdown_layout"
+	LintCheckMediaYAML      = "media_yaml"
+)
+
+// maxListedLines is how many of the lines with the same problem are listed in a finding
+const maxListedLines = 10
+
+var (
+	usfmIDRegexp      = regexp.MustCompile(`\\id[ \t]+([A-Za-z0-9]+)`)
+	usfmChapterRegexp = regexp.MustCompile(`\\c[ \t]+([^\s\\]+)`)
+)
+
+// Linter is implemented by the formats whose metadata lists files that can be checked against what it says they are
+type Linter interface {
+	// Lint checks the files of the commit the metadata lists, returning what it finds wrong with them
+	Lint(commit *git.Commit, metadata *map[string]interface{}) ([]*models.Door43MetadataFinding, error)
+}
+
+// container gives access to the files of a resource container
+type container interface {
+	// readFile returns the content of the file at the path, nil if there is no such file
+	readFile(p string) ([]byte, error)
+	// listDir returns the names of the entries of the directory at the path, with a trailing "/" for directories,
+	// or nil if there is no such directory
+	listDir(p string) ([]string, error)
+}
+
+// commitContainer is the container of the files of a commit
+type commitContainer struct {
+	commit *git.Commit
+}
+
+func (c *commitContainer) readFile(p string) ([]byte, error) {
+	blob, err := readBlob(c.commit, p)
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer dataRc.Close()
+	return ioutil.ReadAll(dataRc)
+}
+
+func (c *commitContainer) listDir(p string) ([]string, error) {
+	tree := &c.commit.Tree
+	if p != "" {
+		entry, err := c.commit.GetTreeEntryByPath(p)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !entry.IsDir() {
+			return nil, nil
+		}
+		if tree, err = c.commit.SubTree(p); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Lint checks that every project of the manifest exists and is what its format says it is, and that the media.yaml
+// file, if any, is consistent with the manifest
+func (f *rcFormat) Lint(commit *git.Commit, metadata *map[string]interface{}) ([]*models.Door43MetadataFinding, error) {
+	manifest := map[string]interface{}{}
+	if metadata != nil {
+		manifest = *metadata
+	}
+	return lintContainer(&commitContainer{commit: commit}, manifest)
+}
+
+// linter collects the findings of linting a container
+type linter struct {
+	c        container
+	manifest map[string]interface{}
+	findings []*models.Door43MetadataFinding
+}
+
+// lintContainer lints the projects and media.yaml file of the container of the manifest
+func lintContainer(c container, manifest map[string]interface{}) ([]*models.Door43MetadataFinding, error) {
+	l := &linter{c: c, manifest: manifest, findings: []*models.Door43MetadataFinding{}}
+	projects, _ := manifest["projects"].([]interface{})
+	for _, project := range projects {
+		if p, ok := project.(map[string]interface{}); ok {
+			if err := l.lintProject(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := l.lintMedia(); err != nil {
+		return nil, err
+	}
+	return l.findings, nil
+}
+
+// addFinding adds a finding of the check, an error if the check is one of RC_LINT_BLOCKING_CHECKS
+func (l *linter) addFinding(check, p, format string, args ...interface{}) {
+	severity := models.FindingSeverityWarning
+	for _, blocking := range setting.DCS.RCLintBlockingChecks {
+		if strings.TrimSpace(blocking) == check {
+			severity = models.FindingSeverityError
+			break
+		}
+	}
+	l.findings = append(l.findings, &models.Door43MetadataFinding{
+		Check:    check,
+		Severity: severity,
+		Path:     p,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// getProjectFormat returns the format of a project of the manifest, "usfm", "tsv" or "markdown", from its format or
+// the container's, or from the extension of its path if neither is known, "" if it is none of them
+func getProjectFormat(manifest, project map[string]interface{}, projectPath string) string {
+	for _, format := range []string{getString(project, "format"), getString(manifest, "dublin_core", "format")} {
+		format = strings.ToLower(format)
+		for _, known := range []string{"usfm", "tsv", "markdown"} {
+			if strings.Contains(format, known) {
+				return known
+			}
+		}
+	}
+	switch strings.ToLower(path.Ext(projectPath)) {
+	case ".usfm":
+		return "usfm"
+	case ".tsv":
+		return "tsv"
+	case ".md":
+		return "markdown"
+	}
+	return ""
+}
+
+// cleanProjectPath returns the path of a project relative to the root of the container, "" for the root, and false
+// if the path is empty or outside of the container
+func cleanProjectPath(rawPath string) (string, bool) {
+	projectPath := path.Clean(strings.TrimPrefix(rawPath, "/"))
+	if projectPath == "." {
+		projectPath = ""
+	}
+	if rawPath == "" || projectPath == ".." || strings.HasPrefix(projectPath, "../") {
+		return "", false
+	}
+	return projectPath, true
+}
+
+// lintProject checks that the path of the project exists and that its content matches its format
+func (l *linter) lintProject(project map[string]interface{}) error {
+	identifier := getString(project, "identifier")
+	rawPath := getString(project, "path")
+	projectPath, ok := cleanProjectPath(rawPath)
+	if !ok {
+		l.addFinding(LintCheckProjectPath, rawPath, "project %q has no valid path in the container", identifier)
+		return nil
+	}
+
+	content, err := l.c.readFile(projectPath)
+	if err != nil {
+		return err
+	}
+	var entries []string
+	if content == nil {
+		if entries, err = l.c.listDir(projectPath); err != nil {
+			return err
+		}
+		if entries == nil {
+			l.addFinding(LintCheckProjectPath, projectPath, "project %q path %s does not exist", identifier, rawPath)
+			return nil
+		}
+	}
+
+	switch getProjectFormat(l.manifest, project, projectPath) {
+	case "usfm":
+		if content == nil {
+			l.addFinding(LintCheckProjectFormat, projectPath, "project %q is USFM but its path is a directory", identifier)
+			return nil
+		}
+		l.lintUSFM(projectPath, identifier, string(content))
+	case "tsv":
+		if content == nil {
+			l.addFinding(LintCheckProjectFormat, projectPath, "project %q is TSV but its path is a directory", identifier)
+			return nil
+		}
+		l.lintTSV(projectPath, identifier, string(content))
+	case "markdown":
+		if content != nil {
+			if !strings.EqualFold(path.Ext(projectPath), ".md") {
+				l.addFinding(LintCheckProjectFormat, projectPath, "project %q is Markdown but its path is not a .md file", identifier)
+			}
+			return nil
+		}
+		return l.lintMarkdown(projectPath, entries)
+	}
+	return nil
+}
+
+// lintUSFM checks that the USFM file is of the project's book and has all of its chapters
+func (l *linter) lintUSFM(p, identifier, content string) {
+	match := usfmIDRegexp.FindStringSubmatch(content)
+	if match == nil {
+		l.addFinding(LintCheckProjectFormat, p, "project %q is USFM but has no \\id marker", identifier)
+		return
+	}
+	bookID := strings.ToLower(match[1])
+	if dcs.IsValidBook(identifier) && bookID != strings.ToLower(identifier) {
+		l.addFinding(LintCheckUSFMID, p, "\\id %s does not match project %q", match[1], identifier)
+	}
+
+	chapters := make(map[int]bool)
+	var duplicates, invalid []string
+	highest := 0
+	for _, match := range usfmChapterRegexp.FindAllStringSubmatch(content, -1) {
+		chapter, err := strconv.Atoi(match[1])
+		if err != nil || chapter <= 0 {
+			invalid = append(invalid, match[1])
+			continue
+		}
+		if chapters[chapter] {
+			duplicates = append(duplicates, match[1])
+		}
+		chapters[chapter] = true
+		if chapter > highest {
+			highest = chapter
+		}
+	}
+	if len(invalid) > 0 {
+		l.addFinding(LintCheckUSFMChapters, p, "invalid chapter numbers: %s", strings.Join(invalid, ", "))
+	}
+	if len(duplicates) > 0 {
+		l.addFinding(LintCheckUSFMChapters, p, "duplicate chapters: %s", strings.Join(duplicates, ", "))
+	}
+
+	expected := dcs.BookMinChapterCount(bookID)
+	if expected == 0 {
+		if len(chapters) == 0 {
+			l.addFinding(LintCheckUSFMChapters, p, "there are no chapters")
+		}
+		return
+	}
+	if highest > expected {
+		expected = highest
+	}
+	var missing []int
+	for chapter := 1; chapter <= expected; chapter++ {
+		if !chapters[chapter] {
+			missing = append(missing, chapter)
+		}
+	}
+	if len(missing) > 0 {
+		l.addFinding(LintCheckUSFMChapters, p, "missing chapters: %s", formatRanges(missing))
+	}
+}
+
+// formatRanges formats sorted numbers as a list of ranges, such as "1, 3-5"
+func formatRanges(numbers []int) string {
+	ranges := make([]string, 0, len(numbers))
+	for i := 0; i < len(numbers); {
+		j := i
+		for j+1 < len(numbers) && numbers[j+1] == numbers[j]+1 {
+			j++
+		}
+		if j > i {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", numbers[i], numbers[j]))
+		} else {
+			ranges = append(ranges, strconv.Itoa(numbers[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// formatLines formats the line numbers, listing up to maxListedLines of them
+func formatLines(lines []int) string {
+	listed := make([]string, 0, maxListedLines)
+	for i, line := range lines {
+		if i == maxListedLines {
+			return strings.Join(listed, ", ") + fmt.Sprintf(" and %d more", len(lines)-maxListedLines)
+		}
+		listed = append(listed, strconv.Itoa(line))
+	}
+	return strings.Join(listed, ", ")
+}
+
+// lintTSV checks that the TSV file has the header of a translationHelps TSV and that every row has its columns
+func (l *linter) lintTSV(p, identifier, content string) {
+	lines := strings.Split(strings.TrimPrefix(content, "\ufeff"), "\n")
+	header := strings.Split(strings.TrimRight(lines[0], "\r"), "\t")
+	if len(header) < 2 {
+		l.addFinding(LintCheckProjectFormat, p, "project %q is TSV but its first line is not a tab-separated header", identifier)
+		return
+	}
+	if !tsv.IsHelpsHeader(header) {
+		l.addFinding(LintCheckTSVHeader, p, "the header is not the one of a translationHelps TSV: %s", strings.Join(header, ", "))
+	}
+
+	var wrong []int
+	for i, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.Count(line, "\t")+1 != len(header) {
+			wrong = append(wrong, i+2)
+		}
+	}
+	if len(wrong) > 0 {
+		l.addFinding(LintCheckTSVColumns, p, "%d rows do not have the %d columns of the header, lines %s",
+			len(wrong), len(header), formatLines(wrong))
+	}
+}
+
+// lintMarkdown checks the layout of the directory of a Markdown project: the translationAcademy articles each in
+// a directory with a 01.md file, the translationWords articles in directories of categories, and else .md files in
+// the directory or its subdirectories
+func (l *linter) lintMarkdown(p string, entries []string) error {
+	var dirs []string
+	hasMarkdown := false
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			dirs = append(dirs, strings.TrimSuffix(entry, "/"))
+		} else if strings.EqualFold(path.Ext(entry), ".md") {
+			hasMarkdown = true
+		}
+	}
+	sort.Strings(dirs)
+
+	switch strings.ToLower(getString(l.manifest, "dublin_core", "identifier")) {
+	case "ta":
+		if !containsString(entries, "toc.yaml") {
+			l.addFinding(LintCheckMarkdownLayout, p, "translationAcademy manual has no toc.yaml")
+		}
+		var missing []string
+		for _, dir := range dirs {
+			articleEntries, err := l.c.listDir(path.Join(p, dir))
+			if err != nil {
+				return err
+			}
+			if !containsString(articleEntries, "01.md") {
+				missing = append(missing, dir)
+			}
+		}
+		if len(missing) > 0 {
+			l.addFinding(LintCheckMarkdownLayout, p, "articles without a 01.md file: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	case "tw":
+		if len(dirs) == 0 {
+			l.addFinding(LintCheckMarkdownLayout, p, "translationWords has no directories of categories of articles")
+			return nil
+		}
+		for _, dir := range dirs {
+			categoryEntries, err := l.c.listDir(path.Join(p, dir))
+			if err != nil {
+				return err
+			}
+			if !hasMarkdownFile(categoryEntries) {
+				l.addFinding(LintCheckMarkdownLayout, path.Join(p, dir), "translationWords category has no .md articles")
+			}
+		}
+		return nil
+	}
+
+	if hasMarkdown {
+		return nil
+	}
+	for _, dir := range dirs {
+		dirEntries, err := l.c.listDir(path.Join(p, dir))
+		if err != nil {
+			return err
+		}
+		if hasMarkdownFile(dirEntries) {
+			return nil
+		}
+	}
+	l.addFinding(LintCheckMarkdownLayout, p, "there are no .md files in the project directory or its subdirectories")
+	return nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMarkdownFile(entries []string) bool {
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, "/") && strings.EqualFold(path.Ext(entry), ".md") {
+			return true
+		}
+	}
+	return false
+}
+
+// lintMedia checks that the projects of the media.yaml file, if any, are projects of the manifest and that each of
+// their media has an identifier and a URL
+func (l *linter) lintMedia() error {
+	content, err := l.c.readFile("media.yaml")
+	if err != nil || content == nil {
+		return err
+	}
+	var media map[string]interface{}
+	if err := yaml.Unmarshal(content, &media); err != nil {
+		l.addFinding(LintCheckMediaYAML, "media.yaml", "media.yaml is not valid YAML: %s", strings.ReplaceAll(err.Error(), " converting YAML to JSON", ""))
+		return nil
+	}
+
+	identifiers := make(map[string]bool)
+	projects, _ := l.manifest["projects"].([]interface{})
+	for _, project := range projects {
+		if p, ok := project.(map[string]interface{}); ok {
+			identifiers[strings.ToLower(getString(p, "identifier"))] = true
+		}
+	}
+
+	mediaProjects, ok := media["projects"].([]interface{})
+	if !ok {
+		l.addFinding(LintCheckMediaYAML, "media.yaml", "media.yaml has no list of projects")
+		return nil
+	}
+	for i, mediaProject := range mediaProjects {
+		mp, ok := mediaProject.(map[string]interface{})
+		if !ok {
+			l.addFinding(LintCheckMediaYAML, "media.yaml", "project %d is not a map", i+1)
+			continue
+		}
+		identifier := getString(mp, "identifier")
+		if !identifiers[strings.ToLower(identifier)] {
+			l.addFinding(LintCheckMediaYAML, "media.yaml", "project %q is not a project of the manifest", identifier)
+		}
+		items, _ := mp["media"].([]interface{})
+		for j, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok || getString(m, "identifier") == "" || (getString(m, "url") == "" && getString(m, "chapter_url") == "") {
+				l.addFinding(LintCheckMediaYAML, "media.yaml", "media %d of project %q needs an identifier and a url or chapter_url", j+1, identifier)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapContainer is a container of the files of a map of paths to contents
+type mapContainer map[string]string
+
+func (c mapContainer) readFile(p string) ([]byte, error) {
+	if content, ok := c[p]; ok {
+		return []byte(content), nil
+	}
+	return nil, nil
+}
+
+func (c mapContainer) listDir(p string) ([]string, error) {
+	prefix := ""
+	if p != "" {
+		prefix = p + "/"
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for filePath := range c {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(filePath, prefix)
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func lintManifest(t *testing.T, c mapContainer, manifest string) []*models.Door43MetadataFinding {
+	var m map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(manifest), &m))
+	findings, err := lintContainer(c, m)
+	assert.NoError(t, err)
+	return findings
+}
+
+func TestLintContainer_USFM(t *testing.T) {
+	setting.DCS.RCLintBlockingChecks = []string{LintCheckProjectPath, LintCheckUSFMChapters}
+
+	manifest := `
+dublin_core: {identifier: ult, format: text/usfm3}
+projects:
+  - {identifier: rut, path: ./08-RUT.usfm}
+  - {identifier: oba, path: ./32-OBA.usfm}
+  - {identifier: jon, path: ./33-JON.usfm}
+  - {identifier: mal, path: ./40-MAL.usfm}
+`
+	findings := lintManifest(t, mapContainer{
+		"08-RUT.usfm": "\\id RUT\n\\c 1\n\\v 1 a\n\\c 2\n\\c 4\n\\c 4\n",
+		"32-OBA.usfm": "\\id JON\n\\c 1\n",
+		"40-MAL.usfm": "\\id MAL\n\\c 1\n\\c 2\n\\c 3\n",
+	}, manifest)
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckUSFMChapters, Severity: models.FindingSeverityError, Path: "08-RUT.usfm", Message: "duplicate chapters: 4"},
+		{Check: LintCheckUSFMChapters, Severity: models.FindingSeverityError, Path: "08-RUT.usfm", Message: "missing chapters: 3"},
+		{Check: LintCheckUSFMID, Severity: models.FindingSeverityWarning, Path: "32-OBA.usfm", Message: `\id JON does not match project "oba"`},
+		{Check: LintCheckUSFMChapters, Severity: models.FindingSeverityError, Path: "32-OBA.usfm", Message: "missing chapters: 2-4"},
+		{Check: LintCheckProjectPath, Severity: models.FindingSeverityError, Path: "33-JON.usfm", Message: `project "jon" path ./33-JON.usfm does not exist`},
+	}, findings)
+}
+
+func TestLintContainer_TSV(t *testing.T) {
+	setting.DCS.RCLintBlockingChecks = []string{LintCheckTSVColumns}
+
+	manifest := `
+dublin_core: {identifier: tn, format: text/tsv}
+projects:
+  - {identifier: tit, path: ./tn_TIT.tsv}
+  - {identifier: phm, path: ./tn_PHM.tsv}
+  - {identifier: jud, path: ./JUD}
+`
+	findings := lintManifest(t, mapContainer{
+		"tn_TIT.tsv": "Reference\tID\tTags\tSupportReference\tQuote\tOccurrence\tNote\r\n1:1\tabcd\t\t\t\t\tNote\r\n1:2\tefgh\t\tNote\r\n\r\n",
+		"tn_PHM.tsv": "Ref\tNote\n1:1\tNote\n",
+		"JUD/01.tsv": "Reference\tNote\n",
+	}, manifest)
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckTSVColumns, Severity: models.FindingSeverityError, Path: "tn_TIT.tsv", Message: "1 rows do not have the 7 columns of the header, lines 3"},
+		{Check: LintCheckTSVHeader, Severity: models.FindingSeverityWarning, Path: "tn_PHM.tsv", Message: "the header is not the one of a translationHelps TSV: Ref, Note"},
+		{Check: LintCheckProjectFormat, Severity: models.FindingSeverityWarning, Path: "JUD", Message: `project "jud" is TSV but its path is a directory`},
+	}, findings)
+}
+
+func TestLintContainer_Markdown(t *testing.T) {
+	setting.DCS.RCLintBlockingChecks = nil
+
+	findings := lintManifest(t, mapContainer{
+		"translate/toc.yaml":             "",
+		"translate/figs-metaphor/01.md":  "# Metaphor",
+		"translate/figs-simile/title.md": "Simile",
+	}, `
+dublin_core: {identifier: ta, format: text/markdown}
+projects: [{identifier: translate, path: ./translate}]
+`)
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckMarkdownLayout, Severity: models.FindingSeverityWarning, Path: "translate", Message: "articles without a 01.md file: figs-simile"},
+	}, findings)
+
+	findings = lintManifest(t, mapContainer{
+		"bible/kt/god.md":        "# God",
+		"bible/names/README.txt": "",
+	}, `
+dublin_core: {identifier: tw, format: text/markdown}
+projects: [{identifier: bible, path: ./bible}]
+`)
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckMarkdownLayout, Severity: models.FindingSeverityWarning, Path: "bible/names", Message: "translationWords category has no .md articles"},
+	}, findings)
+
+	findings = lintManifest(t, mapContainer{
+		"content/01.md": "# Creation",
+		"LICENSE.txt":   "",
+	}, `
+dublin_core: {identifier: obs, format: text/markdown}
+projects: [{identifier: obs, path: ./content}, {identifier: license, path: ./LICENSE.txt}]
+`)
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckProjectFormat, Severity: models.FindingSeverityWarning, Path: "LICENSE.txt", Message: `project "license" is Markdown but its path is not a .md file`},
+	}, findings)
+}
+
+func TestLintContainer_Media(t *testing.T) {
+	setting.DCS.RCLintBlockingChecks = nil
+	manifest := `
+dublin_core: {identifier: obs, format: text/markdown}
+projects: [{identifier: obs, path: ./content}]
+`
+	c := mapContainer{
+		"content/01.md": "# Creation",
+		"media.yaml": `
+projects:
+  - identifier: obs
+    media: [{identifier: mp3, url: "https://example.org/obs.mp3"}, {identifier: pdf}]
+  - identifier: gen
+    media: []
+`,
+	}
+	assert.Equal(t, []*models.Door43MetadataFinding{
+		{Check: LintCheckMediaYAML, Severity: models.FindingSeverityWarning, Path: "media.yaml", Message: `media 2 of project "obs" needs an identifier and a url or chapter_url`},
+		{Check: LintCheckMediaYAML, Severity: models.FindingSeverityWarning, Path: "media.yaml", Message: `project "gen" is not a project of the manifest`},
+	}, lintManifest(t, c, manifest))
+
+	c["media.yaml"] = "projects: ["
+	findings := lintManifest(t, c, manifest)
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, LintCheckMediaYAML, findings[0].Check)
+	}
+}
+
+func TestFormatRanges(t *testing.T) {
+	assert.Equal(t, "1, 3-5, 7-8", formatRanges([]int{1, 3, 4, 5, 7, 8}))
+	assert.Equal(t, "1, 2, 3, 4, 5, 6, 7, 8, 9, 10 and 2 more", formatLines([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}))
+}
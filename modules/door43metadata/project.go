@@ -0,0 +1,112 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// tsTypeSubjects maps the translationStudio project types to the subjects used by Resource Containers
+var tsTypeSubjects = map[string]string{
+	"text": "Bible",
+	"tn":   "Translation Notes",
+	"tq":   "Translation Questions",
+	"tw":   "Translation Words",
+	"ta":   "Translation Academy",
+}
+
+// projectFormat is the format of the manifest.json of a translation project pushed by translationStudio (ts)
+// or translationCore (tc). Both use the same filename, but only translationCore manifests have a tc_version
+type projectFormat struct {
+	metadataType string
+}
+
+func (f *projectFormat) Type() string {
+	return f.metadataType
+}
+
+func (f *projectFormat) ReadMetadata(commit *git.Commit) (*map[string]interface{}, error) {
+	filename := models.MetadataTypeFilenames[f.metadataType]
+	blob, err := readBlob(commit, filename)
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	metadata, err := base.ReadJSONFromBlob(blob)
+	if err != nil {
+		// manifest.json is not ours to require, so an unparsable one just means this isn't a project
+		log.Warn("%s is not valid JSON, skipping: %v", filename, err)
+		return nil, nil
+	}
+	if metadata == nil {
+		return nil, nil
+	}
+	_, isTC := (*metadata)["tc_version"]
+	if isTC != (f.metadataType == models.MetadataTypeTC) {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+func (f *projectFormat) SchemaName() string {
+	return f.metadataType + ".schema.json"
+}
+
+func (f *projectFormat) Validate(metadata *map[string]interface{}) (*gojsonschema.Result, error) {
+	return base.ValidateMetadataBySchema(f.SchemaName(), metadata)
+}
+
+func (f *projectFormat) Normalize(dm *models.Door43Metadata) error {
+	manifest := map[string]interface{}{}
+	if dm.Metadata != nil {
+		manifest = *dm.Metadata
+	}
+
+	dm.MetadataType = f.metadataType
+	dm.MetadataVersion = f.metadataType
+	dm.CheckingLevel = 0
+
+	project := getString(manifest, "project", "id")
+	dm.Title = getString(manifest, "project", "name")
+	if dm.Title == "" {
+		dm.Title = project
+	}
+	dm.Title = base.TruncateString(dm.Title, 255)
+
+	if f.metadataType == models.MetadataTypeTC {
+		dm.Subject = "Aligned Bible"
+	} else if subject, ok := tsTypeSubjects[getString(manifest, "type", "id")]; ok {
+		dm.Subject = subject
+	} else {
+		dm.Subject = "Bible"
+	}
+
+	dm.Language = getString(manifest, "target_language", "id")
+	dm.LanguageTitle = getString(manifest, "target_language", "name")
+	dm.LanguageDir = getString(manifest, "target_language", "direction")
+
+	dm.Books = nil
+	dm.Ingredients = nil
+	dm.Relations = nil
+	if book := strings.ToLower(project); dcs.IsValidBook(book) {
+		dm.Books = []string{book}
+		dm.Ingredients = []interface{}{
+			map[string]interface{}{
+				"identifier": book,
+				"title":      dm.Title,
+				"path":       "./",
+				"sort":       dcs.BookNumber(book),
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/ghodss/yaml"
+)
+
+// pushValidatedTypes are the metadata types whose files are validated on push if the repo or its org enables it
+var pushValidatedTypes = []string{models.MetadataTypeRC, models.MetadataTypeSB}
+
+// ValidatePushedMetadata validates the manifest.yaml and metadata.json files of a pushed commit, if it has them,
+// returning the errors to show the pusher or "" if they are valid. The env must give access to the quarantined
+// objects of the push as the commit isn't in the repo yet.
+func ValidatePushedMetadata(repoPath, commitID string, env []string) (string, error) {
+	var messages []string
+	for _, metadataType := range pushValidatedTypes {
+		format := GetFormat(metadataType)
+		filename := models.MetadataTypeFilenames[metadataType]
+
+		// ls-tree gives no output rather than failing if the file doesn't exist
+		entry, err := git.NewCommand("ls-tree", commitID, "--", filename).RunInDirWithEnv(repoPath, env)
+		if err != nil {
+			return "", fmt.Errorf("unable to list %s of %s: %v", filename, commitID, err)
+		}
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		content, err := git.NewCommand("cat-file", "blob", commitID+":"+filename).RunInDirWithEnv(repoPath, env)
+		if err != nil {
+			return "", fmt.Errorf("unable to read %s of %s: %v", filename, commitID, err)
+		}
+
+		message, err := validateMetadataContent(format, []byte(content))
+		if err != nil {
+			return "", err
+		}
+		if message != "" {
+			messages = append(messages, fmt.Sprintf("%s is invalid:\n%s", filename, message))
+		}
+	}
+	return strings.Join(messages, "\n"), nil
+}
+
+// validateMetadataContent parses the content of a metadata file of the given format and validates it,
+// returning the parse or schema errors or "" if it is valid
+func validateMetadataContent(format Format, content []byte) (string, error) {
+	var metadata *map[string]interface{}
+	var err error
+	if strings.HasSuffix(models.MetadataTypeFilenames[format.Type()], ".json") {
+		err = json.Unmarshal(content, &metadata)
+	} else {
+		err = yaml.Unmarshal(content, &metadata)
+	}
+	if err != nil {
+		return " * " + strings.ReplaceAll(err.Error(), " converting YAML to JSON", ""), nil
+	}
+	if metadata == nil {
+		return " * the file is empty", nil
+	}
+
+	result, err := format.Validate(metadata)
+	if err != nil {
+		return "", err
+	}
+	return base.StringifyValidationErrors(result), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
+)
+
+// metadataRequest is a repo's release, or its default branch if ReleaseID is 0, or another of its branches if Branch is
+// given, to process the metadata of. It is the unique key of the queue so the same repo and release is only queued once,
+// which is why it is queued as a value rather than a pointer.
+type metadataRequest struct {
+	RepoID    int64
+	ReleaseID int64
+	Branch    string
+}
+
+// metadataQueue represents a queue to process the metadata of repos' releases and default branches
+var metadataQueue queue.UniqueQueue
+
+// handle processes the metadata of the queued repos' releases and default branches
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		req, ok := datum.(metadataRequest)
+		if !ok {
+			log.Error("Unable to process provided datum: %v - not possible to cast to metadataRequest", datum)
+			continue
+		}
+		if err := processRequest(req); err != nil {
+			log.Error("door43 metadata queue: processing repo %d, release %d, branch %q failed: %v", req.RepoID, req.ReleaseID, req.Branch, err)
+		}
+	}
+}
+
+// processRequest processes the metadata of a queued repo's release or default branch, skipping it if the repo or
+// release has been deleted since it was queued
+func processRequest(req metadataRequest) error {
+	repo, err := models.GetRepositoryByID(req.RepoID)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if req.Branch != "" {
+		pid := process.GetManager().Add(fmt.Sprintf("ProcessDoor43Metadata [repo: %s, ref: %s]", repo.FullName(), req.Branch), nil)
+		defer process.GetManager().Remove(pid)
+		return ProcessDoor43MetadataForRepoBranch(repo, req.Branch)
+	}
+
+	var release *models.Release
+	ref := repo.DefaultBranch
+	if req.ReleaseID > 0 {
+		release, err = models.GetReleaseByID(req.ReleaseID)
+		if err != nil {
+			if models.IsErrReleaseNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if release.IsTag {
+			return nil
+		}
+		ref = release.TagName
+	}
+
+	pid := process.GetManager().Add(fmt.Sprintf("ProcessDoor43Metadata [repo: %s, ref: %s]", repo.FullName(), ref), nil)
+	defer process.GetManager().Remove(pid)
+	return ProcessDoor43MetadataForRepoRelease(repo, release)
+}
+
+// InitQueue creates the queue the metadata of repos is processed on and starts its workers
+func InitQueue() error {
+	q, err := newQueue(handle)
+	if err != nil {
+		return err
+	}
+	metadataQueue = q
+
+	go graceful.GetManager().RunWithShutdownFns(metadataQueue.Run)
+
+	return nil
+}
+
+// newQueue creates the door43_metadata queue with the given handler without starting it
+func newQueue(handler queue.HandlerFunc) (queue.UniqueQueue, error) {
+	q := queue.CreateUniqueQueue("door43_metadata", handler, metadataRequest{})
+	if q == nil {
+		return nil, fmt.Errorf("unable to create door43_metadata queue")
+	}
+	return q, nil
+}
+
+// pushRequest queues a request, doing nothing if it is already queued. If the queue hasn't been initialized, such as
+// in commands and tests that don't start the queues, the request is processed right away instead.
+func pushRequest(req metadataRequest) error {
+	if metadataQueue == nil {
+		return processRequest(req)
+	}
+	if err := metadataQueue.Push(req); err != nil {
+		if err != queue.ErrAlreadyInQueue {
+			return err
+		}
+		log.Debug("Door43 metadata of repo %d, release %d, branch %q already queued", req.RepoID, req.ReleaseID, req.Branch)
+	}
+	return nil
+}
+
+// QueueDoor43MetadataForRepoRelease queues the metadata of a repo's release, or its default branch if release is nil,
+// to be processed, doing nothing if it is already queued
+func QueueDoor43MetadataForRepoRelease(repo *models.Repository, release *models.Release) error {
+	if repo == nil {
+		return fmt.Errorf("no repository provided")
+	}
+	req := metadataRequest{RepoID: repo.ID}
+	if release != nil {
+		if release.IsTag {
+			return fmt.Errorf("release can only be a release, not a tag")
+		}
+		req.ReleaseID = release.ID
+	}
+	return pushRequest(req)
+}
+
+// QueueDoor43MetadataForRepoBranch queues the metadata of a branch of a repo other than its default branch to be
+// processed, doing nothing if it is already queued
+func QueueDoor43MetadataForRepoBranch(repo *models.Repository, branch string) error {
+	if repo == nil {
+		return fmt.Errorf("no repository provided")
+	}
+	return pushRequest(metadataRequest{RepoID: repo.ID, Branch: branch})
+}
+
+// QueueDoor43MetadataForRepo queues the metadata of all the releases, the default branch and the other catalog
+// branches of a repo to be processed, deleting its metadata right away instead if the repo is archived
+func QueueDoor43MetadataForRepo(repo *models.Repository) error {
+	if repo == nil {
+		return fmt.Errorf("no repository provided")
+	}
+
+	if repo.IsArchived {
+		return DeleteDoor43MetadatasOfRepo(repo, models.DeleteReasonArchived)
+	}
+
+	relIDs, err := models.GetRepoReleaseIDsForMetadata(repo.ID)
+	if err != nil {
+		return err
+	}
+	for _, releaseID := range relIDs {
+		if err := pushRequest(metadataRequest{RepoID: repo.ID, ReleaseID: releaseID}); err != nil {
+			return err
+		}
+	}
+
+	branches, err := getCatalogBranches(repo)
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		if err := pushRequest(metadataRequest{RepoID: repo.ID, Branch: branch}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlushQueue waits for the metadata that has been queued to be processed, for callers that need the result, giving up
+// after the timeout if it isn't 0. There is nothing to wait for if the queue hasn't been initialized.
+func FlushQueue(timeout time.Duration) error {
+	if metadataQueue == nil {
+		return nil
+	}
+	return metadataQueue.Flush(timeout)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+// newTestQueue makes a door43_metadata queue whose workers aren't started, so what is pushed stays queued until it is
+// flushed, recording what it handles
+func newTestQueue(t *testing.T) (*[]metadataRequest, func()) {
+	setting.Cfg = ini.Empty()
+	section := setting.Cfg.Section("queue.door43_metadata")
+	_, err := section.NewKey("TYPE", "channel")
+	assert.NoError(t, err)
+	// Without a block timeout no temporary workers are added to handle what is pushed
+	_, err = section.NewKey("BLOCK_TIMEOUT", "0")
+	assert.NoError(t, err)
+	setting.NewQueueService()
+
+	var lock sync.Mutex
+	handled := []metadataRequest{}
+	q, err := newQueue(func(data ...queue.Data) {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, datum := range data {
+			handled = append(handled, datum.(metadataRequest))
+		}
+	})
+	assert.NoError(t, err)
+	metadataQueue = q
+	return &handled, func() {
+		metadataQueue = nil
+	}
+}
+
+func TestPushRequest_Dedupe(t *testing.T) {
+	handled, reset := newTestQueue(t)
+	defer reset()
+
+	release := metadataRequest{RepoID: 1, ReleaseID: 2}
+	branch := metadataRequest{RepoID: 1, Branch: "review"}
+	assert.NoError(t, pushRequest(release))
+	assert.NoError(t, pushRequest(metadataRequest{RepoID: 1, ReleaseID: 2}))
+	assert.NoError(t, pushRequest(branch))
+	assert.NoError(t, pushRequest(metadataRequest{RepoID: 1, Branch: "review"}))
+
+	has, err := metadataQueue.Has(release)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = metadataQueue.Has(metadataRequest{RepoID: 1})
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	assert.NoError(t, FlushQueue(5*time.Second))
+	assert.Equal(t, []metadataRequest{release, branch}, *handled)
+}
+
+func TestFlushQueue(t *testing.T) {
+	handled, reset := newTestQueue(t)
+	defer reset()
+
+	// Nothing queued
+	assert.NoError(t, FlushQueue(time.Second))
+	assert.Empty(t, *handled)
+
+	req := metadataRequest{RepoID: 3}
+	assert.NoError(t, pushRequest(req))
+	assert.NoError(t, FlushQueue(5*time.Second))
+	assert.Equal(t, []metadataRequest{req}, *handled)
+
+	// Once handled it can be queued again
+	has, err := metadataQueue.Has(req)
+	assert.NoError(t, err)
+	assert.False(t, has)
+	assert.NoError(t, pushRequest(req))
+	assert.NoError(t, FlushQueue(5*time.Second))
+	assert.Len(t, *handled, 2)
+}
+
+func TestFlushQueue_NotInitialized(t *testing.T) {
+	metadataQueue = nil
+	assert.NoError(t, FlushQueue(time.Second))
+}
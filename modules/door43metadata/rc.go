@@ -0,0 +1,92 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// rcFormat is the Resource Container format, a manifest.yaml file validated by the RC 0.2 schema
+type rcFormat struct{}
+
+func (f *rcFormat) Type() string {
+	return models.MetadataTypeRC
+}
+
+func (f *rcFormat) ReadMetadata(commit *git.Commit) (*map[string]interface{}, error) {
+	blob, err := readBlob(commit, models.MetadataTypeFilenames[models.MetadataTypeRC])
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	return base.ReadYAMLFromBlob(blob)
+}
+
+func (f *rcFormat) SchemaName() string {
+	return "rc.schema.json"
+}
+
+func (f *rcFormat) Validate(metadata *map[string]interface{}) (*gojsonschema.Result, error) {
+	return base.ValidateBlobByRC020Schema(metadata)
+}
+
+func (f *rcFormat) Normalize(dm *models.Door43Metadata) error {
+	manifest := map[string]interface{}{}
+	if dm.Metadata != nil {
+		manifest = *dm.Metadata
+	}
+
+	dm.MetadataType = models.MetadataTypeRC
+	dm.MetadataVersion = getString(manifest, "dublin_core", "conformsto")
+	if dm.MetadataVersion == "" {
+		dm.MetadataVersion = "rc0.2"
+	}
+	dm.Title = base.TruncateString(getString(manifest, "dublin_core", "title"), 255)
+	dm.Subject = getString(manifest, "dublin_core", "subject")
+	dm.Language = getString(manifest, "dublin_core", "language", "identifier")
+	dm.LanguageTitle = getString(manifest, "dublin_core", "language", "title")
+	dm.LanguageDir = getString(manifest, "dublin_core", "language", "direction")
+
+	dm.CheckingLevel = 0
+	if checking, ok := manifest["checking"].(map[string]interface{}); ok {
+		switch level := checking["checking_level"].(type) {
+		case string:
+			dm.CheckingLevel, _ = strconv.Atoi(level)
+		case float64:
+			dm.CheckingLevel = int(level)
+		}
+	}
+
+	dm.Relations = []string{}
+	if dublinCore, ok := getMap(manifest, "dublin_core"); ok {
+		if list, ok := dublinCore["relation"].([]interface{}); ok {
+			for _, relation := range list {
+				if r, ok := relation.(string); ok && r != "" {
+					dm.Relations = append(dm.Relations, r)
+				}
+			}
+		}
+	}
+
+	dm.Books = nil
+	dm.Ingredients = nil
+	if projects, ok := manifest["projects"].([]interface{}); ok {
+		dm.Ingredients = projects
+		for _, project := range projects {
+			if p, ok := project.(map[string]interface{}); ok {
+				if identifier := getString(p, "identifier"); identifier != "" {
+					dm.Books = append(dm.Books, strings.ToLower(identifier))
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// RCLinkUnresolvableReason is why an rc:// link can not be resolved to a file in the catalog
+type RCLinkUnresolvableReason string
+
+// RCLinkUnresolvableReason values
+const (
+	RCLinkInvalid      RCLinkUnresolvableReason = "invalid"
+	RCLinkNoLanguage   RCLinkUnresolvableReason = "no_language"
+	RCLinkNotInCatalog RCLinkUnresolvableReason = "not_in_catalog"
+	RCLinkFileNotFound RCLinkUnresolvableReason = "file_not_found"
+)
+
+// ErrRCLinkUnresolvable represents a "RCLinkUnresolvable" kind of error.
+type ErrRCLinkUnresolvable struct {
+	Link   string
+	Reason RCLinkUnresolvableReason
+}
+
+// IsErrRCLinkUnresolvable checks if an error is a ErrRCLinkUnresolvable.
+func IsErrRCLinkUnresolvable(err error) bool {
+	_, ok := err.(ErrRCLinkUnresolvable)
+	return ok
+}
+
+func (err ErrRCLinkUnresolvable) Error() string {
+	return fmt.Sprintf("rc link can not be resolved [link: %s, reason: %s]", err.Link, err.Reason)
+}
+
+// getRCLinkEntry returns the catalog entry the repos of the given name resolve to, the latest production release of the
+// owner that comes first in the owner precedence, or else the latest production release of any owner. If the resource
+// has no production release, its latest prerelease or default branch is used the same way.
+func getRCLinkEntry(repoName string) (*models.Door43Metadata, error) {
+	for _, stage := range []models.Stage{models.StageProd, models.StageLatest} {
+		dms, _, err := models.SearchCatalog(&models.SearchCatalogOptions{
+			Repos:   []string{repoName},
+			Stage:   stage,
+			OrderBy: []models.CatalogOrderBy{models.CatalogOrderByNewest},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range setting.DCS.RCOwnerPrecedence {
+			for _, dm := range dms {
+				if strings.EqualFold(dm.Repo.OwnerName, owner) {
+					return dm, nil
+				}
+			}
+		}
+		if len(dms) > 0 {
+			return dms[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// ResolveRCLink resolves an rc:// link to the catalog entry of its resource and the path of the linked file in it,
+// which is empty for a link to the resource itself
+func ResolveRCLink(link string) (*models.Door43Metadata, string, error) {
+	l := dcs.ParseRCLink(link)
+	if l == nil {
+		return nil, "", ErrRCLinkUnresolvable{Link: link, Reason: RCLinkInvalid}
+	} else if l.Language == "*" {
+		return nil, "", ErrRCLinkUnresolvable{Link: link, Reason: RCLinkNoLanguage}
+	}
+
+	dm, err := getRCLinkEntry(l.RepoName(""))
+	if err != nil {
+		return nil, "", err
+	} else if dm == nil {
+		return nil, "", ErrRCLinkUnresolvable{Link: link, Reason: RCLinkNotInCatalog}
+	}
+	if l.FilePath() == "" {
+		return dm, "", nil
+	}
+
+	gitRepo, err := git.OpenRepository(dm.Repo.RepoPath())
+	if err != nil {
+		return nil, "", err
+	}
+	defer gitRepo.Close()
+	commit, err := gitRepo.GetCommit(dm.BranchOrTag)
+	if err != nil {
+		return nil, "", err
+	}
+	// An article or entry that is not in its usual file may still be a directory or file of the link's path
+	for _, filePath := range []string{l.FilePath(), l.Path} {
+		if _, err := commit.GetTreeEntryByPath(filePath); err == nil {
+			return dm, filePath, nil
+		} else if !git.IsErrNotExist(err) {
+			return nil, "", err
+		}
+	}
+	return nil, "", ErrRCLinkUnresolvable{Link: link, Reason: RCLinkFileNotFound}
+}
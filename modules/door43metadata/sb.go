@@ -0,0 +1,174 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// sbFlavorSubjects maps the Scripture Burrito scripture flavors to the subjects used by Resource Containers
+var sbFlavorSubjects = map[string]string{
+	"textTranslation":              "Bible",
+	"audioTranslation":             "Audio Bible",
+	"signLanguageVideoTranslation": "Sign Language Video Bible",
+	"typesetScripture":             "Typeset Bible",
+	"embossedBrailleScripture":     "Braille Bible",
+}
+
+// sbFormat is the Scripture Burrito format, a metadata.json file validated by sb.schema.json, a local subset of the
+// official SB schema with only the parts the catalog depends on
+type sbFormat struct{}
+
+func (f *sbFormat) Type() string {
+	return models.MetadataTypeSB
+}
+
+func (f *sbFormat) ReadMetadata(commit *git.Commit) (*map[string]interface{}, error) {
+	blob, err := readBlob(commit, models.MetadataTypeFilenames[models.MetadataTypeSB])
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	return base.ReadJSONFromBlob(blob)
+}
+
+func (f *sbFormat) SchemaName() string {
+	return "sb.schema.json"
+}
+
+func (f *sbFormat) Validate(metadata *map[string]interface{}) (*gojsonschema.Result, error) {
+	return base.ValidateMetadataBySBSchema(metadata)
+}
+
+func (f *sbFormat) Normalize(dm *models.Door43Metadata) error {
+	metadata := map[string]interface{}{}
+	if dm.Metadata != nil {
+		metadata = *dm.Metadata
+	}
+
+	locale := getString(metadata, "meta", "defaultLocale")
+	if locale == "" {
+		locale = "en"
+	}
+
+	dm.MetadataType = models.MetadataTypeSB
+	dm.MetadataVersion = models.MetadataTypeSB + getString(metadata, "meta", "version")
+	dm.CheckingLevel = 0
+
+	identification, _ := metadata["identification"].(map[string]interface{})
+	dm.Title = base.TruncateString(getLocalizedText(identification["name"], locale), 255)
+
+	dm.Language, dm.LanguageTitle, dm.LanguageDir = "", "", ""
+	if languages, ok := metadata["languages"].([]interface{}); ok && len(languages) > 0 {
+		if language, ok := languages[0].(map[string]interface{}); ok {
+			dm.Language = getString(language, "tag")
+			dm.LanguageTitle = getLocalizedText(language["name"], locale)
+			dm.LanguageDir = getString(language, "scriptDirection")
+		}
+	}
+
+	flavorType := getString(metadata, "type", "flavorType", "name")
+	flavor := getString(metadata, "type", "flavorType", "flavor", "name")
+	if subject, ok := sbFlavorSubjects[flavor]; ok && flavorType == "scripture" {
+		dm.Subject = subject
+	} else {
+		dm.Subject = flavor
+	}
+
+	localizedNames, _ := metadata["localizedNames"].(map[string]interface{})
+	ingredients, _ := metadata["ingredients"].(map[string]interface{})
+	paths := make([]string, 0, len(ingredients))
+	for path := range ingredients {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	books := map[string]bool{}
+	dm.Ingredients = nil
+	dm.Relations = nil
+	for _, path := range paths {
+		ingredient, _ := ingredients[path].(map[string]interface{})
+		scope, _ := ingredient["scope"].(map[string]interface{})
+		if len(scope) != 1 {
+			continue
+		}
+		for book := range scope {
+			book = strings.ToLower(book)
+			if !dcs.IsValidBook(book) {
+				continue
+			}
+			books[book] = true
+			var title string
+			if names, ok := localizedNames["book-"+book].(map[string]interface{}); ok {
+				title = getLocalizedText(names["short"], locale)
+			}
+			dm.Ingredients = append(dm.Ingredients, map[string]interface{}{
+				"identifier": book,
+				"title":      title,
+				"path":       "./" + path,
+				"sort":       dcs.BookNumber(book),
+				"mime_type":  ingredient["mimeType"],
+				"size":       ingredient["size"],
+			})
+		}
+	}
+	if len(books) == 0 {
+		if currentScope, ok := getMap(metadata, "type", "flavorType", "currentScope"); ok {
+			for book := range currentScope {
+				if book = strings.ToLower(book); dcs.IsValidBook(book) {
+					books[book] = true
+				}
+			}
+		}
+	}
+
+	dm.Books = nil
+	for book := range books {
+		dm.Books = append(dm.Books, book)
+	}
+	sort.Slice(dm.Books, func(i, j int) bool { return dcs.BookNumber(dm.Books[i]) < dcs.BookNumber(dm.Books[j]) })
+	sort.SliceStable(dm.Ingredients, func(i, j int) bool {
+		return dm.Ingredients[i].(map[string]interface{})["sort"].(int) < dm.Ingredients[j].(map[string]interface{})["sort"].(int)
+	})
+	return nil
+}
+
+// getLocalizedText returns the text of a Scripture Burrito localized text for the locale,
+// falling back to the first locale alphabetically if it isn't there
+func getLocalizedText(value interface{}, locale string) string {
+	text, ok := value.(map[string]interface{})
+	if !ok || len(text) == 0 {
+		return ""
+	}
+	if str, ok := text[locale].(string); ok {
+		return str
+	}
+	locales := make([]string, 0, len(text))
+	for l := range text {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	str, _ := text[locales[0]].(string)
+	return str
+}
+
+// getMap returns the map at the given path of keys in a generic map
+func getMap(m map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, key := range keys {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		m = next
+	}
+	return m, true
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files and directories of a catalog snapshot
+const (
+	// SnapshotFile describes the snapshot, with the filters it was exported with
+	SnapshotFile = "snapshot.json"
+	// SnapshotCatalogFile is the v5 catalog search response of all the entries of the snapshot
+	SnapshotCatalogFile = "catalog.json"
+	// SnapshotChecksumsFile lists the SHA-256 checksum of every other file of the snapshot, in sha256sum format
+	SnapshotChecksumsFile = "checksums.sha256"
+)
+
+// CatalogSnapshot describes a static snapshot of the catalog exported for use offline
+type CatalogSnapshot struct {
+	Version   int       `json:"version"`
+	AppURL    string    `json:"app_url"`
+	Created   time.Time `json:"created"`
+	Owners    []string  `json:"owners,omitempty"`
+	Languages []string  `json:"languages,omitempty"`
+	Stage     string    `json:"stage"`
+	Zipballs  bool      `json:"zipballs"`
+	Entries   int       `json:"entries"`
+}
+
+// CatalogSnapshotVersion is the version of the snapshot layout written by this version of DCS
+const CatalogSnapshotVersion = 1
+
+// snapshotRefName returns a ref name that can be used as a file name
+func snapshotRefName(ref string) string {
+	return strings.ReplaceAll(ref, "/", "-")
+}
+
+// SnapshotEntryPath returns the path in a snapshot of the v5 response of the entry of a repo's tag or branch
+func SnapshotEntryPath(owner, repo, ref string) string {
+	return path.Join("entries", owner, repo, snapshotRefName(ref)+".json")
+}
+
+// SnapshotMetadataPath returns the path in a snapshot of the metadata of the entry of a repo's tag or branch
+func SnapshotMetadataPath(owner, repo, ref string) string {
+	return path.Join("entries", owner, repo, snapshotRefName(ref), "metadata.json")
+}
+
+// SnapshotZipballPath returns the path in a snapshot of the zipball of a repo's tag or branch
+func SnapshotZipballPath(owner, repo, ref string) string {
+	return path.Join("zipballs", owner, repo, snapshotRefName(ref)+".zip")
+}
+
+// fileChecksum returns the hex encoded SHA-256 checksum of a file
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteSnapshotChecksums writes the checksums file of the snapshot in the directory, listing all its other files
+func WriteSnapshotChecksums(dir string) error {
+	var lines []string
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == SnapshotChecksumsFile {
+			return nil
+		}
+		sum, err := fileChecksum(p)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, sum+"  "+rel)
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+	return ioutil.WriteFile(filepath.Join(dir, SnapshotChecksumsFile), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// VerifySnapshotChecksums verifies the files of the snapshot in the directory against its checksums file
+func VerifySnapshotChecksums(dir string) error {
+	f, err := os.Open(filepath.Join(dir, SnapshotChecksumsFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 || !isSnapshotPath(fields[1]) {
+			return fmt.Errorf("invalid line in %s: \"%s\"", SnapshotChecksumsFile, line)
+		}
+		sum, err := fileChecksum(filepath.Join(dir, filepath.FromSlash(fields[1])))
+		if err != nil {
+			return err
+		}
+		if sum != fields[0] {
+			return fmt.Errorf("checksum mismatch: %s", fields[1])
+		}
+	}
+	return scanner.Err()
+}
+
+// isSnapshotPath returns true if the path is a relative path that stays within the snapshot, without a ".." or
+// ".git" part, so that extracting it can't write outside of the directory nor into a git repo in it
+func isSnapshotPath(p string) bool {
+	if path.IsAbs(p) {
+		return false
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." || strings.EqualFold(part, ".git") {
+			return false
+		}
+	}
+	return path.Clean(p) != "."
+}
+
+// TarSnapshot writes the snapshot in the directory to a gzipped tarball
+func TarSnapshot(dir string, target io.Writer) error {
+	gw := gzip.NewWriter(target)
+	tw := tar.NewWriter(gw)
+	if err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// UntarSnapshot extracts a snapshot from a gzipped tarball into the directory
+func UntarSnapshot(source io.Reader, dir string) error {
+	gr, err := gzip.NewReader(source)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !isSnapshotPath(header.Name) {
+			return fmt.Errorf("invalid path in snapshot: \"%s\"", header.Name)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(path.Clean(header.Name)))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// ExtractSnapshotZipball extracts a zipball of a snapshot into the directory, without the directory all its files are
+// in if the zipball was archived with a prefix
+func ExtractSnapshotZipball(filename, dir string) error {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	prefix := ""
+	for i, f := range zr.File {
+		name := strings.TrimPrefix(path.Clean(f.Name), "/")
+		first := strings.SplitN(name, "/", 2)[0] + "/"
+		if i == 0 {
+			prefix = first
+		}
+		if !strings.HasPrefix(name+"/", prefix) || (!f.FileInfo().IsDir() && !strings.Contains(name, "/")) {
+			prefix = ""
+			break
+		}
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !isSnapshotPath(f.Name) {
+			return fmt.Errorf("invalid path in zipball: \"%s\"", f.Name)
+		}
+		name := strings.TrimPrefix(path.Clean(f.Name), prefix)
+		if !isSnapshotPath(name) {
+			return fmt.Errorf("invalid path in zipball: \"%s\"", f.Name)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
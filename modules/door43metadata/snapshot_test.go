@@ -0,0 +1,130 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, filename, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+}
+
+func TestSnapshotChecksums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, filepath.Join(dir, SnapshotCatalogFile), `{"ok":true,"data":[]}`)
+	writeTestFile(t, filepath.Join(dir, filepath.FromSlash(SnapshotEntryPath("user2", "repo1", "v1"))), `{}`)
+
+	assert.NoError(t, WriteSnapshotChecksums(dir))
+	checksums, err := ioutil.ReadFile(filepath.Join(dir, SnapshotChecksumsFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(checksums), "  catalog.json\n")
+	assert.Contains(t, string(checksums), "  entries/user2/repo1/v1.json\n")
+	assert.NoError(t, VerifySnapshotChecksums(dir))
+
+	var buf bytes.Buffer
+	assert.NoError(t, TarSnapshot(dir, &buf))
+	extracted, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(extracted)
+	assert.NoError(t, UntarSnapshot(&buf, extracted))
+	assert.NoError(t, VerifySnapshotChecksums(extracted))
+
+	writeTestFile(t, filepath.Join(dir, SnapshotCatalogFile), `{"ok":false}`)
+	assert.Error(t, VerifySnapshotChecksums(dir))
+}
+
+func TestExtractSnapshotZipball(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zipball")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, prefix := range []string{"repo1/", ""} {
+		filename := filepath.Join(dir, "repo1.zip")
+		f, err := os.Create(filename)
+		assert.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for _, name := range []string{"manifest.yaml", "content/01-GEN.usfm"} {
+			w, err := zw.Create(prefix + name)
+			assert.NoError(t, err)
+			_, err = w.Write([]byte(name))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, zw.Close())
+		assert.NoError(t, f.Close())
+
+		target := filepath.Join(dir, "extracted"+prefix)
+		assert.NoError(t, ExtractSnapshotZipball(filename, target))
+		content, err := ioutil.ReadFile(filepath.Join(target, "content", "01-GEN.usfm"))
+		assert.NoError(t, err)
+		assert.Equal(t, "content/01-GEN.usfm", string(content))
+		assert.FileExists(t, filepath.Join(target, "manifest.yaml"))
+	}
+}
+
+func TestExtractSnapshotZipball_InvalidPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zipball")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		".git/hooks/pre-commit",
+		"repo1/.git/hooks/pre-commit",
+		"repo1/.GIT/config",
+		"/etc/passwd",
+		"../outside",
+		"repo1/../../outside",
+	} {
+		filename := filepath.Join(dir, "repo1.zip")
+		f, err := os.Create(filename)
+		assert.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for _, n := range []string{"repo1/manifest.yaml", name} {
+			w, err := zw.Create(n)
+			assert.NoError(t, err)
+			_, err = w.Write([]byte("#!/bin/sh\nexit 1\n"))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, zw.Close())
+		assert.NoError(t, f.Close())
+
+		target := filepath.Join(dir, "extracted")
+		assert.Error(t, ExtractSnapshotZipball(filename, target), name)
+		_, err = os.Stat(filepath.Join(target, ".git"))
+		assert.True(t, os.IsNotExist(err), name)
+		assert.NoError(t, os.RemoveAll(target))
+	}
+}
+
+func TestUntarSnapshot_InvalidPaths(t *testing.T) {
+	for _, name := range []string{".git/hooks/pre-commit", "entries/.git/config", "/etc/passwd", "../outside"} {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte("x"))
+		assert.NoError(t, err)
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gw.Close())
+
+		dir, err := ioutil.TempDir("", "snapshot")
+		assert.NoError(t, err)
+		assert.Error(t, UntarSnapshot(&buf, dir), name)
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
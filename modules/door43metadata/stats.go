@@ -0,0 +1,302 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/git"
+)
+
+var usfmMarkerRegexp = regexp.MustCompile(`\\\+?([A-Za-z0-9-]*)(\*?)`)
+
+// usfmSkippedMarkers are the markers whose content up to their end marker is not text of the verses,
+// such as footnotes, cross references and alternate numbers
+var usfmSkippedMarkers = map[string]bool{
+	"f": true, "fe": true, "ef": true, "x": true, "ex": true, "ca": true, "va": true, "vp": true, "fig": true, "rq": true,
+}
+
+// usfmHeadingMarkers are the paragraph markers, without their number, whose paragraph is not text of the verses,
+// such as the identification, titles, headings and introductions
+var usfmHeadingMarkers = map[string]bool{
+	"id": true, "ide": true, "h": true, "toc": true, "toca": true, "usfm": true, "sts": true, "rem": true,
+	"mt": true, "mte": true, "ms": true, "mr": true, "s": true, "sr": true, "r": true, "d": true, "sp": true,
+	"sd": true, "cl": true, "cp": true, "cd": true, "qa": true, "lit": true, "imt": true, "imte": true, "is": true,
+	"ip": true, "ipi": true, "im": true, "imi": true, "ipq": true, "imq": true, "ipr": true, "iq": true, "ib": true,
+	"ili": true, "iot": true, "io": true, "ior": true, "iex": true, "ie": true,
+}
+
+// BookStatsCounter is implemented by the formats whose metadata lists the USFM files of the books of a Bible
+type BookStatsCounter interface {
+	// CountBooks returns the statistics of the USFM files of the books of the Bible the metadata lists in the commit
+	CountBooks(commit *git.Commit, metadata *map[string]interface{}) ([]*models.Door43MetadataBookStats, error)
+}
+
+// bookFile is the USFM file of a book of the Bible listed in a metadata file
+type bookFile struct {
+	book string
+	path string
+}
+
+// CountBooks counts the USFM projects of the manifest
+func (f *rcFormat) CountBooks(commit *git.Commit, metadata *map[string]interface{}) ([]*models.Door43MetadataBookStats, error) {
+	manifest := map[string]interface{}{}
+	if metadata != nil {
+		manifest = *metadata
+	}
+	var files []bookFile
+	projects, _ := manifest["projects"].([]interface{})
+	for _, project := range projects {
+		p, ok := project.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		projectPath, ok := cleanProjectPath(getString(p, "path"))
+		if ok && projectPath != "" && getProjectFormat(manifest, p, projectPath) == "usfm" {
+			files = append(files, bookFile{book: getString(p, "identifier"), path: projectPath})
+		}
+	}
+	return countBooks(&commitContainer{commit: commit}, files)
+}
+
+// CountBooks counts the USFM ingredients of the metadata whose scope is a single book
+func (f *sbFormat) CountBooks(commit *git.Commit, metadata *map[string]interface{}) ([]*models.Door43MetadataBookStats, error) {
+	var files []bookFile
+	if metadata != nil {
+		ingredients, _ := (*metadata)["ingredients"].(map[string]interface{})
+		for ingredientPath, value := range ingredients {
+			ingredient, _ := value.(map[string]interface{})
+			mimeType := getString(ingredient, "mimeType")
+			if !strings.Contains(mimeType, "usfm") && !strings.EqualFold(path.Ext(ingredientPath), ".usfm") {
+				continue
+			}
+			scope, _ := ingredient["scope"].(map[string]interface{})
+			if len(scope) != 1 {
+				continue
+			}
+			for book := range scope {
+				files = append(files, bookFile{book: book, path: ingredientPath})
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return countBooks(&commitContainer{commit: commit}, files)
+}
+
+// countBooks returns the statistics of the files of the books of the Bible that are in the container, identifying the
+// book of a file by its \id marker if it isn't listed as one, and counting only the first file of each book
+func countBooks(c container, files []bookFile) ([]*models.Door43MetadataBookStats, error) {
+	stats := make([]*models.Door43MetadataBookStats, 0, len(files))
+	seen := make(map[string]bool)
+	for _, file := range files {
+		content, err := c.readFile(file.path)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		book := strings.ToLower(file.book)
+		if !dcs.IsValidBook(book) {
+			match := usfmIDRegexp.FindSubmatch(content)
+			if match == nil || !dcs.IsValidBook(string(match[1])) {
+				continue
+			}
+			book = strings.ToLower(string(match[1]))
+		}
+		if seen[book] {
+			continue
+		}
+		seen[book] = true
+
+		counts := countUSFM(string(content))
+		stats = append(stats, &models.Door43MetadataBookStats{
+			Book:             book,
+			Path:             file.path,
+			Chapters:         counts.chapters,
+			ExpectedChapters: len(dcs.BookVerseCounts(book)),
+			Verses:           counts.verses,
+			ExpectedVerses:   dcs.BookVerseCount(book),
+			Words:            counts.words,
+			AlignedWords:     counts.alignedWords,
+		})
+	}
+	return stats, nil
+}
+
+// usfmCounts are the counts of the content of a USFM file
+type usfmCounts struct {
+	// chapters is the number of chapters with at least one verse
+	chapters int
+	// verses is the number of verses that have text, so the empty verses of a template aren't counted
+	verses int
+	// words is the number of words of the text of the verses, leaving out notes and headings
+	words int
+	// alignedWords is the number of words that are within a \zaln-s and \zaln-e milestone
+	alignedWords int
+}
+
+// countUSFM counts the chapters, verses and words of the text of a USFM file and how many of the words are aligned
+func countUSFM(content string) *usfmCounts {
+	counts := &usfmCounts{}
+	present := make(map[int]map[int]bool)
+	var chapter, fromVerse, toVerse, skipDepth, alignDepth int
+	var inHeading bool
+
+	countText := func(text string) {
+		if chapter <= 0 || fromVerse <= 0 || skipDepth > 0 || inHeading {
+			return
+		}
+		if i := strings.IndexByte(text, '|'); i >= 0 {
+			// The attributes of a word or milestone
+			text = text[:i]
+		}
+		for _, field := range strings.Fields(text) {
+			if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) < 0 {
+				continue
+			}
+			counts.words++
+			if alignDepth > 0 {
+				counts.alignedWords++
+			}
+			if present[chapter] == nil {
+				present[chapter] = make(map[int]bool)
+			}
+			for verse := fromVerse; verse <= toVerse; verse++ {
+				present[chapter][verse] = true
+			}
+		}
+	}
+
+	matches := usfmMarkerRegexp.FindAllStringSubmatchIndex(content, -1)
+	for i, match := range matches {
+		if i == 0 {
+			countText(content[:match[0]])
+		}
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		name := content[match[2]:match[3]]
+		isEnd := match[5] > match[4]
+		text := content[match[1]:end]
+
+		switch {
+		case isEnd:
+			if usfmSkippedMarkers[name] && skipDepth > 0 {
+				skipDepth--
+			}
+		case usfmSkippedMarkers[name]:
+			skipDepth++
+		case name == "zaln-s":
+			alignDepth++
+		case name == "zaln-e":
+			if alignDepth > 0 {
+				alignDepth--
+			}
+		case name == "c":
+			var number string
+			number, text = splitUSFMNumber(text)
+			chapter, _ = strconv.Atoi(number)
+			fromVerse, toVerse, inHeading = 0, 0, false
+		case name == "v":
+			var number string
+			number, text = splitUSFMNumber(text)
+			fromVerse, toVerse = parseVerseRange(number)
+			inHeading = false
+		case usfmHeadingMarkers[strings.TrimRight(name, "0123456789")]:
+			inHeading = true
+		case !isUSFMCharacterMarker(name):
+			inHeading = false
+		}
+		countText(text)
+	}
+
+	for _, verses := range present {
+		counts.chapters++
+		counts.verses += len(verses)
+	}
+	return counts
+}
+
+// isUSFMCharacterMarker returns true if the marker is one of the character or milestone markers that can be within
+// the text of a paragraph, such as \w, \add or \zaln-s, rather than one that starts a paragraph
+func isUSFMCharacterMarker(name string) bool {
+	switch strings.TrimRight(name, "0123456789") {
+	case "", "w", "wj", "add", "nd", "bk", "pn", "png", "qt", "sig", "sls", "tl", "k", "bd", "it", "bdit", "em", "no",
+		"sc", "sup", "rb", "pro", "wg", "wh", "wa", "ord", "dc", "lik", "liv", "litl", "qs", "qac", "jmp", "th", "thr",
+		"tc", "tcr", "ts", "qt-s", "qt-e", "ts-s", "ts-e":
+		return true
+	}
+	return strings.HasPrefix(name, "z")
+}
+
+// splitUSFMNumber splits the text following a \c or \v marker into its number and the rest of the text
+func splitUSFMNumber(text string) (string, string) {
+	text = strings.TrimLeft(text, " \t\r\n")
+	if i := strings.IndexAny(text, " \t\r\n"); i >= 0 {
+		return text[:i], text[i:]
+	}
+	return text, ""
+}
+
+// parseVerseRange returns the first and last verses of a verse number such as "3", "3-5" or "3a", 0 if it is invalid
+func parseVerseRange(number string) (int, int) {
+	parts := strings.SplitN(number, "-", 2)
+	from := leadingNumber(parts[0])
+	to := from
+	if len(parts) == 2 {
+		if last := leadingNumber(parts[1]); last > from {
+			to = last
+		}
+	}
+	return from, to
+}
+
+// leadingNumber returns the number the string starts with, 0 if it doesn't start with one
+func leadingNumber(s string) int {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i >= 0 {
+		s = s[:i]
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// recordBookStats saves the statistics of the books of the ref of a repo's release or branch, unless they were already
+// counted for the same commit or its format doesn't list books
+func recordBookStats(repo *models.Repository, release *models.Release, ref string, commit *git.Commit, format Format, metadata *map[string]interface{}) error {
+	counter, ok := format.(BookStatsCounter)
+	if !ok {
+		return models.DeleteDoor43MetadataBookStats(repo.ID, ref)
+	}
+	commitSHA, err := models.GetDoor43MetadataBookStatsCommitSHA(repo.ID, ref)
+	if err != nil {
+		return err
+	}
+	if commitSHA == commit.ID.String() {
+		return nil
+	}
+
+	stats, err := counter.CountBooks(commit, metadata)
+	if err != nil {
+		return err
+	}
+	var releaseID int64
+	if release != nil {
+		releaseID = release.ID
+	}
+	for _, s := range stats {
+		s.CommitSHA = commit.ID.String()
+	}
+	return models.ReplaceDoor43MetadataBookStats(repo.ID, releaseID, ref, stats)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountUSFM(t *testing.T) {
+	content := `\id RUT unfoldingWord Literal Text
+\usfm 3.0
+\h Ruth
+\mt Ruth
+\c 1
+\s1 Naomi goes to Moab
+\p
+\v 1 In the days when the judges ruled\f + \ft Or: \fqa governed\fqa*\f*, there was a famine.
+\v 2-3 The name of the man was Elimelech.
+\v 4
+\c 2
+\v 1
+\c 3
+\v 1 Then Naomi said,
+\q1 "My daughter,
+\v 2 \add is\add* Boaz not our relative?"
+`
+	assert.Equal(t, &usfmCounts{chapters: 2, verses: 5, words: 28}, countUSFM(content))
+
+	aligned := `\id TIT
+\c 1
+\p
+\v 1 \zaln-s |x-strong="G39720" x-lemma="Παῦλος" x-occurrence="1" x-occurrences="1" x-content="Παῦλος"\*\w Paul|x-occurrence="1" x-occurrences="1"\w*\zaln-e\*,
+\w a|x-occurrence="1" x-occurrences="1"\w*
+\zaln-s |x-strong="G14010" x-lemma="δοῦλος" x-occurrence="1" x-occurrences="1" x-content="δοῦλος"\*\w servant|x-occurrence="1" x-occurrences="1"\w*
+\w of|x-occurrence="1" x-occurrences="1"\w*\zaln-e\*
+`
+	assert.Equal(t, &usfmCounts{chapters: 1, verses: 1, words: 4, alignedWords: 3}, countUSFM(aligned))
+}
+
+func TestCountBooks(t *testing.T) {
+	c := mapContainer{
+		"08-RUT.usfm": "\\id RUT\n\\c 1\n\\v 1 In the days\n\\v 2 \n",
+		"front.usfm":  "\\id FRT\n\\c 1\n\\v 1 Front\n",
+		"extra.usfm":  "\\id RUT\n\\c 1\n\\v 1 Again\n",
+		"book.usfm":   "\\id JUD\n\\c 1\n\\v 1 Jude\n",
+	}
+	stats, err := countBooks(c, []bookFile{
+		{book: "RUT", path: "08-RUT.usfm"},
+		{book: "frt", path: "front.usfm"},
+		{book: "rut", path: "extra.usfm"},
+		{book: "", path: "book.usfm"},
+		{book: "gen", path: "01-GEN.usfm"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Door43MetadataBookStats{
+		{Book: "rut", Path: "08-RUT.usfm", Chapters: 1, ExpectedChapters: 4, Verses: 1, ExpectedVerses: 85, Words: 3},
+		{Book: "jud", Path: "book.usfm", Chapters: 1, ExpectedChapters: 1, Verses: 1, ExpectedVerses: 25, Words: 1},
+	}, stats)
+}
+
+func TestParseVerseRange(t *testing.T) {
+	for number, expected := range map[string][2]int{
+		"3":   {3, 3},
+		"3-5": {3, 5},
+		"3a":  {3, 3},
+		"5-3": {5, 5},
+		"x":   {0, 0},
+	} {
+		from, to := parseVerseRange(number)
+		assert.Equal(t, expected, [2]int{from, to}, number)
+	}
+}
@@ -13,7 +13,8 @@ import (
 	"xorm.io/builder"
 )
 
-// UpdateDoor43Metadata generates door43_metadata table entries for valid repos/releases that don't have them
+// UpdateDoor43Metadata queues the releases and default branches of the repos with metadata files to be processed and
+// waits for them, which generates door43_metadata table entries for the valid ones that don't have them
 func UpdateDoor43Metadata(ctx context.Context) error {
 	log.Trace("Doing: UpdateDoor43Metadata")
 
@@ -33,11 +34,11 @@ func UpdateDoor43Metadata(ctx context.Context) error {
 				return models.ErrCancelledf("before update door43 metadata of %s", repo.FullName())
 			default:
 			}
-			log.Trace("Running generate metadata on %v", repo)
-			if err := ProcessDoor43MetadataForRepo(repo); err != nil {
-				log.Warn("Failed to process metadata for repo (%v): %v", repo, err)
-				if err = models.CreateRepositoryNotice("Failed to process metadata for repository (%s): %v", repo.FullName(), err); err != nil {
-					log.Error("ProcessDoor43MetadataForRepo: %v", err)
+			log.Trace("Queueing generate metadata on %v", repo)
+			if err := QueueDoor43MetadataForRepo(repo); err != nil {
+				log.Warn("Failed to queue metadata for repo (%v): %v", repo, err)
+				if err = models.CreateRepositoryNotice("Failed to queue metadata for repository (%s): %v", repo.FullName(), err); err != nil {
+					log.Error("QueueDoor43MetadataForRepo: %v", err)
 				}
 			}
 			return nil
@@ -47,6 +48,12 @@ func UpdateDoor43Metadata(ctx context.Context) error {
 		return err
 	}
 
+	// Wait for what was queued to be processed, so the task only finishes once the catalog is up to date
+	if err := FlushQueue(0); err != nil {
+		log.Error("FlushQueue: %v", err)
+		return err
+	}
+
 	log.Trace("Finished: UpdateDoor43Metadata")
 	return nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// getValidationRef returns the ref a validation is kept under, the tag of a release or the default branch
+func getValidationRef(repo *models.Repository, release *models.Release) string {
+	if release != nil {
+		return release.TagName
+	}
+	return repo.DefaultBranch
+}
+
+// getValidationRefType returns the type of the ref a validation is kept under, a tag for a release and a branch if not
+func getValidationRefType(release *models.Release) string {
+	if release != nil {
+		return models.ValidationRefTypeTag
+	}
+	return models.ValidationRefTypeBranch
+}
+
+// newValidation returns the outcome of validating the metadata file of a repo's release or branch, kept under the given
+// ref, against its schema and of linting the files it lists
+func newValidation(repo *models.Repository, release *models.Release, ref string, commit *git.Commit, format Format, result *gojsonschema.Result, findings []*models.Door43MetadataFinding) (*models.Door43MetadataValidation, error) {
+	schema, err := base.GetSchemaVersion(format.SchemaName())
+	if err != nil {
+		return nil, err
+	}
+
+	validation := &models.Door43MetadataValidation{
+		RepoID:       repo.ID,
+		Ref:          ref,
+		RefType:      getValidationRefType(release),
+		CommitSHA:    commit.ID.String(),
+		MetadataType: format.Type(),
+		Filename:     models.MetadataTypeFilenames[format.Type()],
+		Schema:       schema,
+		IsValid:      result.Valid(),
+		Errors:       []*models.Door43MetadataValidationError{},
+		Findings:     findings,
+	}
+	if validation.Findings == nil {
+		validation.Findings = []*models.Door43MetadataFinding{}
+	}
+	if release != nil {
+		validation.ReleaseID = release.ID
+	}
+	for _, resultError := range result.Errors() {
+		validation.Errors = append(validation.Errors, &models.Door43MetadataValidationError{
+			Field:       resultError.Field(),
+			Type:        resultError.Type(),
+			Description: resultError.Description(),
+			Value:       resultError.Value(),
+		})
+	}
+	return validation, nil
+}
+
+// recordValidation saves the outcome of validating the metadata file of a repo's release or default branch and
+// linting its files, also posting it as a commit status if enabled and it has changed
+func recordValidation(repo *models.Repository, release *models.Release, validation *models.Door43MetadataValidation) error {
+	previous, err := models.GetDoor43MetadataValidation(repo.ID, validation.RefType, validation.Ref)
+	if err != nil && !models.IsErrDoor43MetadataValidationNotExist(err) {
+		return err
+	}
+	if err := models.UpsertDoor43MetadataValidation(validation); err != nil {
+		return err
+	}
+
+	if !setting.DCS.MetadataCommitStatus ||
+		(previous != nil && previous.CommitSHA == validation.CommitSHA && previous.IsValid == validation.IsValid &&
+			len(previous.ErrorFindings()) == len(validation.ErrorFindings()) &&
+			len(previous.WarningFindings()) == len(validation.WarningFindings())) {
+		return nil
+	}
+	return createValidationCommitStatus(repo, release, validation)
+}
+
+// createValidationCommitStatus posts a metadata validation as a commit status of the commit that was validated
+func createValidationCommitStatus(repo *models.Repository, release *models.Release, validation *models.Door43MetadataValidation) error {
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+
+	status := &models.CommitStatus{
+		State:       structs.CommitStatusSuccess,
+		Description: fmt.Sprintf("%s is valid", validation.Filename),
+		Context:     models.Door43MetadataValidationStatusContext,
+	}
+	if !validation.IsValid {
+		status.State = structs.CommitStatusFailure
+		status.Description = fmt.Sprintf("%s has %d validation error(s)", validation.Filename, len(validation.Errors))
+	} else if errors := validation.ErrorFindings(); len(errors) > 0 {
+		status.State = structs.CommitStatusFailure
+		status.Description = fmt.Sprintf("the files of %s have %d error(s)", validation.Filename, len(errors))
+	} else if warnings := validation.WarningFindings(); len(warnings) > 0 {
+		status.Description = fmt.Sprintf("%s is valid, its files have %d warning(s)", validation.Filename, len(warnings))
+	}
+	if release != nil {
+		status.TargetURL = fmt.Sprintf("%s/releases/tag/%s", repo.HTMLURL(), util.PathEscapeSegments(release.TagName))
+	} else {
+		status.TargetURL = fmt.Sprintf("%s/src/branch/%s/%s", repo.HTMLURL(), util.PathEscapeSegments(validation.Ref), validation.Filename)
+	}
+
+	creator, err := getValidationStatusCreator(repo, release)
+	if err != nil {
+		return err
+	}
+	return models.NewCommitStatus(models.NewCommitStatusOptions{
+		Repo:         repo,
+		Creator:      creator,
+		SHA:          validation.CommitSHA,
+		CommitStatus: status,
+	})
+}
+
+// getValidationStatusCreator returns the user a validation commit status is posted as, the publisher of the release, or
+// for a branch the owner of the repo, or a site admin if the owner is an org as commit statuses are made by users
+func getValidationStatusCreator(repo *models.Repository, release *models.Release) (*models.User, error) {
+	if release != nil {
+		if release.Publisher != nil {
+			return release.Publisher, nil
+		}
+		publisher, err := models.GetUserByID(release.PublisherID)
+		if err == nil {
+			return publisher, nil
+		} else if !models.IsErrUserNotExist(err) {
+			return nil, err
+		}
+	}
+	if !repo.Owner.IsOrganization() {
+		return repo.Owner, nil
+	}
+	return models.GetAdminUser()
+}
+
+// clearValidation deletes the validation of the ref of a repo's release or branch that no longer has a metadata file
+func clearValidation(repo *models.Repository, release *models.Release, ref string) error {
+	return models.DeleteDoor43MetadataValidation(repo.ID, getValidationRefType(release), ref)
+}
+
+// ValidateCommitMetadata validates the metadata file of a commit against its schema and lints the files it lists, as
+// is done before putting a release into the catalog, returning what keeps the commit out of the catalog or "" if nothing
+func ValidateCommitMetadata(commit *git.Commit) (string, error) {
+	format, metadata, err := DetectFormat(commit)
+	if err != nil {
+		return "", err
+	}
+	if format == nil {
+		return " * there is no metadata file, such as manifest.yaml", nil
+	}
+
+	result, err := format.Validate(metadata)
+	if err != nil {
+		return "", err
+	}
+	if !result.Valid() {
+		return base.StringifyValidationErrors(result), nil
+	}
+	if linter, ok := format.(Linter); ok {
+		findings, err := linter.Lint(commit, metadata)
+		if err != nil {
+			return "", err
+		}
+		var messages []string
+		for _, finding := range findings {
+			if finding.Severity == models.FindingSeverityError {
+				messages = append(messages, fmt.Sprintf(" * %s: %s", finding.Path, finding.Message))
+			}
+		}
+		return strings.Join(messages, "\n"), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package door43metadata
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordValidation(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	defer func(metadataCommitStatus bool) {
+		setting.DCS.MetadataCommitStatus = metadataCommitStatus
+	}(setting.DCS.MetadataCommitStatus)
+	setting.DCS.MetadataCommitStatus = true
+
+	const sha = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	for _, test := range []struct {
+		repoID    int64
+		release   *models.Release
+		creatorID int64
+	}{
+		// a branch of a user's repo is posted as the owner
+		{1, nil, 2},
+		// a release is posted as its publisher
+		{1, &models.Release{ID: 1, RepoID: 1, TagName: "v1.1", PublisherID: 4}, 4},
+		// a branch of an org's repo is posted as an admin, as the org can't be the creator
+		{3, nil, 1},
+	} {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: test.repoID}).(*models.Repository)
+		assert.NoError(t, repo.GetOwner())
+		ref := getValidationRef(repo, test.release)
+		validation := &models.Door43MetadataValidation{
+			RepoID:    repo.ID,
+			Ref:       ref,
+			RefType:   getValidationRefType(test.release),
+			CommitSHA: sha,
+			Filename:  "manifest.yaml",
+			IsValid:   true,
+		}
+		if test.release != nil {
+			validation.ReleaseID = test.release.ID
+		}
+		assert.NoError(t, recordValidation(repo, test.release, validation))
+
+		saved, err := models.GetDoor43MetadataValidation(repo.ID, validation.RefType, ref)
+		assert.NoError(t, err)
+		assert.True(t, saved.IsValid)
+		status := models.AssertExistsAndLoadBean(t, &models.CommitStatus{
+			RepoID:    repo.ID,
+			SHA:       sha,
+			Context:   models.Door43MetadataValidationStatusContext,
+			CreatorID: test.creatorID,
+		}).(*models.CommitStatus)
+		assert.Equal(t, structs.CommitStatusSuccess, status.State)
+	}
+
+	// the commit status is only posted again if the outcome changed
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	count := func() int {
+		return models.GetCount(t, &models.CommitStatus{RepoID: 1, SHA: sha})
+	}
+	before := count()
+	validation := &models.Door43MetadataValidation{RepoID: 1, Ref: repo.DefaultBranch, RefType: models.ValidationRefTypeBranch,
+		CommitSHA: sha, Filename: "manifest.yaml", IsValid: true}
+	assert.NoError(t, recordValidation(repo, nil, validation))
+	assert.Equal(t, before, count())
+	validation = &models.Door43MetadataValidation{RepoID: 1, Ref: repo.DefaultBranch, RefType: models.ValidationRefTypeBranch,
+		CommitSHA: sha, Filename: "manifest.yaml"}
+	assert.NoError(t, recordValidation(repo, nil, validation))
+	assert.Equal(t, before+1, count())
+}
@@ -36,6 +36,15 @@ func (a ArchiveType) String() string {
 
 // CreateArchive create archive content to the target path
 func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, target io.Writer, usePrefix bool, commitID string) error {
+	return repo.CreateArchiveOfPaths(ctx, format, target, usePrefix, commitID, nil) // DCS Customizations
+}
+
+/*** DCS Customizations ***/
+
+// CreateArchiveOfPaths create archive content of only the given paths of the commit to the target path, or of all of
+// it if there are none. All the paths must exist in the commit.
+func (repo *Repository) CreateArchiveOfPaths(ctx context.Context, format ArchiveType, target io.Writer, usePrefix bool, commitID string, paths []string) error {
+	/*** END DCS Customizations ***/
 	if format.String() == "unknown" {
 		return fmt.Errorf("unknown format: %v", format)
 	}
@@ -51,6 +60,13 @@ func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, t
 		"--format="+format.String(),
 		commitID,
 	)
+	/*** DCS Customizations - The paths are taken as they are, not as patterns ***/
+	if len(paths) > 0 {
+		args = append([]string{"--literal-pathspecs"}, args...)
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	/*** END DCS Customizations ***/
 
 	var stderr strings.Builder
 	err := NewCommandContext(ctx, args...).RunInDirPipeline(repo.Path, target, &stderr)
@@ -0,0 +1,46 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_CreateArchiveOfPaths(t *testing.T) {
+	bareRepo1Path := filepath.Join(testReposDir, "repo1_bare")
+	repo, err := OpenRepository(bareRepo1Path)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	archive := func(paths ...string) ([]string, error) {
+		var buf bytes.Buffer
+		if err := repo.CreateArchiveOfPaths(context.Background(), ZIP, &buf, false, "HEAD", paths); err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		return names, nil
+	}
+
+	names, err := archive("file1.txt", "foo/nar/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file1.txt", "foo/", "foo/nar/", "foo/nar/hello"}, names)
+
+	// the paths are not patterns
+	_, err = archive("file*.txt")
+	assert.Error(t, err)
+	_, err = archive(":(glob)**/hello")
+	assert.Error(t, err)
+}
@@ -36,7 +36,7 @@ func (Renderer) NeedPostProcess() bool { return false }
 
 // Extensions implements markup.Renderer
 func (Renderer) Extensions() []string {
-	return []string{".csv", ".tsv"}
+	return []string{".csv"} // DCS Customizations - .tsv files are rendered by modules/markup/tsv
 }
 
 // SanitizerRules implements markup.Renderer
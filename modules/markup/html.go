@@ -151,6 +151,7 @@ type processor func(ctx *RenderContext, node *html.Node)
 var defaultProcessors = []processor{
 	fullIssuePatternProcessor,
 	fullSha1PatternProcessor,
+	rcLinkProcessor, // DCS Customizations - before shortLinkProcessor, which would take [[rc://...]] for a wiki link
 	shortLinkProcessor,
 	linkProcessor,
 	mentionProcessor,
@@ -380,6 +381,15 @@ func visitNode(ctx *RenderContext, procs []processor, node *html.Node, visitText
 				node.Attr[i] = attr
 			}
 		} else if node.Data == "a" {
+			/*** DCS Customizations - rc:// links redirect to the files they link to ***/
+			for i, attr := range node.Attr {
+				if attr.Key == "href" && IsRCLink([]byte(attr.Val)) {
+					if u := RCLinkURL(ctx, attr.Val); u != "" {
+						node.Attr[i].Val = u
+					}
+				}
+			}
+			/*** END DCS Customizations ***/
 			visitText = false
 		} else if node.Data == "code" || node.Data == "pre" {
 			return
@@ -143,7 +143,12 @@ func (g *ASTTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 		case *ast.Link:
 			// Links need their href to munged to be a real value
 			link := v.Destination
-			if len(link) > 0 && !markup.IsLink(link) &&
+			/*** DCS Customizations - rc:// links go to the URL that redirects to the file they link to ***/
+			if markup.IsRCLink(link) {
+				if u := markup.RCLinkURL(&markup.RenderContext{Metas: pc.Get(renderMetasKey).(map[string]string)}, string(link)); u != "" {
+					link = []byte(u)
+				}
+			} else /*** END DCS Customizations ***/ if len(link) > 0 && !markup.IsLink(link) &&
 				link[0] != '#' && !bytes.HasPrefix(link, byteMailto) {
 				// special case: this is not a link, a hash link or a mailto:, so it's a
 				// relative URL
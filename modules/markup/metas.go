@@ -0,0 +1,11 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+// Metas keys a RenderContext can set to render a resource's files in the language of the resource
+const (
+	MetaLangDirection = "langDirection"
+	MetaLang          = "lang"
+)
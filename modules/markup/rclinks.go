@@ -0,0 +1,75 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"golang.org/x/net/html"
+)
+
+// rcLinkPattern matches the rc:// links in text, either plain or as [[rc://...]]
+var rcLinkPattern = regexp.MustCompile(`\[\[(` + dcs.RCLinkRegexp.String() + `)\]\]|` + dcs.RCLinkRegexp.String())
+
+// IsRCLink reports whether link is an rc:// link
+func IsRCLink(link []byte) bool {
+	return strings.HasPrefix(string(link), "rc://")
+}
+
+// RCLinkURL returns the URL that redirects to the file an rc:// link links to, "" if it is not a valid link. The *
+// language of a link is the language of the resource of the rendered file if it is known.
+func RCLinkURL(ctx *RenderContext, link string) string {
+	l := dcs.ParseRCLink(link)
+	if l == nil {
+		return ""
+	}
+	lang := l.Language
+	if lang == "*" && ctx != nil {
+		if ctx.Metas[MetaLang] != "" {
+			lang = ctx.Metas[MetaLang]
+		} else if parts := strings.SplitN(ctx.Metas["repo"], "_", 2); len(parts) == 2 {
+			lang = parts[0]
+		}
+	}
+	if lang != "*" {
+		lang = url.PathEscape(lang)
+	}
+	u := setting.AppSubURL + "/rc/" + lang + "/" + url.PathEscape(l.Resource)
+	if l.Type != "" {
+		u += "/" + url.PathEscape(l.Type)
+	}
+	if l.Path != "" {
+		u += "/" + util.PathEscapeSegments(l.Path)
+	}
+	return u
+}
+
+// rcLinkProcessor replaces rc:// links with links that redirect to the files they link to
+func rcLinkProcessor(ctx *RenderContext, node *html.Node) {
+	next := node.NextSibling
+	for node != nil && node != next {
+		m := rcLinkPattern.FindStringSubmatchIndex(node.Data)
+		if m == nil {
+			return
+		}
+
+		link := node.Data[m[0]:m[1]]
+		if m[2] >= 0 {
+			link = node.Data[m[2]:m[3]]
+		}
+		u := RCLinkURL(ctx, link)
+		if u == "" {
+			return
+		}
+		replaceContent(node, m[0], m[1], createLink(u, link, "rc-link"))
+		node = node.NextSibling.NextSibling
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup_test
+
+import (
+	"strings"
+	"testing"
+
+	. "code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_RCLinks(t *testing.T) {
+	setting.AppURL = AppURL
+	setting.AppSubURL = strings.TrimSuffix(AppSubURL, "/")
+
+	test := func(input, expected string) {
+		buffer, err := markdown.RenderString(&RenderContext{
+			URLPrefix: setting.AppSubURL,
+			Metas:     map[string]string{"user": "unfoldingWord", "repo": "fr_tn"},
+		}, input)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+	}
+
+	ta := setting.AppSubURL + "/rc/fr/ta/man/translate/figs-metaphor"
+	test("See [[rc://*/ta/man/translate/figs-metaphor]]",
+		`<p>See <a href="`+ta+`" rel="nofollow">rc://*/ta/man/translate/figs-metaphor</a></p>`)
+	test("See rc://*/ta/man/translate/figs-metaphor.",
+		`<p>See <a href="`+ta+`" rel="nofollow">rc://*/ta/man/translate/figs-metaphor</a>.</p>`)
+	test("[God](rc://en/tw/dict/bible/kt/god)",
+		`<p><a href="`+setting.AppSubURL+`/rc/en/tw/dict/bible/kt/god" rel="nofollow">God</a></p>`)
+	test("`rc://*/ta/man/translate/figs-metaphor`",
+		`<p><code>rc://*/ta/man/translate/figs-metaphor</code></p>`)
+
+	assert.Equal(t, setting.AppSubURL+"/rc/*/ult/book/gen", RCLinkURL(&RenderContext{}, "rc://*/ult/book/gen"))
+	assert.Equal(t, setting.AppSubURL+"/rc/hbo/uhb", RCLinkURL(&RenderContext{Metas: map[string]string{MetaLang: "hbo"}}, "rc://*/uhb"))
+	assert.Empty(t, RCLinkURL(&RenderContext{}, "rc://en"))
+}
@@ -0,0 +1,319 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tsv
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	csv_markup "code.gitea.io/gitea/modules/markup/csv"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+func init() {
+	markup.RegisterRenderer(Renderer{})
+}
+
+// Renderer implements markup.Renderer for tsv files, rendering translationHelps TSVs by their layout and any other tsv
+// file as a table like a csv file
+type Renderer struct {
+}
+
+// Name implements markup.Renderer
+func (Renderer) Name() string {
+	return "tsv"
+}
+
+// NeedPostProcess implements markup.Renderer
+func (Renderer) NeedPostProcess() bool { return false }
+
+// Extensions implements markup.Renderer
+func (Renderer) Extensions() []string {
+	return []string{".tsv"}
+}
+
+// SanitizerRules implements markup.Renderer
+func (Renderer) SanitizerRules() []setting.MarkupSanitizerRule {
+	classRegexp := regexp.MustCompile(`^tsv-helps(-[a-z]+)?( tsv-helps(-[a-z]+)?)*$`)
+	rules := csv_markup.Renderer{}.SanitizerRules()
+	for _, element := range []string{"div", "p", "h2", "table", "tr", "th", "td"} {
+		rules = append(rules, setting.MarkupSanitizerRule{Element: element, AllowAttr: "class", Regexp: classRegexp})
+	}
+	return rules
+}
+
+// layout is the columns of a kind of translationHelps TSV
+type layout struct {
+	name    string
+	columns []string
+	// markdownColumns are the columns with Markdown that can have rc:// links
+	markdownColumns []string
+	// linkColumns are the columns with an rc:// link
+	linkColumns []string
+	// quoteColumns are the columns quoting the original language text
+	quoteColumns []string
+}
+
+var layouts = []*layout{
+	{
+		name:            "tn",
+		columns:         []string{"Reference", "ID", "Tags", "SupportReference", "Quote", "Occurrence", "Note"},
+		markdownColumns: []string{"Note"},
+		linkColumns:     []string{"SupportReference"},
+		quoteColumns:    []string{"Quote"},
+	},
+	{
+		name:            "tn",
+		columns:         []string{"Book", "Chapter", "Verse", "ID", "SupportReference", "OrigQuote", "Occurrence", "GLQuote", "OccurrenceNote"},
+		markdownColumns: []string{"OccurrenceNote"},
+		linkColumns:     []string{"SupportReference"},
+		quoteColumns:    []string{"OrigQuote"},
+	},
+	{
+		name:            "tq",
+		columns:         []string{"Reference", "ID", "Tags", "Quote", "Occurrence", "Question", "Response"},
+		markdownColumns: []string{"Question", "Response"},
+		quoteColumns:    []string{"Quote"},
+	},
+	{
+		name:         "twl",
+		columns:      []string{"Reference", "ID", "Tags", "OrigWords", "Occurrence", "TWLink"},
+		linkColumns:  []string{"TWLink"},
+		quoteColumns: []string{"OrigWords"},
+	},
+}
+
+// getLayout returns the layout of the header, nil if it is not the header of a translationHelps TSV
+func getLayout(header []string) *layout {
+	for _, l := range layouts {
+		if len(header) != len(l.columns) {
+			continue
+		}
+		matches := true
+		for i, column := range l.columns {
+			if !strings.EqualFold(strings.TrimSpace(header[i]), column) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return l
+		}
+	}
+	return nil
+}
+
+// IsHelpsHeader returns true if the columns are the header of one of the layouts of translationHelps TSVs
+func IsHelpsHeader(header []string) bool {
+	return getLayout(header) != nil
+}
+
+func (l *layout) columnIndex(column string) int {
+	for i, c := range l.columns {
+		if c == column {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// reference returns the chapter and verse of a row, either from its Reference column of the form chapter:verse or
+// from its Chapter and Verse columns
+func (l *layout) reference(row []string) (chapter, verse string) {
+	if i := l.columnIndex("Reference"); i >= 0 {
+		parts := strings.SplitN(row[i], ":", 2)
+		if len(parts) == 2 {
+			return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		return strings.TrimSpace(parts[0]), ""
+	}
+	return strings.TrimSpace(row[l.columnIndex("Chapter")]), strings.TrimSpace(row[l.columnIndex("Verse")])
+}
+
+var (
+	anchorRegexp = regexp.MustCompile(`[^\w.-]+`)
+	// noteRCLinkRegexp matches the [[rc://...]] links and the plain rc:// links of a Markdown note
+	noteRCLinkRegexp  = regexp.MustCompile(`\[\[` + dcs.RCLinkRegexp.String() + `\]\]|` + dcs.RCLinkRegexp.String())
+	lineBreakReplacer = strings.NewReplacer(`\n`, "\n", "<br>", "\n", "<br/>", "\n", "<br />", "\n")
+)
+
+// anchorID returns the id of the anchor of a chapter or verse
+func anchorID(chapter, verse string) string {
+	id := "chapter-" + anchorRegexp.ReplaceAllString(chapter, "-")
+	if verse != "" {
+		id += "-verse-" + anchorRegexp.ReplaceAllString(verse, "-")
+	}
+	return id
+}
+
+// renderMarkdown renders a Markdown cell, with its escaped line breaks and rc:// links resolved
+func renderMarkdown(ctx *markup.RenderContext, text string) string {
+	text = lineBreakReplacer.Replace(text)
+	text = noteRCLinkRegexp.ReplaceAllStringFunc(text, func(match string) string {
+		link := strings.TrimSuffix(strings.TrimPrefix(match, "[["), "]]")
+		u := markup.RCLinkURL(ctx, link)
+		if u == "" {
+			return match
+		} else if strings.HasPrefix(match, "[[") {
+			return "[" + link + "](" + u + ")"
+		}
+		return u
+	})
+	rendered, err := markdown.RenderRawString(&markup.RenderContext{
+		Ctx:       ctx.Ctx,
+		URLPrefix: ctx.URLPrefix,
+		Metas:     ctx.Metas,
+		GitRepo:   ctx.GitRepo,
+	}, text)
+	if err != nil {
+		log.Error("Unable to render the Markdown of a TSV cell: %v", err)
+		return html.EscapeString(text)
+	}
+	return rendered
+}
+
+// renderCell renders a cell of the column of the layout
+func (l *layout) renderCell(ctx *markup.RenderContext, column, value string) string {
+	switch {
+	case contains(l.markdownColumns, column):
+		return `<td>` + renderMarkdown(ctx, value) + `</td>`
+	case contains(l.linkColumns, column):
+		if u := markup.RCLinkURL(ctx, strings.TrimSpace(value)); u != "" {
+			return `<td><a href="` + html.EscapeString(u) + `">` + html.EscapeString(value) + `</a></td>`
+		}
+	case contains(l.quoteColumns, column):
+		return `<td dir="auto">` + html.EscapeString(value) + `</td>`
+	}
+	return `<td>` + html.EscapeString(value) + `</td>`
+}
+
+// Render implements markup.Renderer
+func (Renderer) Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	rawBytes, err := ioutil.ReadAll(input)
+	if err != nil {
+		return err
+	}
+	if setting.UI.CSV.MaxFileSize != 0 && setting.UI.CSV.MaxFileSize < int64(len(rawBytes)) {
+		return csv_markup.Renderer{}.Render(ctx, bytes.NewReader(rawBytes), output)
+	}
+
+	lines := strings.Split(strings.TrimPrefix(string(rawBytes), "\ufeff"), "\n")
+	header := strings.Split(strings.TrimRight(lines[0], "\r"), "\t")
+	l := getLayout(header)
+	if l == nil {
+		return csv_markup.Renderer{}.Render(ctx, bytes.NewReader(rawBytes), output)
+	}
+
+	var rows [][]string
+	var chapters []string
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		row := strings.Split(line, "\t")
+		for len(row) < len(l.columns) {
+			row = append(row, "")
+		}
+		if chapter, _ := l.reference(row); len(chapters) == 0 || chapters[len(chapters)-1] != chapter {
+			chapters = append(chapters, chapter)
+		}
+		rows = append(rows, row)
+	}
+
+	tmpBlock := bufio.NewWriter(output)
+	if _, err := tmpBlock.WriteString(`<div class="tsv-helps tsv-helps-` + l.name + `">`); err != nil {
+		return err
+	}
+	if len(chapters) > 1 {
+		if _, err := tmpBlock.WriteString(`<p class="tsv-helps-nav">`); err != nil {
+			return err
+		}
+		for i, chapter := range chapters {
+			if i > 0 {
+				if _, err := tmpBlock.WriteString(" "); err != nil {
+					return err
+				}
+			}
+			if _, err := tmpBlock.WriteString(`<a href="#` + anchorID(chapter, "") + `">` + html.EscapeString(chapter) + `</a>`); err != nil {
+				return err
+			}
+		}
+		if _, err := tmpBlock.WriteString(`</p>`); err != nil {
+			return err
+		}
+	}
+
+	var lastChapter, lastVerse string
+	for i, row := range rows {
+		chapter, verse := l.reference(row)
+		if i == 0 || chapter != lastChapter {
+			if i > 0 {
+				if _, err := tmpBlock.WriteString(`</tbody></table>`); err != nil {
+					return err
+				}
+			}
+			if _, err := tmpBlock.WriteString(`<h2 id="user-content-` + anchorID(chapter, "") + `">` + html.EscapeString(chapter) + `</h2>`); err != nil {
+				return err
+			}
+			if _, err := tmpBlock.WriteString(`<table class="tsv-helps-table"><thead><tr>`); err != nil {
+				return err
+			}
+			for _, column := range l.columns {
+				if _, err := tmpBlock.WriteString(`<th>` + html.EscapeString(column) + `</th>`); err != nil {
+					return err
+				}
+			}
+			if _, err := tmpBlock.WriteString(`</tr></thead><tbody>`); err != nil {
+				return err
+			}
+			lastVerse = ""
+		}
+		if verse != "" && (verse != lastVerse || chapter != lastChapter) {
+			_, err = tmpBlock.WriteString(`<tr id="user-content-` + anchorID(chapter, verse) + `">`)
+		} else {
+			_, err = tmpBlock.WriteString(`<tr>`)
+		}
+		if err != nil {
+			return err
+		}
+		for j, column := range l.columns {
+			if _, err := tmpBlock.WriteString(l.renderCell(ctx, column, row[j])); err != nil {
+				return err
+			}
+		}
+		if _, err := tmpBlock.WriteString(`</tr>`); err != nil {
+			return err
+		}
+		lastChapter, lastVerse = chapter, verse
+	}
+	if len(rows) > 0 {
+		if _, err := tmpBlock.WriteString(`</tbody></table>`); err != nil {
+			return err
+		}
+	}
+	if _, err := tmpBlock.WriteString(`</div>`); err != nil {
+		return err
+	}
+	return tmpBlock.Flush()
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tsv
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTSV(t *testing.T) {
+	var render Renderer
+	ctx := &markup.RenderContext{Metas: map[string]string{"user": "unfoldingWord", "repo": "en_tn"}}
+	var kases = map[string]string{
+		"a\tb\n1\t2": "<table class=\"data-table\"><tr><th class=\"line-num\">1</th><th>a</th><th>b</th></tr><tr><td class=\"line-num\">2</td><td>1</td><td>2</td></tr></table>",
+		"Reference\tID\tTags\tSupportReference\tQuote\tOccurrence\tNote\n" +
+			"1:1\tab12\t\trc://*/ta/man/translate/figs-metaphor\tבְּ\t1\tSee: [[rc://*/ta/man/translate/figs-metaphor]]\\nMore\n" +
+			"1:1\tcd34\t\t\t\t0\t**Bold**\n" +
+			"2:intro\tef56\t\t\t\t0\tIntro": `<div class="tsv-helps tsv-helps-tn">` +
+			`<p class="tsv-helps-nav"><a href="#chapter-1">1</a> <a href="#chapter-2">2</a></p>` +
+			`<h2 id="user-content-chapter-1">1</h2><table class="tsv-helps-table"><thead><tr><th>Reference</th><th>ID</th><th>Tags</th><th>SupportReference</th><th>Quote</th><th>Occurrence</th><th>Note</th></tr></thead><tbody>` +
+			`<tr id="user-content-chapter-1-verse-1"><td>1:1</td><td>ab12</td><td></td>` +
+			`<td><a href="/rc/en/ta/man/translate/figs-metaphor">rc://*/ta/man/translate/figs-metaphor</a></td>` +
+			`<td dir="auto">בְּ</td><td>1</td>` +
+			`<td><p>See: <a href="/rc/en/ta/man/translate/figs-metaphor" rel="nofollow">rc://*/ta/man/translate/figs-metaphor</a><br>` + "\n" + `More</p>` + "\n" + `</td></tr>` +
+			`<tr><td>1:1</td><td>cd34</td><td></td><td></td><td dir="auto"></td><td>0</td><td><p><strong>Bold</strong></p>` + "\n" + `</td></tr>` +
+			`</tbody></table>` +
+			`<h2 id="user-content-chapter-2">2</h2><table class="tsv-helps-table"><thead><tr><th>Reference</th><th>ID</th><th>Tags</th><th>SupportReference</th><th>Quote</th><th>Occurrence</th><th>Note</th></tr></thead><tbody>` +
+			`<tr id="user-content-chapter-2-verse-intro"><td>2:intro</td><td>ef56</td><td></td><td></td><td dir="auto"></td><td>0</td><td><p>Intro</p>` + "\n" + `</td></tr>` +
+			`</tbody></table></div>`,
+		"Reference\tID\tTags\tOrigWords\tOccurrence\tTWLink\n" +
+			"1:3\tx1\tkeyterm\tאֱלֹהִ֔ים\t1\trc://*/tw/dict/bible/kt/god": `<div class="tsv-helps tsv-helps-twl">` +
+			`<h2 id="user-content-chapter-1">1</h2><table class="tsv-helps-table"><thead><tr><th>Reference</th><th>ID</th><th>Tags</th><th>OrigWords</th><th>Occurrence</th><th>TWLink</th></tr></thead><tbody>` +
+			`<tr id="user-content-chapter-1-verse-3"><td>1:3</td><td>x1</td><td>keyterm</td><td dir="auto">אֱלֹהִ֔ים</td><td>1</td>` +
+			`<td><a href="/rc/en/tw/dict/bible/kt/god">rc://*/tw/dict/bible/kt/god</a></td></tr>` +
+			`</tbody></table></div>`,
+	}
+
+	for k, v := range kases {
+		var buf strings.Builder
+		err := render.Render(ctx, strings.NewReader(k), &buf)
+		assert.NoError(t, err)
+		assert.EqualValues(t, v, buf.String())
+	}
+}
@@ -0,0 +1,610 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package usfm
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+func init() {
+	markup.RegisterRenderer(Renderer{})
+}
+
+// Renderer implements markup.Renderer for USFM (Unified Standard Format Markers) Bible texts
+type Renderer struct {
+}
+
+// Name implements markup.Renderer
+func (Renderer) Name() string {
+	return "usfm"
+}
+
+// NeedPostProcess implements markup.Renderer
+func (Renderer) NeedPostProcess() bool { return false }
+
+// Extensions implements markup.Renderer
+func (Renderer) Extensions() []string {
+	return []string{".usfm", ".sfm"}
+}
+
+// SanitizerRules implements markup.Renderer
+func (Renderer) SanitizerRules() []setting.MarkupSanitizerRule {
+	classRegexp := regexp.MustCompile(`^usfm(-[a-z0-9]+)?$`)
+	rules := []setting.MarkupSanitizerRule{
+		{Element: "span", AllowAttr: "data-lemma"},
+		{Element: "span", AllowAttr: "data-strong"},
+	}
+	for _, element := range []string{"div", "p", "span", "sup", "h2", "a", "ol", "li", "table", "tr", "th", "td"} {
+		rules = append(rules, setting.MarkupSanitizerRule{Element: element, AllowAttr: "class", Regexp: classRegexp})
+	}
+	return rules
+}
+
+var (
+	// paragraphMarkers start a paragraph, poetry line or list item, without a trailing level number
+	paragraphMarkers = toSet("p", "m", "po", "pr", "cls", "pmo", "pm", "pmc", "pmr", "pi", "mi", "nb", "pc", "ph",
+		"q", "qr", "qc", "qa", "qm", "qd", "lh", "li", "lf", "lim", "d",
+		"ip", "ipi", "im", "imi", "ipq", "imq", "ipr", "iq", "ili", "io", "iot", "ie")
+	// headingMarkers start a title, section heading or reference line
+	headingMarkers = toSet("mt", "mte", "ms", "mr", "s", "sr", "r", "sp", "sd", "imt", "imte", "is", "iex", "cd")
+	// skippedMarkers are identification and comment markers whose text up to the next marker is not shown
+	skippedMarkers = toSet("id", "ide", "usfm", "h", "toc", "toca", "rem", "sts", "restore", "esb", "esbe")
+	// skippedCharMarkers are character markers whose text up to their end marker is not shown
+	skippedCharMarkers = toSet("fig", "va", "vp", "ca", "cat", "ndx")
+	// charTags are the HTML elements of the character markers that have one, other character markers are spans
+	charTags = map[string]string{"bd": "b", "it": "i", "em": "em", "sup": "sup"}
+	// noteMarkers start a footnote or a cross reference
+	noteMarkers = toSet("f", "fe", "ef", "x", "ex")
+	// cellMarkers start a table cell
+	cellMarkers = toSet("th", "thr", "thc", "tc", "tcr", "tcc")
+
+	attributeRegexp  = regexp.MustCompile(`([\w-]+)\s*=\s*"([^"]*)"`)
+	whitespaceRegexp = regexp.MustCompile(`\s+`)
+)
+
+func toSet(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// token is a marker with its number argument for chapters and verses, or text if marker is empty
+type token struct {
+	marker string
+	end    bool
+	arg    string
+	text   string
+}
+
+// tokenize splits USFM into its markers and the text between them
+func tokenize(usfm string) []*token {
+	tokens := make([]*token, 0, len(usfm)/8)
+	runes := []rune(usfm)
+	for i := 0; i < len(runes); {
+		if runes[i] != '\\' {
+			start := i
+			for i < len(runes) && runes[i] != '\\' {
+				i++
+			}
+			tokens = append(tokens, &token{text: string(runes[start:i])})
+			continue
+		}
+		i++
+		start := i
+		for i < len(runes) && (runes[i] == '+' || runes[i] == '-' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			i++
+		}
+		t := &token{marker: strings.TrimPrefix(string(runes[start:i]), "+")}
+		if i < len(runes) && runes[i] == '*' {
+			t.end = true
+			i++
+		} else if i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+			if t.marker == "c" || t.marker == "v" {
+				for i < len(runes) && unicode.IsSpace(runes[i]) {
+					i++
+				}
+				start = i
+				for i < len(runes) && runes[i] != '\\' && !unicode.IsSpace(runes[i]) {
+					i++
+				}
+				t.arg = string(runes[start:i])
+				if i < len(runes) && unicode.IsSpace(runes[i]) {
+					i++
+				}
+			}
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// baseMarker returns the marker without its level number, such as q for q2
+func baseMarker(marker string) string {
+	return strings.TrimRight(marker, "0123456789")
+}
+
+// isMilestone returns whether the marker is a milestone, such as the zaln-s and zaln-e alignment milestones
+func isMilestone(marker string) bool {
+	base := baseMarker(marker)
+	return strings.HasSuffix(base, "-s") || strings.HasSuffix(base, "-e") || base == "ts"
+}
+
+// parseAttributes parses the attributes of a word or milestone, where a value without a name is the lemma
+func parseAttributes(attributes string) map[string]string {
+	attrs := make(map[string]string)
+	attributes = strings.TrimSpace(attributes)
+	if attributes == "" {
+		return attrs
+	}
+	if !strings.Contains(attributes, "=") {
+		attrs["lemma"] = attributes
+		return attrs
+	}
+	for _, match := range attributeRegexp.FindAllStringSubmatch(attributes, -1) {
+		attrs[strings.TrimPrefix(match[1], "x-")] = match[2]
+	}
+	return attrs
+}
+
+type span struct {
+	marker string
+	close  string
+}
+
+type note struct {
+	marker string
+	buf    bytes.Buffer
+}
+
+type htmlRenderer struct {
+	out *bytes.Buffer
+	// buf is where text is written, the output or the content of the current note
+	buf   *bytes.Buffer
+	spans []*span
+	// block is the closing tag of the open paragraph or heading
+	block      string
+	inTable    bool
+	cell       string
+	notes      []*note
+	note       *note
+	readCaller bool
+	skipping   bool
+	// skipUntil is the marker whose end marker ends the text not being shown, "" for the next marker
+	skipUntil       string
+	chapter         string
+	chapterSet      bool
+	chapterRendered bool
+	// allChaptersLabel is the \cl label of all chapters, given before the first chapter
+	allChaptersLabel string
+	// chapterLabel is the \cl label of the current chapter, given after its \c marker
+	chapterLabel string
+	label        *strings.Builder
+	word         *strings.Builder
+	// milestone is the marker of the milestone whose attributes are being read
+	milestone      string
+	milestoneAttrs *strings.Builder
+	// alignments are the attributes of the zaln-s milestones of the original language words the text is aligned to
+	alignments []map[string]string
+}
+
+// Render renders USFM to HTML
+func Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	rawBytes, err := ioutil.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	r := &htmlRenderer{out: &bytes.Buffer{}}
+	r.buf = r.out
+	for _, t := range tokenize(string(rawBytes)) {
+		r.render(t)
+	}
+	r.closeBlock()
+	r.flushChapter()
+	r.writeNotes()
+
+	if _, err := io.WriteString(output, `<div class="usfm"`); err != nil {
+		return err
+	}
+	if ctx != nil && ctx.Metas != nil {
+		if dir := ctx.Metas[markup.MetaLangDirection]; dir == "rtl" || dir == "ltr" {
+			if _, err := io.WriteString(output, ` dir="`+dir+`"`); err != nil {
+				return err
+			}
+		}
+		if lang := ctx.Metas[markup.MetaLang]; lang != "" {
+			if _, err := io.WriteString(output, ` lang="`+html.EscapeString(lang)+`"`); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(output, ">"); err != nil {
+		return err
+	}
+	if _, err := r.out.WriteTo(output); err != nil {
+		return err
+	}
+	_, err = io.WriteString(output, "</div>")
+	return err
+}
+
+// Render implements markup.Renderer
+func (Renderer) Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	return Render(ctx, input, output)
+}
+
+func (r *htmlRenderer) render(t *token) {
+	if t.marker == "" && !t.end {
+		r.renderText(t.text)
+		return
+	}
+
+	if r.milestone != "" {
+		r.closeMilestone()
+		if t.end && (t.marker == "" || t.marker == r.milestone) {
+			return
+		}
+	}
+	if r.skipping {
+		if r.skipUntil == "" || (t.end && t.marker == r.skipUntil) {
+			r.skipping = false
+		}
+		if r.skipUntil != "" {
+			return
+		}
+	}
+	if r.label != nil {
+		if r.chapterSet {
+			r.chapterLabel = strings.TrimSpace(r.label.String())
+		} else {
+			r.allChaptersLabel = strings.TrimSpace(r.label.String())
+		}
+		r.label = nil
+	}
+
+	marker := t.marker
+	base := baseMarker(marker)
+	switch {
+	case t.end && marker == "":
+		// the end of a milestone that was not skipped, such as \zaln-e\*
+	case isMilestone(marker):
+		r.renderMilestone(marker)
+	case t.end:
+		r.renderEndMarker(marker)
+	case marker == "c":
+		r.closeBlock()
+		r.flushChapter()
+		r.chapter, r.chapterSet, r.chapterRendered, r.chapterLabel = t.arg, true, false, ""
+	case marker == "cl":
+		r.closeBlock()
+		r.label = &strings.Builder{}
+	case marker == "v":
+		r.renderVerse(t.arg)
+	case skippedMarkers[base]:
+		r.closeBlock()
+		r.skipping, r.skipUntil = true, ""
+	case skippedCharMarkers[base]:
+		r.skipping, r.skipUntil = true, marker
+	case marker == "b":
+		r.flushChapter()
+		r.closeBlock()
+		r.out.WriteString("<br>")
+	case paragraphMarkers[base]:
+		r.flushChapter()
+		r.openBlock("p", marker)
+	case headingMarkers[base]:
+		r.flushChapter()
+		r.openBlock("div", marker)
+	case marker == "tr":
+		r.flushChapter()
+		r.renderRow()
+	case cellMarkers[base]:
+		r.renderCell(marker)
+	case noteMarkers[marker]:
+		r.openNote(marker)
+	case r.note != nil && (strings.HasPrefix(marker, "f") || strings.HasPrefix(marker, "x")):
+		r.closeSpansTo(r.note.marker, false)
+		r.openSpan(marker)
+	case marker == "w":
+		r.word = &strings.Builder{}
+	default:
+		r.openSpan(marker)
+	}
+}
+
+func (r *htmlRenderer) renderText(text string) {
+	if r.milestone != "" {
+		r.milestoneAttrs.WriteString(text)
+		return
+	}
+	if r.skipping {
+		return
+	}
+	text = whitespaceRegexp.ReplaceAllString(text, " ")
+	if r.label != nil {
+		r.label.WriteString(text)
+		return
+	}
+	if r.word != nil {
+		r.word.WriteString(text)
+		return
+	}
+	if r.readCaller {
+		// The caller (+, - or a character) of a note is replaced by the note's number
+		r.readCaller = false
+		text = strings.TrimLeft(text, " ")
+		if idx := strings.IndexAny(text, " "); idx >= 0 {
+			text = text[idx+1:]
+		} else {
+			text = ""
+		}
+	}
+	if len(r.spans) > 0 {
+		// the attributes of character markers are at the end of their text
+		if idx := strings.Index(text, "|"); idx >= 0 {
+			text = text[:idx]
+		}
+	}
+	if strings.TrimSpace(text) == "" && r.block == "" && r.note == nil {
+		return
+	}
+	r.flushChapter()
+	if r.block == "" && r.note == nil && !r.inTable {
+		r.openBlock("p", "p")
+	}
+	r.buf.WriteString(html.EscapeString(text))
+}
+
+func (r *htmlRenderer) openBlock(element, marker string) {
+	r.closeBlock()
+	r.buf.WriteString(`<` + element + ` class="usfm-` + marker + `">`)
+	r.block = `</` + element + `>`
+}
+
+func (r *htmlRenderer) closeBlock() {
+	r.closeNote()
+	r.closeSpans()
+	r.word = nil
+	if r.cell != "" {
+		r.out.WriteString(r.cell)
+		r.cell = ""
+	}
+	if r.inTable {
+		r.out.WriteString("</tr></table>")
+		r.inTable = false
+	}
+	r.out.WriteString(r.block)
+	r.block = ""
+}
+
+func (r *htmlRenderer) flushChapter() {
+	if !r.chapterSet || r.chapterRendered {
+		return
+	}
+	r.chapterRendered = true
+	label := r.chapter
+	if r.chapterLabel != "" {
+		label = r.chapterLabel
+	} else if r.allChaptersLabel != "" {
+		label = r.allChaptersLabel + " " + r.chapter
+	}
+	r.out.WriteString(`<h2 class="usfm-c" id="user-content-chapter-` + html.EscapeString(r.chapter) + `">` + html.EscapeString(label) + `</h2>`)
+}
+
+func (r *htmlRenderer) renderVerse(verse string) {
+	r.closeNote()
+	r.closeSpans()
+	r.flushChapter()
+	if r.block == "" && !r.inTable {
+		r.openBlock("p", "p")
+	}
+	id := "verse-" + verse
+	if r.chapterSet {
+		id = "chapter-" + r.chapter + "-" + id
+	}
+	r.buf.WriteString(`<sup class="usfm-v" id="user-content-` + html.EscapeString(id) + `">` + html.EscapeString(verse) + `</sup>`)
+}
+
+func (r *htmlRenderer) renderRow() {
+	if r.inTable {
+		r.closeNote()
+		r.closeSpans()
+		if r.cell != "" {
+			r.out.WriteString(r.cell)
+			r.cell = ""
+		}
+		r.out.WriteString("</tr><tr>")
+		return
+	}
+	r.closeBlock()
+	r.out.WriteString(`<table class="usfm-table"><tr>`)
+	r.inTable = true
+}
+
+func (r *htmlRenderer) renderCell(marker string) {
+	if !r.inTable {
+		r.renderRow()
+	}
+	r.closeNote()
+	r.closeSpans()
+	if r.cell != "" {
+		r.out.WriteString(r.cell)
+	}
+	element := "td"
+	if strings.HasPrefix(marker, "th") {
+		element = "th"
+	}
+	r.out.WriteString(`<` + element + ` class="usfm-` + marker + `">`)
+	r.cell = `</` + element + `>`
+}
+
+func (r *htmlRenderer) renderMilestone(marker string) {
+	if baseMarker(marker) == "zaln-e" {
+		if len(r.alignments) > 0 {
+			r.alignments = r.alignments[:len(r.alignments)-1]
+		}
+		return
+	}
+	r.milestone, r.milestoneAttrs = marker, &strings.Builder{}
+}
+
+// closeMilestone ends reading the attributes of the milestone, keeping those of an alignment for the words aligned to it
+func (r *htmlRenderer) closeMilestone() {
+	if baseMarker(r.milestone) == "zaln-s" {
+		attrs := r.milestoneAttrs.String()
+		if idx := strings.Index(attrs, "|"); idx >= 0 {
+			attrs = attrs[idx+1:]
+		}
+		r.alignments = append(r.alignments, parseAttributes(attrs))
+	}
+	r.milestone, r.milestoneAttrs = "", nil
+}
+
+func (r *htmlRenderer) renderEndMarker(marker string) {
+	switch {
+	case marker == "w":
+		r.renderWord()
+	case noteMarkers[marker]:
+		r.closeNote()
+	case marker == "cl":
+	default:
+		r.closeSpansTo(marker, true)
+	}
+}
+
+func (r *htmlRenderer) renderWord() {
+	if r.word == nil {
+		return
+	}
+	text := r.word.String()
+	r.word = nil
+	attrs := make(map[string]string)
+	if idx := strings.Index(text, "|"); idx >= 0 {
+		attrs = parseAttributes(text[idx+1:])
+		text = text[:idx]
+	}
+	if attrs["lemma"] == "" && attrs["strong"] == "" {
+		// the lemmas and Strong's numbers of aligned text are those of the original language words it is aligned to
+		var lemmas, strongs []string
+		for _, alignment := range r.alignments {
+			if alignment["lemma"] != "" {
+				lemmas = append(lemmas, alignment["lemma"])
+			}
+			if alignment["strong"] != "" {
+				strongs = append(strongs, alignment["strong"])
+			}
+		}
+		attrs["lemma"], attrs["strong"] = strings.Join(lemmas, " "), strings.Join(strongs, " ")
+	}
+
+	r.flushChapter()
+	if r.block == "" && r.note == nil && !r.inTable {
+		r.openBlock("p", "p")
+	}
+	if attrs["lemma"] == "" && attrs["strong"] == "" {
+		r.buf.WriteString(html.EscapeString(text))
+		return
+	}
+	r.buf.WriteString(`<span class="usfm-w"`)
+	if attrs["lemma"] != "" {
+		r.buf.WriteString(` data-lemma="` + html.EscapeString(attrs["lemma"]) + `"`)
+	}
+	if attrs["strong"] != "" {
+		r.buf.WriteString(` data-strong="` + html.EscapeString(attrs["strong"]) + `"`)
+	}
+	r.buf.WriteString(` title="` + html.EscapeString(strings.TrimSpace(attrs["strong"]+" "+attrs["lemma"])) + `">`)
+	r.buf.WriteString(html.EscapeString(text) + `</span>`)
+}
+
+func (r *htmlRenderer) openSpan(marker string) {
+	r.flushChapter()
+	if r.block == "" && r.note == nil && !r.inTable {
+		r.openBlock("p", "p")
+	}
+	if tag, ok := charTags[marker]; ok {
+		r.buf.WriteString(`<` + tag + `>`)
+		r.spans = append(r.spans, &span{marker: marker, close: `</` + tag + `>`})
+		return
+	}
+	r.buf.WriteString(`<span class="usfm-` + baseMarker(marker) + `">`)
+	r.spans = append(r.spans, &span{marker: marker, close: `</span>`})
+}
+
+// closeSpansTo closes the open character spans down to the span of the marker, including it if inclusive
+func (r *htmlRenderer) closeSpansTo(marker string, inclusive bool) {
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		if r.spans[i].marker != marker {
+			continue
+		}
+		if !inclusive {
+			i++
+		}
+		for j := len(r.spans) - 1; j >= i; j-- {
+			r.buf.WriteString(r.spans[j].close)
+		}
+		r.spans = r.spans[:i]
+		return
+	}
+}
+
+func (r *htmlRenderer) closeSpans() {
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		r.buf.WriteString(r.spans[i].close)
+	}
+	r.spans = r.spans[:0]
+}
+
+func (r *htmlRenderer) openNote(marker string) {
+	r.closeNote()
+	r.flushChapter()
+	if r.block == "" && !r.inTable {
+		r.openBlock("p", "p")
+	}
+	r.note = &note{marker: marker}
+	r.notes = append(r.notes, r.note)
+	n := strconv.Itoa(len(r.notes))
+	r.buf.WriteString(`<sup class="usfm-` + marker + `"><a href="#note-` + n + `" id="user-content-noteref-` + n + `">` + n + `</a></sup>`)
+	r.buf = &r.note.buf
+	// the note is its own span so its content spans can be closed without closing the spans around it
+	r.spans = append(r.spans, &span{marker: marker})
+	r.readCaller = true
+}
+
+func (r *htmlRenderer) closeNote() {
+	if r.note == nil {
+		return
+	}
+	r.closeSpansTo(r.note.marker, true)
+	r.buf = r.out
+	r.note = nil
+	r.readCaller = false
+}
+
+func (r *htmlRenderer) writeNotes() {
+	if len(r.notes) == 0 {
+		return
+	}
+	r.out.WriteString(`<ol class="usfm-notes">`)
+	for i, note := range r.notes {
+		n := strconv.Itoa(i + 1)
+		r.out.WriteString(`<li class="usfm-` + note.marker + `" id="user-content-note-` + n + `">`)
+		r.out.WriteString(strings.TrimSpace(note.buf.String()))
+		r.out.WriteString(` <a href="#noteref-` + n + `">↩</a></li>`)
+	}
+	r.out.WriteString(`</ol>`)
+}
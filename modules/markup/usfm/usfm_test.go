@@ -0,0 +1,61 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package usfm
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderUSFM(t *testing.T) {
+	var render Renderer
+	var kases = map[string]string{
+		"\\id GEN EN_ULT\n\\h Genesis\n\\mt1 Genesis\n\\c 1\n\\p\n\\v 1 In the beginning\n\\v 2 The earth": `<div class="usfm">` +
+			`<div class="usfm-mt1">Genesis </div>` +
+			`<h2 class="usfm-c" id="user-content-chapter-1">1</h2>` +
+			`<p class="usfm-p"><sup class="usfm-v" id="user-content-chapter-1-verse-1">1</sup>In the beginning ` +
+			`<sup class="usfm-v" id="user-content-chapter-1-verse-2">2</sup>The earth</p></div>`,
+		"\\cl Psalm\n\\c 3\n\\s1 A <psalm>\n\\q1\n\\v 1 \\bd Yahweh\\bd*": `<div class="usfm">` +
+			`<h2 class="usfm-c" id="user-content-chapter-3">Psalm 3</h2>` +
+			`<div class="usfm-s1">A &lt;psalm&gt; </div>` +
+			`<p class="usfm-q1"><sup class="usfm-v" id="user-content-chapter-3-verse-1">1</sup><b>Yahweh</b></p></div>`,
+		"\\c 1\n\\p\n\\v 1 Paul\\f + \\fr 1:1 \\ft A servant\\f*, a servant\\x - \\xo 1:1 \\xt Acts 9:15\\x*": `<div class="usfm">` +
+			`<h2 class="usfm-c" id="user-content-chapter-1">1</h2>` +
+			`<p class="usfm-p"><sup class="usfm-v" id="user-content-chapter-1-verse-1">1</sup>Paul` +
+			`<sup class="usfm-f"><a href="#note-1" id="user-content-noteref-1">1</a></sup>, a servant` +
+			`<sup class="usfm-x"><a href="#note-2" id="user-content-noteref-2">2</a></sup></p>` +
+			`<ol class="usfm-notes">` +
+			`<li class="usfm-f" id="user-content-note-1"><span class="usfm-fr">1:1 </span><span class="usfm-ft">A servant</span> <a href="#noteref-1">↩</a></li>` +
+			`<li class="usfm-x" id="user-content-note-2"><span class="usfm-xo">1:1 </span><span class="usfm-xt">Acts 9:15</span> <a href="#noteref-2">↩</a></li>` +
+			`</ol></div>`,
+		"\\v 1 \\w בְּ|lemma=\"בְּ\" strong=\"b:H9003\"\\w*\\w רֵאשִׁ֖ית|reshit\\w*": `<div class="usfm">` +
+			`<p class="usfm-p"><sup class="usfm-v" id="user-content-verse-1">1</sup>` +
+			`<span class="usfm-w" data-lemma="בְּ" data-strong="b:H9003" title="b:H9003 בְּ">בְּ</span>` +
+			`<span class="usfm-w" data-lemma="reshit" title="reshit">רֵאשִׁ֖ית</span></p></div>`,
+		"\\v 1 \\zaln-s |x-strong=\"G39720\" x-lemma=\"Παῦλος\" x-occurrence=\"1\"\\*\\w Paul|x-occurrence=\"1\"\\w*\\zaln-e\\* \\w a|x-occurrence=\"1\"\\w*": `<div class="usfm">` +
+			`<p class="usfm-p"><sup class="usfm-v" id="user-content-verse-1">1</sup>` +
+			`<span class="usfm-w" data-lemma="Παῦλος" data-strong="G39720" title="G39720 Παῦλος">Paul</span> a</p></div>`,
+		"\\tr \\th1 Tribe \\thr2 Number\n\\tr \\tc1 Judah \\tcr2 74,600\n\\p Text": `<div class="usfm">` +
+			`<table class="usfm-table"><tr><th class="usfm-th1">Tribe </th><th class="usfm-thr2">Number </th></tr>` +
+			`<tr><td class="usfm-tc1">Judah </td><td class="usfm-tcr2">74,600 </td></tr></table>` +
+			`<p class="usfm-p">Text</p></div>`,
+	}
+
+	for k, v := range kases {
+		var buf strings.Builder
+		err := render.Render(&markup.RenderContext{}, strings.NewReader(k), &buf)
+		assert.NoError(t, err)
+		assert.EqualValues(t, v, buf.String())
+	}
+
+	var buf strings.Builder
+	err := render.Render(&markup.RenderContext{Metas: map[string]string{markup.MetaLangDirection: "rtl", markup.MetaLang: "hbo"}}, strings.NewReader("\\p שָׁלוֹם"), &buf)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `<div class="usfm" dir="rtl" lang="hbo"><p class="usfm-p">שָׁלוֹם</p></div>`, buf.String())
+}
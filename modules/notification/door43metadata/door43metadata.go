@@ -30,60 +30,77 @@ func NewNotifier() base.Notifier {
 
 func (m *metadataNotifier) NotifyNewRelease(rel *models.Release) {
 	if !rel.IsTag {
-		if err := door43metadata.ProcessDoor43MetadataForRepoRelease(rel.Repo, rel); err != nil {
-			log.Error("ProcessDoor43MetadataForRepoRelease: %v\n", err)
+		if err := door43metadata.QueueDoor43MetadataForRepoRelease(rel.Repo, rel); err != nil {
+			log.Error("QueueDoor43MetadataForRepoRelease: %v\n", err)
 		}
 	}
 }
 
 func (m *metadataNotifier) NotifyUpdateRelease(doer *models.User, rel *models.Release) {
 	if !rel.IsTag {
-		if err := door43metadata.ProcessDoor43MetadataForRepoRelease(rel.Repo, rel); err != nil {
-			log.Error("ProcessDoor43MetadataForRepoRelease: %v\n", err)
+		if err := door43metadata.QueueDoor43MetadataForRepoRelease(rel.Repo, rel); err != nil {
+			log.Error("QueueDoor43MetadataForRepoRelease: %v\n", err)
 		}
 	}
 }
 
 func (m *metadataNotifier) NotifyDeleteRelease(doer *models.User, rel *models.Release) {
-	if err := models.DeleteDoor43MetadataByRelease(rel); err != nil {
+	if err := door43metadata.DeleteDoor43MetadataOfRelease(rel); err != nil {
 		log.Error("ProcessDoor43MetadataForRepoRelease: %v\n", err)
 	}
 }
 
 func (m *metadataNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, opts *repository.PushUpdateOptions, commits *repository.PushCommits) {
-	if strings.HasPrefix(opts.RefFullName, git.BranchPrefix) && strings.TrimPrefix(opts.RefFullName, git.BranchPrefix) == repo.DefaultBranch {
-		if err := door43metadata.ProcessDoor43MetadataForRepoRelease(repo, nil); err != nil {
-			log.Info("ProcessDoor43MetadataForRepoRelease: %v\n", err)
+	if !strings.HasPrefix(opts.RefFullName, git.BranchPrefix) {
+		return
+	}
+	branch := strings.TrimPrefix(opts.RefFullName, git.BranchPrefix)
+	if branch == repo.DefaultBranch {
+		if err := door43metadata.QueueDoor43MetadataForRepoRelease(repo, nil); err != nil {
+			log.Error("QueueDoor43MetadataForRepoRelease: %v\n", err)
+		}
+	} else if repo.IsCatalogBranch(branch) {
+		if err := door43metadata.QueueDoor43MetadataForRepoBranch(repo, branch); err != nil {
+			log.Error("QueueDoor43MetadataForRepoBranch: %v\n", err)
+		}
+	}
+}
+
+func (m *metadataNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	branch := git.RefEndName(refFullName)
+	if refType == "branch" && repo.IsCatalogBranch(branch) {
+		if err := door43metadata.QueueDoor43MetadataForRepoBranch(repo, branch); err != nil {
+			log.Error("QueueDoor43MetadataForRepoBranch: %v\n", err)
 		}
 	}
 }
 
 func (m *metadataNotifier) NotifyDeleteRepository(doer *models.User, repo *models.Repository) {
-	if _, err := models.DeleteAllDoor43MetadatasByRepoID(repo.ID); err != nil {
-		log.Error("DeleteAllDoor43MetadatasByRepoID: %v\n", err)
+	if err := door43metadata.DeleteDoor43MetadatasOfRepo(repo, models.DeleteReasonRepoDeleted); err != nil {
+		log.Error("DeleteDoor43MetadatasOfRepo: %v\n", err)
 	}
 }
 
 func (m *metadataNotifier) NotifyMigrateRepository(doer *models.User, u *models.User, repo *models.Repository) {
-	if err := door43metadata.ProcessDoor43MetadataForRepo(repo); err != nil {
-		log.Error("ProcessDoor43MetadataForRepo: %v\n", err)
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+		log.Error("QueueDoor43MetadataForRepo: %v\n", err)
 	}
 }
 
 func (m *metadataNotifier) NotifyTransferRepository(doer *models.User, repo *models.Repository, newOwnerName string) {
-	if err := door43metadata.ProcessDoor43MetadataForRepo(repo); err != nil {
-		log.Error("ProcessDoor43MetadataForRepo: %v\n", err)
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+		log.Error("QueueDoor43MetadataForRepo: %v\n", err)
 	}
 }
 
 func (m *metadataNotifier) NotifyForkRepository(doer *models.User, oldRepo, repo *models.Repository) {
-	if err := door43metadata.ProcessDoor43MetadataForRepo(repo); err != nil {
-		log.Error("ProcessDoor43MetadataForRepo: %v\n", err)
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+		log.Error("QueueDoor43MetadataForRepo: %v\n", err)
 	}
 }
 
 func (m *metadataNotifier) NotifyRenameRepository(doer *models.User, repo *models.Repository, oldName string) {
-	if err := door43metadata.ProcessDoor43MetadataForRepo(repo); err != nil {
-		log.Error("ProcessDoor43MetadataForRepo: %v\n", err)
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+		log.Error("QueueDoor43MetadataForRepo: %v\n", err)
 	}
 }
@@ -95,6 +95,15 @@ func Schemas(name string) ([]byte, error) {
 	return fileFromDir(path.Join("schema", name))
 }
 
+/*** DCS Customizations ***/
+
+// Languages reads the content of a specific language data file from static or custom path.
+func Languages(name string) ([]byte, error) {
+	return fileFromDir(path.Join("languages", name))
+}
+
+/*** END DCS Customizations ***/
+
 // fileFromDir is a helper to read files from static or custom path.
 func fileFromDir(name string) ([]byte, error) {
 	customPath := path.Join(setting.CustomPath, "options", name)
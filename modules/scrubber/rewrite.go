@@ -0,0 +1,440 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*** DCS Customizations - Rewriting of the history of repos ***/
+
+package scrubber
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+)
+
+const pgpSignatureStart = "-----BEGIN PGP SIGNATURE-----"
+
+// pullHeadRefs are the refs of the heads of the pull requests, which keep the old history reachable if not rewritten
+const pullHeadRefs = "refs/pull/*/head"
+
+// RefReport is a branch, tag or pull request head whose history is rewritten
+type RefReport struct {
+	Name        string `json:"name"`
+	OldCommitID string `json:"old_commit_id"`
+	// NewCommitID is the ID of the rewritten commit, or tag object for an annotated tag, empty for a dry run
+	NewCommitID string `json:"new_commit_id,omitempty"`
+}
+
+// CommitReport is a commit with something to scrub
+type CommitReport struct {
+	CommitID string `json:"commit_id"`
+	// Summary is the first line of the scrubbed commit message
+	Summary string   `json:"summary"`
+	Files   []string `json:"files,omitempty"`
+	// Message is true if the commit message is scrubbed
+	Message bool `json:"message,omitempty"`
+	// Identity is true if the author or committer is replaced
+	Identity bool `json:"identity,omitempty"`
+}
+
+// Report is what scrubbing a repo changes, or would change for a dry run
+type Report struct {
+	DryRun  bool            `json:"dry_run"`
+	Refs    []*RefReport    `json:"refs"`
+	Commits []*CommitReport `json:"commits"`
+	// Files are the paths of the files scrubbed in at least one commit
+	Files []string `json:"files"`
+}
+
+// HasChanges returns true if a branch or tag is rewritten
+func (r *Report) HasChanges() bool {
+	return len(r.Refs) > 0
+}
+
+// treeResult is a tree with its scrubbed files
+type treeResult struct {
+	newID string
+	files []string
+}
+
+// rewriter rewrites the commits, trees and blobs of a repo with the rules applied, leaving its refs alone. Signatures
+// of rewritten commits and tags are dropped, as they no longer match.
+type rewriter struct {
+	repoPath string
+	rules    *RuleSet
+	// identity, if not nil, replaces the name and email of the authors, committers and taggers
+	identity *git.Signature
+	dryRun   bool
+
+	batchWriter git.WriteCloserError
+	batchReader *bufio.Reader
+	indexFile   string
+
+	// blobs maps the ID and path of a scrubbed blob to the ID of its scrubbed content, "" if it is unchanged
+	blobs map[string]string
+	trees map[string]*treeResult
+	// commits maps the ID of a commit to the ID of its rewritten commit, itself for a dry run
+	commits map[string]string
+	// changed is true for the commits that are rewritten, or would be for a dry run
+	changed map[string]bool
+	files   map[string]bool
+	report  *Report
+}
+
+func newRewriter(repoPath string, rules *RuleSet, identity *git.Signature, dryRun bool) *rewriter {
+	return &rewriter{
+		repoPath:  repoPath,
+		rules:     rules,
+		identity:  identity,
+		dryRun:    dryRun,
+		indexFile: filepath.Join(repoPath, "scrub-index"),
+		blobs:     make(map[string]string),
+		trees:     make(map[string]*treeResult),
+		commits:   make(map[string]string),
+		changed:   make(map[string]bool),
+		files:     make(map[string]bool),
+		report:    &Report{DryRun: dryRun, Refs: []*RefReport{}, Commits: []*CommitReport{}},
+	}
+}
+
+// ref is a branch, tag or pull request head with the commit it points to
+type ref struct {
+	name     string
+	objectID string
+	// commitID is the commit the ref points to, through its annotated tag if objectID is one
+	commitID string
+	isTag    bool
+}
+
+// listRefs lists the branches, tags and pull request heads that point to a commit, directly or through an annotated
+// tag
+func (r *rewriter) listRefs() ([]*ref, error) {
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(refname) %(objectname) %(objecttype) %(*objectname) %(*objecttype)",
+		git.BranchPrefix, git.TagPrefix, pullHeadRefs).RunInDir(r.repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var refs []*ref
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[2] == "commit":
+			refs = append(refs, &ref{name: fields[0], objectID: fields[1], commitID: fields[1]})
+		case len(fields) == 5 && fields[2] == "tag" && fields[4] == "commit":
+			refs = append(refs, &ref{name: fields[0], objectID: fields[1], commitID: fields[3], isTag: true})
+		}
+	}
+	return refs, nil
+}
+
+// run rewrites every commit reachable from the refs, parents first, then the annotated tags, returning the report of
+// the refs that change
+func (r *rewriter) run() (*Report, error) {
+	refs, err := r.listRefs()
+	if err != nil {
+		return nil, fmt.Errorf("listRefs: %v", err)
+	}
+	if len(refs) == 0 {
+		return r.report, nil
+	}
+
+	var batchCancel func()
+	r.batchWriter, r.batchReader, batchCancel = git.CatFileBatch(r.repoPath)
+	defer batchCancel()
+	defer os.Remove(r.indexFile)
+
+	args := []string{"rev-list", "--reverse", "--topo-order"}
+	for _, rf := range refs {
+		args = append(args, rf.commitID)
+	}
+	stdout, err := git.NewCommand(args...).RunInDir(r.repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("rev-list: %v", err)
+	}
+	for _, commitID := range strings.Fields(stdout) {
+		if err := r.rewriteCommit(commitID); err != nil {
+			return nil, fmt.Errorf("commit %s: %v", commitID, err)
+		}
+	}
+
+	for _, rf := range refs {
+		newID := r.commits[rf.commitID]
+		changed := r.changed[rf.commitID]
+		if rf.isTag {
+			if newID, changed, err = r.rewriteTag(rf.objectID, newID, changed); err != nil {
+				return nil, fmt.Errorf("tag %s: %v", rf.name, err)
+			}
+		}
+		if !changed {
+			continue
+		}
+		rr := &RefReport{Name: rf.name, OldCommitID: rf.objectID}
+		if !r.dryRun {
+			rr.NewCommitID = newID
+		}
+		r.report.Refs = append(r.report.Refs, rr)
+	}
+
+	for file := range r.files {
+		r.report.Files = append(r.report.Files, file)
+	}
+	sort.Strings(r.report.Files)
+	return r.report, nil
+}
+
+// readObject reads the content of an object of the type
+func (r *rewriter) readObject(id, typ string) ([]byte, error) {
+	if _, err := r.batchWriter.Write([]byte(id + "\n")); err != nil {
+		return nil, err
+	}
+	_, objectType, size, err := git.ReadBatchLine(r.batchReader)
+	if err != nil {
+		return nil, err
+	}
+	content := make([]byte, size)
+	if _, err := io.ReadFull(r.batchReader, content); err != nil {
+		return nil, err
+	}
+	// Each object is followed by a line feed
+	if _, err := r.batchReader.Discard(1); err != nil {
+		return nil, err
+	}
+	if objectType != typ {
+		return nil, fmt.Errorf("object %s is a %s, not a %s", id, objectType, typ)
+	}
+	return content, nil
+}
+
+// writeObject writes an object of the type, returning its ID
+func (r *rewriter) writeObject(typ string, content []byte) (string, error) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	if err := git.NewCommand("hash-object", "-t", typ, "-w", "--stdin").
+		RunInDirFullPipeline(r.repoPath, stdout, stderr, bytes.NewReader(content)); err != nil {
+		return "", git.ConcatenateError(err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// rewriteTree scrubs the files of a tree, returning the ID of the scrubbed tree, the same for a dry run, and the paths
+// of the scrubbed files
+func (r *rewriter) rewriteTree(treeID string) (*treeResult, error) {
+	if result, ok := r.trees[treeID]; ok {
+		return result, nil
+	}
+
+	stdout, err := git.NewCommand("ls-tree", "-r", "-z", "--full-tree", treeID).RunInDirBytes(r.repoPath)
+	if err != nil {
+		return nil, err
+	}
+	result := &treeResult{newID: treeID}
+	indexInfo := &bytes.Buffer{}
+	for _, entry := range bytes.Split(stdout, []byte{0}) {
+		// <mode> SP <type> SP <object> TAB <path>
+		tab := bytes.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(string(entry[:tab]))
+		filePath := string(entry[tab+1:])
+		if len(fields) != 3 || fields[1] != "blob" || !r.rules.MatchesFile(filePath) {
+			continue
+		}
+		mode, blobID := fields[0], fields[2]
+
+		key := blobID + " " + filePath
+		newBlobID, ok := r.blobs[key]
+		if !ok {
+			content, err := r.readObject(blobID, "blob")
+			if err != nil {
+				return nil, err
+			}
+			scrubbed, changed, err := r.rules.ScrubFile(filePath, content)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				newBlobID = blobID
+				if !r.dryRun {
+					if newBlobID, err = r.writeObject("blob", scrubbed); err != nil {
+						return nil, err
+					}
+				}
+			}
+			r.blobs[key] = newBlobID
+		}
+		if newBlobID == "" {
+			continue
+		}
+		result.files = append(result.files, filePath)
+		r.files[filePath] = true
+		fmt.Fprintf(indexInfo, "%s %s\t%s\x00", mode, newBlobID, filePath)
+	}
+
+	if len(result.files) > 0 && !r.dryRun {
+		env := append(os.Environ(), "GIT_INDEX_FILE="+r.indexFile)
+		if _, err := git.NewCommand("read-tree", treeID).RunInDirWithEnv(r.repoPath, env); err != nil {
+			return nil, fmt.Errorf("read-tree: %v", err)
+		}
+		stderr := &strings.Builder{}
+		if err := git.NewCommand("update-index", "-z", "--index-info").
+			RunInDirTimeoutEnvFullPipeline(env, -1, r.repoPath, ioutil.Discard, stderr, indexInfo); err != nil {
+			return nil, fmt.Errorf("update-index: %v", git.ConcatenateError(err, stderr.String()))
+		}
+		newTreeID, err := git.NewCommand("write-tree").RunInDirWithEnv(r.repoPath, env)
+		if err != nil {
+			return nil, fmt.Errorf("write-tree: %v", err)
+		}
+		result.newID = strings.TrimSpace(newTreeID)
+	}
+
+	r.trees[treeID] = result
+	return result, nil
+}
+
+// replaceIdentity replaces the name and email of an author, committer or tagger header, keeping its date
+func (r *rewriter) replaceIdentity(header string) (string, bool) {
+	lt := strings.Index(header, " <")
+	gt := strings.LastIndex(header, ">")
+	if r.identity == nil || lt < 0 || gt < lt {
+		return header, false
+	}
+	space := strings.IndexByte(header, ' ')
+	replaced := header[:space+1] + r.identity.Name + " <" + r.identity.Email + header[gt:]
+	return replaced, replaced != header
+}
+
+// splitObject splits a commit or tag into its headers and message, dropping the signature header of a commit
+func splitObject(content []byte) (headers []string, message string) {
+	raw := string(content)
+	if idx := strings.Index(raw, "\n\n"); idx >= 0 {
+		raw, message = raw[:idx], raw[idx+2:]
+	}
+	inSignature := false
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\n"), "\n") {
+		// Continuation lines of a multi-line header start with a space
+		if strings.HasPrefix(line, " ") {
+			if !inSignature && len(headers) > 0 {
+				headers[len(headers)-1] += "\n" + line
+			}
+			continue
+		}
+		inSignature = strings.HasPrefix(line, "gpgsig ")
+		if !inSignature {
+			headers = append(headers, line)
+		}
+	}
+	return headers, message
+}
+
+// rewriteCommit rewrites a commit with its tree scrubbed, its message scrubbed, its identities replaced and its
+// parents rewritten, recording it in the report if it has anything to scrub
+func (r *rewriter) rewriteCommit(commitID string) error {
+	content, err := r.readObject(commitID, "commit")
+	if err != nil {
+		return err
+	}
+	headers, message := splitObject(content)
+
+	var files []string
+	changed, identityChanged := false, false
+	for i, header := range headers {
+		switch {
+		case strings.HasPrefix(header, "tree "):
+			result, err := r.rewriteTree(strings.TrimPrefix(header, "tree "))
+			if err != nil {
+				return err
+			}
+			files = result.files
+			headers[i] = "tree " + result.newID
+		case strings.HasPrefix(header, "parent "):
+			parentID := strings.TrimPrefix(header, "parent ")
+			if r.changed[parentID] {
+				changed = true
+			}
+			if newParentID, ok := r.commits[parentID]; ok {
+				headers[i] = "parent " + newParentID
+			}
+		case strings.HasPrefix(header, "author "), strings.HasPrefix(header, "committer "):
+			if replaced, ok := r.replaceIdentity(header); ok {
+				headers[i] = replaced
+				identityChanged = true
+			}
+		}
+	}
+	message, messageChanged := r.rules.ScrubMessage(message)
+
+	if len(files) > 0 || messageChanged || identityChanged {
+		changed = true
+		summary := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+		r.report.Commits = append(r.report.Commits, &CommitReport{
+			CommitID: commitID,
+			Summary:  summary,
+			Files:    files,
+			Message:  messageChanged,
+			Identity: identityChanged,
+		})
+	}
+
+	r.commits[commitID] = commitID
+	r.changed[commitID] = changed
+	if changed && !r.dryRun {
+		newCommitID, err := r.writeObject("commit", []byte(strings.Join(headers, "\n")+"\n\n"+message))
+		if err != nil {
+			return err
+		}
+		r.commits[commitID] = newCommitID
+	}
+	return nil
+}
+
+// rewriteTag rewrites an annotated tag to point to the rewritten commit, with its message scrubbed and tagger
+// replaced, returning the ID of the rewritten tag and whether it changed
+func (r *rewriter) rewriteTag(tagID, newCommitID string, commitChanged bool) (string, bool, error) {
+	content, err := r.readObject(tagID, "tag")
+	if err != nil {
+		return "", false, err
+	}
+	headers, message := splitObject(content)
+
+	changed := commitChanged
+	for i, header := range headers {
+		switch {
+		case strings.HasPrefix(header, "object "):
+			headers[i] = "object " + newCommitID
+		case strings.HasPrefix(header, "tagger "):
+			if replaced, ok := r.replaceIdentity(header); ok {
+				headers[i] = replaced
+				changed = true
+			}
+		}
+	}
+	unsigned := message
+	if idx := strings.Index(message, pgpSignatureStart); idx >= 0 {
+		unsigned = message[:idx]
+	}
+	scrubbed, messageChanged := r.rules.ScrubMessage(unsigned)
+	if !changed && !messageChanged {
+		// Only the rewritten tags need their signature dropped, so the others keep it
+		return tagID, false, nil
+	}
+	message = scrubbed
+	if r.dryRun {
+		return tagID, true, nil
+	}
+	newTagID, err := r.writeObject("tag", []byte(strings.Join(headers, "\n")+"\n\n"+message))
+	if err != nil {
+		return "", false, err
+	}
+	return newTagID, true, nil
+}
+
+/*** END DCS Customizations ***/
@@ -0,0 +1,115 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scrubber
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// commitFiles writes the files to the repo and commits them as John Smith
+func commitFiles(t *testing.T, repoPath, message string, files map[string]string) {
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644))
+	}
+	sig := &git.Signature{Name: "John Smith", Email: "john@smith.com", When: time.Now()}
+	assert.NoError(t, git.AddChanges(repoPath, true))
+	assert.NoError(t, git.CommitChanges(repoPath, git.CommitChangesOptions{Committer: sig, Author: sig, Message: message}))
+}
+
+func revParse(t *testing.T, repoPath, rev string) string {
+	stdout, err := git.NewCommand("rev-parse", rev).RunInDir(repoPath)
+	assert.NoError(t, err)
+	return strings.TrimSpace(stdout)
+}
+
+func TestRewriter(t *testing.T) {
+	repoPath, err := ioutil.TempDir(os.TempDir(), "scrub_rewrite_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(repoPath)
+
+	assert.NoError(t, git.InitRepository(repoPath, false))
+	commitFiles(t, repoPath, "Initial commit", map[string]string{
+		"README.md":     "# Genesis\n",
+		"manifest.json": `{"translators": ["Jim"]}`,
+	})
+	commitFiles(t, repoPath, "Add Genesis", map[string]string{
+		"01-GEN.usfm": "\\id GEN\n\\rem Jim jim@example.org\n\\c 1\n",
+	})
+	env := append(os.Environ(), "GIT_COMMITTER_NAME=John Smith", "GIT_COMMITTER_EMAIL=john@smith.com")
+	_, err = git.NewCommand("tag", "-a", "v1", "-m", "Version 1").RunInDirWithEnv(repoPath, env)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("branch", "draft").RunInDir(repoPath)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", "refs/pull/1/head", "draft").RunInDir(repoPath)
+	assert.NoError(t, err)
+	commitFiles(t, repoPath, "Update README", map[string]string{
+		"README.md": "# Genesis, by Jim\n",
+	})
+	oldHead := revParse(t, repoPath, "HEAD")
+	branch, err := git.NewCommand("symbolic-ref", "HEAD").RunInDir(repoPath)
+	assert.NoError(t, err)
+
+	rules, err := NewRuleSet(DefaultRules)
+	assert.NoError(t, err)
+
+	report, err := newRewriter(repoPath, rules, nil, true).run()
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Refs, 4)
+	for _, rr := range report.Refs {
+		assert.Empty(t, rr.NewCommitID)
+	}
+	assert.Len(t, report.Commits, 3)
+	assert.Equal(t, []string{"01-GEN.usfm", "manifest.json"}, report.Files)
+	assert.Equal(t, oldHead, revParse(t, repoPath, "HEAD"))
+
+	report, err = newRewriter(repoPath, rules, &git.Signature{Name: "Door43", Email: "commit@door43.org"}, false).run()
+	assert.NoError(t, err)
+	assert.Len(t, report.Refs, 4)
+	newIDs := make(map[string]string)
+	for _, rr := range report.Refs {
+		assert.NotEmpty(t, rr.NewCommitID)
+		assert.NotEqual(t, rr.OldCommitID, rr.NewCommitID)
+		newIDs[rr.Name] = rr.NewCommitID
+	}
+
+	head := newIDs[strings.TrimSpace(branch)]
+	content, err := git.NewCommand("show", head+":manifest.json").RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "translators": []
+}`, content)
+	content, err = git.NewCommand("show", head+":01-GEN.usfm").RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "\\id GEN\n\\c 1\n", content)
+	content, err = git.NewCommand("show", head+":README.md").RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Genesis, by Jim\n", content)
+
+	authors, err := git.NewCommand("log", "--format=%an <%ae> %cn <%ce> %s", head).RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Door43 <commit@door43.org> Door43 <commit@door43.org> Update README\n"+
+		"Door43 <commit@door43.org> Door43 <commit@door43.org> Add Genesis\n"+
+		"Door43 <commit@door43.org> Door43 <commit@door43.org> Initial commit\n", authors)
+
+	// The tag, the other branch and the head of the pull request point to the same rewritten commit
+	tagged, err := git.NewCommand("rev-parse", newIDs[git.TagPrefix+"v1"]+"^{commit}").RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, newIDs[git.BranchPrefix+"draft"], strings.TrimSpace(tagged))
+	assert.Equal(t, newIDs[git.BranchPrefix+"draft"], newIDs["refs/pull/1/head"])
+	tagger, err := git.NewCommand("cat-file", "tag", newIDs[git.TagPrefix+"v1"]).RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.Contains(t, tagger, "tagger Door43 <commit@door43.org>")
+	assert.Contains(t, tagger, "Version 1")
+}
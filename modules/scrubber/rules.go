@@ -0,0 +1,337 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*** DCS Customizations - Rules of what to scrub from repos ***/
+
+package scrubber
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Rule is a rule of what to scrub: the values at its JSON paths are emptied and the matches of its patterns are
+// replaced in the files whose path matches one of its globs, and in the commit messages if CommitMessages is set
+type Rule struct {
+	Name string `json:"name"`
+	// Files are globs of the paths of the files to scrub. A glob without a "/" matches the file name in any directory,
+	// one with a "/" matches the path from the root of the repo.
+	Files []string `json:"files"`
+	// JSONPaths are dot separated keys of the values to empty in JSON files. A "*" key matches every key or element
+	// and a path starting with ".." matches at any depth, so "..translators" empties every translators field.
+	JSONPaths []string `json:"json_paths,omitempty"`
+	// Patterns are regular expressions whose matches are replaced with Replacement, which can refer to submatches
+	// with $1
+	Patterns       []string `json:"patterns,omitempty"`
+	Replacement    string   `json:"replacement,omitempty"`
+	CommitMessages bool     `json:"commit_messages,omitempty"`
+}
+
+// DefaultRules are the rules used when none are given: the names in the JSON metadata files, the \rem lines of USFM
+// files and the email addresses in TSV files
+var DefaultRules = []*Rule{
+	{
+		Name:      "metadata-names",
+		Files:     []string{"manifest.json", "project.json", "package.json", "status.json"},
+		JSONPaths: []string{"..translators", "..contributors", "..checking_entity"},
+	},
+	{
+		Name:     "usfm-remarks",
+		Files:    []string{"*.usfm", "*.USFM"},
+		Patterns: []string{`(?m)^[ \t]*\\rem([ \t][^\r\n]*)?(\r?\n|$)`},
+	},
+	{
+		Name:     "tsv-emails",
+		Files:    []string{"*.tsv"},
+		Patterns: []string{`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`},
+	},
+}
+
+// ErrInvalidRule represents an error of a rule that can not be applied
+type ErrInvalidRule struct {
+	Name   string
+	Reason string
+}
+
+// IsErrInvalidRule checks if an error is a ErrInvalidRule
+func IsErrInvalidRule(err error) bool {
+	_, ok := err.(ErrInvalidRule)
+	return ok
+}
+
+func (err ErrInvalidRule) Error() string {
+	return fmt.Sprintf("invalid scrub rule [name: %s]: %s", err.Name, err.Reason)
+}
+
+// ParseRules parses the JSON of a list of rules, returning the default rules if it is empty
+func ParseRules(content []byte) ([]*Rule, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return DefaultRules, nil
+	}
+	var rules []*Rule
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, ErrInvalidRule{Reason: err.Error()}
+	}
+	return rules, nil
+}
+
+// LoadRules reads the rules of a JSON file, returning the default rules if fileName is empty
+func LoadRules(fileName string) ([]*Rule, error) {
+	if fileName == "" {
+		return DefaultRules, nil
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(content)
+}
+
+// compiledRule is a rule with its patterns compiled and its JSON paths split
+type compiledRule struct {
+	*Rule
+	jsonPaths [][]string
+	patterns  []*regexp.Regexp
+}
+
+// RuleSet is a list of rules ready to be applied
+type RuleSet struct {
+	rules []*compiledRule
+}
+
+// NewRuleSet checks and compiles the rules
+func NewRuleSet(rules []*Rule) (*RuleSet, error) {
+	if len(rules) == 0 {
+		return nil, ErrInvalidRule{Reason: "no rules given"}
+	}
+	rs := &RuleSet{}
+	for _, r := range rules {
+		if r == nil {
+			return nil, ErrInvalidRule{Reason: "empty rule"}
+		}
+		if len(r.JSONPaths) == 0 && len(r.Patterns) == 0 {
+			return nil, ErrInvalidRule{Name: r.Name, Reason: "a rule needs JSON paths or patterns"}
+		}
+		if len(r.Files) == 0 && !r.CommitMessages {
+			return nil, ErrInvalidRule{Name: r.Name, Reason: "a rule needs files or to apply to the commit messages"}
+		}
+		if len(r.JSONPaths) > 0 && r.CommitMessages {
+			return nil, ErrInvalidRule{Name: r.Name, Reason: "JSON paths can not apply to the commit messages"}
+		}
+		cr := &compiledRule{Rule: r}
+		for _, glob := range r.Files {
+			if _, err := path.Match(glob, ""); err != nil || glob == "" {
+				return nil, ErrInvalidRule{Name: r.Name, Reason: fmt.Sprintf("invalid glob %q", glob)}
+			}
+		}
+		for _, jsonPath := range r.JSONPaths {
+			keys := strings.Split(jsonPath, ".")
+			if strings.HasPrefix(jsonPath, "..") {
+				keys = append([]string{".."}, strings.Split(jsonPath[2:], ".")...)
+			}
+			for _, key := range keys {
+				if key == "" {
+					return nil, ErrInvalidRule{Name: r.Name, Reason: fmt.Sprintf("invalid JSON path %q", jsonPath)}
+				}
+			}
+			cr.jsonPaths = append(cr.jsonPaths, keys)
+		}
+		for _, pattern := range r.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, ErrInvalidRule{Name: r.Name, Reason: err.Error()}
+			}
+			cr.patterns = append(cr.patterns, re)
+		}
+		rs.rules = append(rs.rules, cr)
+	}
+	return rs, nil
+}
+
+// matchesFile returns true if one of the globs of the rule matches the path of the file
+func (r *compiledRule) matchesFile(filePath string) bool {
+	for _, glob := range r.Files {
+		name := filePath
+		if !strings.Contains(glob, "/") {
+			name = path.Base(filePath)
+		} else {
+			glob = strings.TrimPrefix(glob, "/")
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesFile returns true if any of the rules applies to the file
+func (rs *RuleSet) MatchesFile(filePath string) bool {
+	for _, r := range rs.rules {
+		if r.matchesFile(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScrubFile applies the rules that match the path of the file to its content, returning the scrubbed content and
+// whether it changed. A JSON file that can not be parsed is an error, as it can not be known to be scrubbed.
+func (rs *RuleSet) ScrubFile(filePath string, content []byte) ([]byte, bool, error) {
+	changed := false
+	for _, r := range rs.rules {
+		if !r.matchesFile(filePath) {
+			continue
+		}
+		if len(r.jsonPaths) > 0 {
+			scrubbed, ok, err := scrubJSON(content, r.jsonPaths)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %v", filePath, err)
+			}
+			if ok {
+				content, changed = scrubbed, true
+			}
+		}
+		for _, re := range r.patterns {
+			if scrubbed := re.ReplaceAll(content, []byte(r.Replacement)); !bytes.Equal(scrubbed, content) {
+				content, changed = scrubbed, true
+			}
+		}
+	}
+	return content, changed, nil
+}
+
+// ScrubMessage applies the patterns of the rules for commit messages to the message, returning the scrubbed message
+// and whether it changed
+func (rs *RuleSet) ScrubMessage(message string) (string, bool) {
+	changed := false
+	for _, r := range rs.rules {
+		if !r.CommitMessages {
+			continue
+		}
+		for _, re := range r.patterns {
+			if scrubbed := re.ReplaceAllString(message, r.Replacement); scrubbed != message {
+				message, changed = scrubbed, true
+			}
+		}
+	}
+	return message, changed
+}
+
+// scrubJSON empties the values at the paths of the JSON content, returning the content re-encoded only if a value
+// was not already empty, so files with nothing to scrub keep their formatting
+func scrubJSON(content []byte, paths [][]string) ([]byte, bool, error) {
+	var data interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, false, err
+	}
+	changed := false
+	for _, keys := range paths {
+		if emptyJSONPath(data, keys) {
+			changed = true
+		}
+	}
+	if !changed {
+		return content, false, nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return nil, false, err
+	}
+	scrubbed := buf.Bytes()
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		scrubbed = bytes.TrimSuffix(scrubbed, []byte("\n"))
+	}
+	return scrubbed, true, nil
+}
+
+// emptyJSONPath empties the values at the path of keys in the data, returning true if one was not already empty
+func emptyJSONPath(data interface{}, keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	key, rest := keys[0], keys[1:]
+	changed := false
+	if key == ".." {
+		// Match the rest of the path here, then at every depth below
+		if emptyJSONPath(data, rest) {
+			changed = true
+		}
+		forEachJSONChild(data, func(_ string, child interface{}, _ func(interface{})) {
+			if emptyJSONPath(child, keys) {
+				changed = true
+			}
+		})
+		return changed
+	}
+	forEachJSONChild(data, func(childKey string, child interface{}, set func(interface{})) {
+		if key != "*" && key != childKey {
+			return
+		}
+		if len(rest) > 0 {
+			if emptyJSONPath(child, rest) {
+				changed = true
+			}
+		} else if empty := emptyJSONValue(child); !isEmptyJSONValue(child) {
+			set(empty)
+			changed = true
+		}
+	})
+	return changed
+}
+
+// forEachJSONChild calls fn with the key, value and setter of every field of an object or element of an array
+func forEachJSONChild(data interface{}, fn func(key string, child interface{}, set func(interface{}))) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			k := k
+			fn(k, child, func(value interface{}) { v[k] = value })
+		}
+	case []interface{}:
+		for i, child := range v {
+			i := i
+			fn(fmt.Sprint(i), child, func(value interface{}) { v[i] = value })
+		}
+	}
+}
+
+// emptyJSONValue returns the empty value of the type of a JSON value
+func emptyJSONValue(value interface{}) interface{} {
+	switch value.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	case []interface{}:
+		return []interface{}{}
+	case nil:
+		return nil
+	default:
+		return ""
+	}
+}
+
+func isEmptyJSONValue(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case nil:
+		return true
+	default:
+		return false
+	}
+}
+
+/*** END DCS Customizations ***/
@@ -0,0 +1,118 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scrubber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRuleSet(t *testing.T) {
+	_, err := NewRuleSet(DefaultRules)
+	assert.NoError(t, err)
+
+	for _, rules := range [][]*Rule{
+		nil,
+		{{Name: "no-action", Files: []string{"*.json"}}},
+		{{Name: "no-files", Patterns: []string{"x"}}},
+		{{Name: "bad-glob", Files: []string{"[.json"}, Patterns: []string{"x"}}},
+		{{Name: "bad-pattern", Files: []string{"*.md"}, Patterns: []string{"("}}},
+		{{Name: "bad-path", Files: []string{"*.json"}, JSONPaths: []string{"a..b"}}},
+		{{Name: "json-message", JSONPaths: []string{"a"}, CommitMessages: true}},
+	} {
+		_, err := NewRuleSet(rules)
+		assert.True(t, IsErrInvalidRule(err), "%v", rules)
+	}
+}
+
+func TestRuleSet_ScrubFile(t *testing.T) {
+	rules, err := NewRuleSet(DefaultRules)
+	assert.NoError(t, err)
+
+	assert.True(t, rules.MatchesFile("manifest.json"))
+	assert.True(t, rules.MatchesFile("sub/manifest.json"))
+	assert.True(t, rules.MatchesFile("01-GEN.usfm"))
+	assert.False(t, rules.MatchesFile("README.md"))
+
+	scrubbed, changed, err := rules.ScrubFile("manifest.json",
+		[]byte(`{"translators": ["Jim", "Bob"], "resource": {"status": {"checking_entity": ["Sally"]}}, "title": "A & B"}`+"\n"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `{
+  "resource": {
+    "status": {
+      "checking_entity": []
+    }
+  },
+  "title": "A & B",
+  "translators": []
+}
+`, string(scrubbed))
+
+	// Files with nothing to scrub keep their formatting
+	content := []byte(`{"translators":[],"title":"x"}`)
+	scrubbed, changed, err = rules.ScrubFile("package.json", content)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, content, scrubbed)
+
+	_, _, err = rules.ScrubFile("status.json", []byte(`{"translators": [`))
+	assert.Error(t, err)
+
+	scrubbed, changed, err = rules.ScrubFile("01-GEN.usfm", []byte("\\id GEN\n\\rem Translated by Jim\n\\c 1\n\\rem\n\\p\n"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "\\id GEN\n\\c 1\n\\p\n", string(scrubbed))
+
+	scrubbed, changed, err = rules.ScrubFile("tn_GEN.tsv", []byte("Reference\tNote\n1:1\tAsk jim.smith@example.org\n"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Reference\tNote\n1:1\tAsk \n", string(scrubbed))
+}
+
+func TestRuleSet_JSONPaths(t *testing.T) {
+	rules, err := NewRuleSet([]*Rule{
+		{Name: "paths", Files: []string{"/meta/*.json"}, JSONPaths: []string{"dublin_core.contributor", "projects.*.author"}},
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, rules.MatchesFile("manifest.json"))
+	assert.True(t, rules.MatchesFile("meta/manifest.json"))
+
+	scrubbed, changed, err := rules.ScrubFile("meta/manifest.json",
+		[]byte(`{"dublin_core":{"contributor":["Jim"],"creator":"Door43"},"contributor":["Bob"],"projects":[{"author":"Sally","id":"gen"}]}`))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.JSONEq(t, `{"dublin_core":{"contributor":[],"creator":"Door43"},"contributor":["Bob"],"projects":[{"author":"","id":"gen"}]}`,
+		string(scrubbed))
+}
+
+func TestRuleSet_ScrubMessage(t *testing.T) {
+	rules, err := NewRuleSet([]*Rule{
+		{Name: "emails", Files: []string{"*.md"}, Patterns: []string{`\S+@\S+`}, Replacement: "[email]", CommitMessages: true},
+	})
+	assert.NoError(t, err)
+
+	message, changed := rules.ScrubMessage("Reviewed by jim@example.org\n")
+	assert.True(t, changed)
+	assert.Equal(t, "Reviewed by [email]\n", message)
+
+	_, changed = rules.ScrubMessage("Fix typo\n")
+	assert.False(t, changed)
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(" "))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRules, rules)
+
+	rules, err = ParseRules([]byte(`[{"name": "x", "files": ["*.md"], "patterns": ["secret"], "replacement": "***"}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Rule{{Name: "x", Files: []string{"*.md"}, Patterns: []string{"secret"}, Replacement: "***"}}, rules)
+
+	_, err = ParseRules([]byte(`{`))
+	assert.True(t, IsErrInvalidRule(err))
+}
@@ -11,192 +11,221 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
-	"path"
-	"reflect"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/door43metadata"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
 	repo_service "code.gitea.io/gitea/services/repository"
 )
 
-var jsonFilesToScrub = [...]string{
-	"project.json",
-	"package.json",
-	"manifest.json",
-	"status.json",
+// scrubbingPool makes sure a repo is scrubbed once at a time
+var scrubbingPool = sync.NewExclusivePool()
+
+// ErrRepoChanged represents an error of a branch, tag or pull request head that was pushed to while its repo was being scrubbed
+type ErrRepoChanged struct {
+	RepoName string
 }
 
-var jsonFieldsToScrub = [...]string{
-	"translators",
-	"contributors",
-	"checking_entity",
+// IsErrRepoChanged checks if an error is a ErrRepoChanged
+func IsErrRepoChanged(err error) bool {
+	_, ok := err.(ErrRepoChanged)
+	return ok
 }
 
-// ScrubSensitiveDataOptions options for scrubbing sensitive data
-type ScrubSensitiveDataOptions struct {
-	LastCommitID  string
-	CommitMessage string
+func (err ErrRepoChanged) Error() string {
+	return fmt.Sprintf("repository changed while it was being scrubbed, try again [repo: %s]", err.RepoName)
 }
 
-// ScrubSensitiveData removes names and email addresses from the manifest|project|package|status.json files and scrubs previous history.
-func ScrubSensitiveData(repo *models.Repository, doer *models.User, opts ScrubSensitiveDataOptions) error {
-	localPath, err := models.CreateTemporaryPath("repo-scrubber")
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := models.RemoveTemporaryPath(localPath); err != nil {
-			log.Error("ScrubSensitiveData: RemoveTemporaryPath: %s", err)
-		}
-	}()
+// ScrubOptions options for scrubbing a repo
+type ScrubOptions struct {
+	// Rules are the rules to apply, the configured default rules if empty
+	Rules []*Rule
+	// IdentityName and IdentityEmail, if set, replace the names and emails of the authors, committers and taggers
+	IdentityName  string
+	IdentityEmail string
+	// DryRun only reports what would be scrubbed
+	DryRun bool
+}
 
-	if err := git.Clone(repo.RepoPath(), localPath, git.CloneRepoOptions{}); err != nil {
-		log.Error("Failed to clone repository: %s (%v)", repo.FullName(), err)
-		return fmt.Errorf("failed to clone repository: %s (%v)", repo.FullName(), err)
-	}
-
-	if err := ScrubJSONFiles(localPath); err == nil {
-		if err := git.AddChanges(localPath, true); err != nil {
-			return fmt.Errorf("AddChanges: %v", err)
-		} else if err := git.CommitChanges(localPath, git.CommitChangesOptions{
-			Committer: doer.NewGitSig(),
-			Message:   opts.CommitMessage,
-		}); err != nil {
-			return fmt.Errorf("CommitChanges: %v", err)
-		} else if err := git.Push(localPath, git.PushOptions{
-			Remote: "origin",
-			Branch: "master",
-			Force:  true,
-		}); err != nil {
-			return fmt.Errorf("PushForce: %v", err)
-		}
-		gitRepo, err := git.OpenRepository(repo.RepoPath())
-		if err != nil {
-			return fmt.Errorf("OpenRepository: %v", err)
-		}
-		commit, err := gitRepo.GetBranchCommit("master")
-		if err != nil {
-			return fmt.Errorf("GetBranchCommit [branch: %s]: %v", "master", err)
+// GetDefaultRules returns the rules of the SCRUB_RULES_FILE setting, the built-in ones if it is not set
+func GetDefaultRules() ([]*Rule, error) {
+	return LoadRules(setting.DCS.ScrubRulesFile)
+}
+
+// ScrubRepository applies the rules to every commit of every branch, tag and pull request head of a repo, or only
+// reports what they would scrub for a dry run. The history is rewritten in a bare clone and pushed back only if none
+// of these refs changed in the meantime, then the Door43 metadata of the repo is processed again. Forks are separate
+// repos and are not scrubbed. Every scrub, including dry runs
+// and failures, is recorded as a models.RepoScrub with the JSON of its Report, which is returned along with the error
+// if it failed.
+func ScrubRepository(repo *models.Repository, doer *models.User, opts ScrubOptions) (*models.RepoScrub, error) {
+	rules := opts.Rules
+	if len(rules) == 0 {
+		var err error
+		if rules, err = GetDefaultRules(); err != nil {
+			return nil, fmt.Errorf("GetDefaultRules: %v", err)
 		}
-		oldCommitID := opts.LastCommitID
-		if err := repo_service.PushUpdate(
-			&repo_module.PushUpdateOptions{
-				PusherID:     doer.ID,
-				PusherName:   doer.Name,
-				RepoUserName: repo.MustOwner().Name,
-				RepoName:     repo.Name,
-				RefFullName:  git.BranchPrefix + "master",
-				OldCommitID:  oldCommitID,
-				NewCommitID:  commit.ID.String(),
-			}); err != nil {
-			return fmt.Errorf("PushCommits: %v", err)
+	}
+	ruleSet, err := NewRuleSet(rules)
+	if err != nil {
+		return nil, err
+	}
+	var identity *git.Signature
+	if opts.IdentityName != "" || opts.IdentityEmail != "" {
+		if opts.IdentityName == "" || opts.IdentityEmail == "" || strings.ContainsAny(opts.IdentityName+opts.IdentityEmail, "<>\n") {
+			return nil, ErrInvalidRule{Name: "identity", Reason: "the identity needs a name and an email"}
 		}
-	} else {
-		return err
+		identity = &git.Signature{Name: opts.IdentityName, Email: opts.IdentityEmail}
 	}
 
-	return ScrubCommitNameAndEmail(localPath, "Door43", "commit@door43.org")
-}
+	scrubbingPool.CheckIn(fmt.Sprint(repo.ID))
+	defer scrubbingPool.CheckOut(fmt.Sprint(repo.ID))
 
-// ScrubJSONFiles will scrub all JSON files
-func ScrubJSONFiles(localPath string) error {
-	for _, fileName := range jsonFilesToScrub {
-		if err := scrubJSONFile(localPath, fileName); err != nil {
-			return err
+	report, scrubErr := scrubRepository(repo, doer, ruleSet, identity, opts.DryRun)
+
+	audit := &models.RepoScrub{
+		RepoID:       repo.ID,
+		Repo:         repo,
+		RepoFullName: repo.FullName(),
+		DoerID:       doer.ID,
+		Doer:         doer,
+		IsDryRun:     opts.DryRun,
+	}
+	if rulesJSON, err := json.Marshal(rules); err == nil {
+		audit.Rules = string(rulesJSON)
+	}
+	if identity != nil {
+		audit.Identity = identity.Name + " <" + identity.Email + ">"
+	}
+	if report != nil {
+		audit.NumRefs, audit.NumCommits, audit.NumFiles = len(report.Refs), len(report.Commits), len(report.Files)
+		if reportJSON, err := json.Marshal(report); err == nil {
+			audit.Report = string(reportJSON)
 		}
 	}
-	return nil
+	if scrubErr != nil {
+		audit.Error = scrubErr.Error()
+	}
+	if err := models.CreateRepoScrub(audit); err != nil {
+		log.Error("CreateRepoScrub [repo: %s]: %v", repo.FullName(), err)
+	}
+	return audit, scrubErr
 }
 
-func scrubJSONFile(localPath, fileName string) error {
-	jsonPath := path.Join(localPath, fileName)
-
-	var jsonData interface{}
-	if _, err := os.Stat(jsonPath); os.IsNotExist(err) {
-		return nil // path does not exist, nothing to scrub!
-	} else if fileContent, err := ioutil.ReadFile(jsonPath); err != nil {
-		log.Error("%v", err)
-		return err // error reading file
-	} else if err = json.Unmarshal(fileContent, &jsonData); err != nil {
-		log.Error("%v", err)
-		return err // error unmarhalling file
+func scrubRepository(repo *models.Repository, doer *models.User, rules *RuleSet, identity *git.Signature, dryRun bool) (*Report, error) {
+	localPath, err := models.CreateTemporaryPath("repo-scrubber")
+	if err != nil {
+		return nil, err
 	}
+	defer func() {
+		if err := models.RemoveTemporaryPath(localPath); err != nil {
+			log.Error("ScrubRepository: RemoveTemporaryPath: %s", err)
+		}
+	}()
 
-	m := jsonData.(map[string]interface{})
-	ScrubMap(m)
-
-	if fileContent, err := json.MarshalIndent(m, "", "  "); err != nil {
-		return err
-	} else if err := ScrubFile(localPath, fileName); err != nil {
-		return err
-	} else if err := ioutil.WriteFile(jsonPath, []byte(fileContent), 0666); err != nil {
-		return err
+	if err := git.Clone(repo.RepoPath(), localPath, git.CloneRepoOptions{Bare: true}); err != nil {
+		return nil, fmt.Errorf("failed to clone repository: %s (%v)", repo.FullName(), err)
+	}
+	// A clone only has the branches and tags, but the heads of the pull requests must be rewritten too
+	if _, err := git.NewCommand("fetch", "origin", "+"+pullHeadRefs+":"+pullHeadRefs).RunInDir(localPath); err != nil {
+		return nil, fmt.Errorf("failed to fetch the pull requests of repository: %s (%v)", repo.FullName(), err)
 	}
 
-	return nil
-}
+	report, err := newRewriter(localPath, rules, identity, dryRun).run()
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || !report.HasChanges() {
+		return report, nil
+	}
 
-// ScrubMap will scrub a map
-func ScrubMap(m map[string]interface{}) {
-	for _, field := range jsonFieldsToScrub {
-		if _, ok := m[field]; ok {
-			m[field] = []string{}
+	// Every ref is pushed at once, and only if it still points to what was cloned
+	args := []string{"push", "--atomic", "--porcelain"}
+	for _, rr := range report.Refs {
+		args = append(args, "--force-with-lease="+rr.Name+":"+rr.OldCommitID)
+	}
+	args = append(args, "origin")
+	for _, rr := range report.Refs {
+		args = append(args, rr.NewCommitID+":"+rr.Name)
+	}
+	var stdout, stderr strings.Builder
+	if err := git.NewCommand(args...).RunInDirTimeoutEnvPipeline(models.InternalPushingEnvironment(doer, repo), -1, localPath, &stdout, &stderr); err != nil {
+		if strings.Contains(stdout.String(), "stale info") || strings.Contains(stderr.String(), "stale info") {
+			return nil, ErrRepoChanged{repo.FullName()}
 		}
+		return nil, fmt.Errorf("push: %v", git.ConcatenateError(err, stderr.String()))
 	}
-	for _, v := range m {
-		if reflect.ValueOf(v).Kind() == reflect.Map {
-			vm := v.(map[string]interface{})
-			ScrubMap(vm)
+
+	// The hooks are skipped by the internal push, so the branches, tags and releases are updated here
+	updates := make([]*repo_module.PushUpdateOptions, 0, len(report.Refs))
+	for _, rr := range report.Refs {
+		if !strings.HasPrefix(rr.Name, git.BranchPrefix) && !strings.HasPrefix(rr.Name, git.TagPrefix) {
+			continue
 		}
+		updates = append(updates, &repo_module.PushUpdateOptions{
+			PusherID:     doer.ID,
+			PusherName:   doer.Name,
+			RepoUserName: repo.OwnerName,
+			RepoName:     repo.Name,
+			RefFullName:  rr.Name,
+			OldCommitID:  rr.OldCommitID,
+			NewCommitID:  rr.NewCommitID,
+		})
 	}
+	if err := repo_service.PushUpdates(updates); err != nil {
+		log.Error("PushUpdates [repo: %s]: %v", repo.FullName(), err)
+	}
+
+	// Drop the objects of the old history once they expire, as git gc does for the repo health check. Pruning them
+	// now could drop the objects of a push received at the same time that aren't referred to yet.
+	if _, err := git.NewCommand(append([]string{"gc"}, setting.Git.GCArgs...)...).
+		RunInDirTimeout(time.Duration(setting.Git.Timeout.GC)*time.Second, repo.RepoPath()); err != nil {
+		log.Error("gc [repo: %s]: %v", repo.FullName(), err)
+	}
+
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+		log.Error("QueueDoor43MetadataForRepo [repo: %s]: %v", repo.FullName(), err)
+	}
+	return report, nil
 }
 
-// ScrubFile completely removes a file from a repository's history
-func ScrubFile(repoPath string, fileName string) error {
-	gitPath, err := exec.LookPath("git")
+// ScrubWorkingTree scrubs the files of the working tree at localPath with the default rules, without committing them
+func ScrubWorkingTree(localPath string) error {
+	rules, err := NewRuleSet(DefaultRules)
 	if err != nil {
 		return err
 	}
-	cmd := git.NewCommand("filter-branch", "--force", "--prune-empty", "--tag-name-filter", "cat",
-		"--index-filter", "\""+gitPath+"\" rm --cached --ignore-unmatch "+fileName,
-		"--", "--all")
-	_, err = cmd.RunInDir(repoPath)
-	if err != nil && err.Error() == "exit status 1" {
-		err := os.RemoveAll(path.Join(repoPath, ".git/refs/original/"))
+	return filepath.Walk(localPath, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		cmd = git.NewCommand("reflog", "expire", "--all")
-		_, err = cmd.RunInDir(repoPath)
-		if err != nil && err.Error() == "exit status 1" {
-			cmd = git.NewCommand("gc", "--aggressive", "--prune")
-			_, err = cmd.RunInDir(repoPath)
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(localPath, filePath)
+		if err != nil || !rules.MatchesFile(filepath.ToSlash(relPath)) {
 			return err
 		}
-	}
-	return err
-}
-
-// ScrubCommitNameAndEmail scrubs all commit names and emails
-func ScrubCommitNameAndEmail(localPath, newName, newEmail string) error {
-	if err := os.RemoveAll(path.Join(localPath, ".git/refs/original/")); err != nil {
-		return err
-	}
-	if _, err := git.NewCommand("filter-branch", "-f", "--env-filter", `
-export GIT_COMMITTER_NAME="`+newName+`"
-export GIT_COMMITTER_EMAIL="`+newEmail+`"
-export GIT_AUTHOR_NAME="`+newName+`"
-export GIT_AUTHOR_EMAIL="`+newEmail+`"
-`, "--tag-name-filter", "cat", "--", "--branches", "--tags").RunInDir(localPath); err != nil {
-		return err
-	}
-	if _, err := git.NewCommand("push", "--force", "--tags", "origin", "refs/heads/*").RunInDir(localPath); err != nil {
-		return err
-	}
-	return nil
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		scrubbed, changed, err := rules.ScrubFile(filepath.ToSlash(relPath), content)
+		if err != nil || !changed {
+			return err
+		}
+		return ioutil.WriteFile(filePath, scrubbed, info.Mode())
+	})
 }
 
 /*** END DCS Customizations ***/
@@ -55,9 +55,9 @@ func TestScrubJsonFiles(t *testing.T) {
 			Message: "Initial Commit",
 		})
 		if throwsError {
-			assert.NotNil(t, scrubber.ScrubJSONFiles(repoDir))
+			assert.NotNil(t, scrubber.ScrubWorkingTree(repoDir))
 		} else {
-			assert.Nil(t, scrubber.ScrubJSONFiles(repoDir))
+			assert.Nil(t, scrubber.ScrubWorkingTree(repoDir))
 		}
 	}
 }
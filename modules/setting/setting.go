@@ -430,6 +430,16 @@ var (
 	DCS struct {
 		GATrackingID     string
 		Door43PreviewURL string
+		LangNamesURL     string
+		RCSchemaURL      string
+
+		MetadataCommitStatus bool
+		RCOwnerPrecedence    []string
+		RCLintBlockingChecks []string
+
+		ScrubIdentityName  string
+		ScrubIdentityEmail string
+		ScrubRulesFile     string
 	}
 	/*** END DCS Customizations ***/
 )
@@ -982,6 +992,23 @@ func NewContext() {
 	/*** DCS Customizations ***/
 	DCS.GATrackingID = Cfg.Section("dcs").Key("GA_TRACKING_ID").MustString("UA-60106521-5")
 	DCS.Door43PreviewURL = Cfg.Section("dcs").Key("DOOR43_PREVIEW_URL").MustString("https://door43.org")
+	DCS.LangNamesURL = Cfg.Section("dcs").Key("LANGNAMES_URL").MustString("https://td.unfoldingword.org/exports/langnames.json")
+	DCS.RCSchemaURL = Cfg.Section("dcs").Key("RC_SCHEMA_URL").MustString("https://raw.githubusercontent.com/unfoldingWord/rc-schema/master/rc.schema.json")
+	DCS.MetadataCommitStatus = Cfg.Section("dcs").Key("METADATA_COMMIT_STATUS").MustBool(false)
+	DCS.RCOwnerPrecedence = Cfg.Section("dcs").Key("RC_OWNER_PRECEDENCE").Strings(",")
+	if !Cfg.Section("dcs").HasKey("RC_OWNER_PRECEDENCE") {
+		DCS.RCOwnerPrecedence = []string{"unfoldingWord", "Door43-Catalog"}
+	}
+	DCS.RCLintBlockingChecks = Cfg.Section("dcs").Key("RC_LINT_BLOCKING_CHECKS").Strings(",")
+	if !Cfg.Section("dcs").HasKey("RC_LINT_BLOCKING_CHECKS") {
+		DCS.RCLintBlockingChecks = []string{"project_path", "project_format", "usfm_id", "usfm_chapters", "tsv_columns", "markdown_layout"}
+	}
+	DCS.ScrubIdentityName = Cfg.Section("dcs").Key("SCRUB_IDENTITY_NAME").MustString("Door43")
+	DCS.ScrubIdentityEmail = Cfg.Section("dcs").Key("SCRUB_IDENTITY_EMAIL").MustString("commit@door43.org")
+	DCS.ScrubRulesFile = Cfg.Section("dcs").Key("SCRUB_RULES_FILE").MustString("")
+	if DCS.ScrubRulesFile != "" && !filepath.IsAbs(DCS.ScrubRulesFile) {
+		DCS.ScrubRulesFile = filepath.Join(CustomPath, DCS.ScrubRulesFile)
+	}
 	/*** END DCS Customizations ***/
 
 	HasRobotsTxt, err = util.IsFile(path.Join(CustomPath, "robots.txt"))
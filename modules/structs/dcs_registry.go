@@ -0,0 +1,57 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// DCSSubject represents a subject of the subject registry
+type DCSSubject struct {
+	ResourceID string `json:"resource_id"`
+	Title      string `json:"title"`
+	// resource ID of the subject this is another name for, empty if it is not an alias
+	AliasOf string `json:"alias_of"`
+	// repos are no longer recognized as being of a deprecated subject by their name
+	Deprecated bool `json:"deprecated"`
+	// whether the subject is one of the subjects DCS knows of out of the box
+	Builtin bool `json:"builtin"`
+}
+
+// CreateDCSSubjectOption options for adding a subject to the subject registry
+type CreateDCSSubjectOption struct {
+	// resource ID of the subject, e.g. obs-twl
+	// required: true
+	ResourceID string `json:"resource_id" binding:"Required;MaxSize(50)"`
+	// required unless it is an alias
+	Title string `json:"title" binding:"MaxSize(255)"`
+	// resource ID of the subject this is another name for
+	AliasOf string `json:"alias_of" binding:"MaxSize(50)"`
+}
+
+// EditDCSSubjectOption options for editing a subject of the subject registry
+type EditDCSSubjectOption struct {
+	Title *string `json:"title" binding:"MaxSize(255)"`
+	// resource ID of the subject this is another name for, empty for it not to be an alias
+	AliasOf    *string `json:"alias_of" binding:"MaxSize(50)"`
+	Deprecated *bool   `json:"deprecated"`
+}
+
+// DCSLanguage represents a language of the language registry
+type DCSLanguage struct {
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	AnglicizedName string `json:"anglicized_name"`
+	// ltr or rtl
+	Direction string `json:"direction"`
+	Gateway   bool   `json:"gateway"`
+	// whether a site admin overrode the language, so it is no longer updated from langnames.json
+	Overridden bool `json:"overridden"`
+}
+
+// EditDCSLanguageOption options for overriding a language of the language registry
+type EditDCSLanguageOption struct {
+	Name           *string `json:"name" binding:"MaxSize(255)"`
+	AnglicizedName *string `json:"anglicized_name" binding:"MaxSize(255)"`
+	// ltr or rtl
+	Direction *string `json:"direction" binding:"OmitEmpty;In(ltr,rtl)"`
+	Gateway   *bool   `json:"gateway"`
+}
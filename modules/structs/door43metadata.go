@@ -4,6 +4,10 @@
 
 package structs
 
+import (
+	"time"
+)
+
 // Door43MetadataV4 represents a repository's metadata of a tag or default branch
 type Door43MetadataV4 struct {
 	ID                     int64         `json:"id"`
@@ -47,10 +51,13 @@ type Door43MetadataV5 struct {
 	MetadataURL            string        `json:"metadata_url"`
 	MetadataJSONURL        string        `json:"metadata_json_url"`
 	MetadataAPIContentsURL string        `json:"metadata_api_contents_url"`
+	MetadataType           string        `json:"metadata_type"`
 	MetadataVersion        string        `json:"metadata_version"`
 	Released               string        `json:"released"`
 	Books                  []string      `json:"books"`
 	Ingredients            []interface{} `json:"ingredients,omitempty"`
+	// statistics of the USFM files of the books of a Bible, only given for a single entry
+	BookStats []*Door43MetadataBookStats `json:"book_stats,omitempty"`
 }
 
 // CatalogSearchResultsV4 results of a successful search for V4
@@ -63,6 +70,86 @@ type CatalogSearchResultsV4 struct {
 type CatalogSearchResultsV5 struct {
 	OK   bool                `json:"ok"`
 	Data []*Door43MetadataV5 `json:"data"`
+	// number of entries matching the search for each value of each of the requested facets, the most common first
+	Facets map[string][]*CatalogFacetCount `json:"facets,omitempty"`
+}
+
+// CatalogFacetCount the number of entries matching a catalog search that have a value of a facet
+type CatalogFacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// CatalogChangeV5 represents a change made to a catalog entry
+type CatalogChangeV5 struct {
+	// cursor of the change, pass it as `after` to get the changes made after it
+	ID int64 `json:"id"`
+	// "created", "updated" or "deleted"
+	Type        string `json:"type"`
+	EntryID     int64  `json:"entry_id"`
+	Owner       string `json:"owner"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	BranchOrTag string `json:"branch_or_tag_name"`
+	// swagger:strfmt date-time
+	Changed time.Time `json:"changed"`
+	// the entry as it is now, omitted if it has been deleted since
+	Entry *Door43MetadataV5 `json:"entry,omitempty"`
+}
+
+// CatalogChangesV5 results of a successful request for the catalog changes
+type CatalogChangesV5 struct {
+	OK   bool               `json:"ok"`
+	Data []*CatalogChangeV5 `json:"data"`
+	// cursor to get the next page of changes with, the same as `after` if there are none yet
+	NextCursor int64 `json:"next_cursor"`
+}
+
+// WithdrawnCatalogEntryV5 represents a catalog entry that has been deleted from the catalog
+type WithdrawnCatalogEntryV5 struct {
+	// id the entry had in the catalog
+	EntryID         int64  `json:"entry_id"`
+	Owner           string `json:"owner"`
+	Name            string `json:"name"`
+	FullName        string `json:"full_name"`
+	BranchOrTag     string `json:"branch_or_tag_name"`
+	MetadataType    string `json:"metadata_type"`
+	MetadataVersion string `json:"metadata_version"`
+	Title           string `json:"title"`
+	Subject         string `json:"subject"`
+	Language        string `json:"language"`
+	Stage           string `json:"stage"`
+	// "repo_deleted", "made_private", "archived", "invalid_manifest", "invalid_container", "release_deleted" or "branch_removed"
+	Reason string `json:"reason"`
+	// swagger:strfmt date-time
+	Withdrawn time.Time `json:"withdrawn"`
+}
+
+// CatalogWithdrawnV5 results of a successful request for the withdrawn catalog entries
+type CatalogWithdrawnV5 struct {
+	OK   bool                       `json:"ok"`
+	Data []*WithdrawnCatalogEntryV5 `json:"data"`
+}
+
+// CatalogRelationV5 represents a resource a catalog entry relates to, such as a source text it depends on, and the
+// catalog entry it resolves to
+type CatalogRelationV5 struct {
+	// relation as listed in the entry's metadata, e.g. "en/tw?v=21"
+	Relation   string `json:"relation"`
+	Language   string `json:"language"`
+	Identifier string `json:"identifier"`
+	// version of the release the relation is to, empty for the latest release
+	Version string `json:"version,omitempty"`
+	// "resolved" if it resolves to a production release, "unpublished" if only to a prerelease, draft or default
+	// branch, or "dangling" if the resource is not in the catalog
+	Status string            `json:"status"`
+	Entry  *Door43MetadataV5 `json:"entry,omitempty"`
+}
+
+// CatalogRelationsV5 results of a successful request for the relations of a catalog entry
+type CatalogRelationsV5 struct {
+	OK   bool                 `json:"ok"`
+	Data []*CatalogRelationV5 `json:"data"`
 }
 
 // CatalogVersionEndpoints Info on the versions of the catalog
@@ -93,3 +180,67 @@ type CatalogStage struct {
 	ZipballURL string  `json:"zipball_url"`
 	TarballURL string  `json:"tarball_url"`
 }
+
+// Door43MetadataValidation represents the outcome of validating the metadata file of a repo's release or default branch
+type Door43MetadataValidation struct {
+	Ref string `json:"ref"`
+	// "branch" or "tag"
+	RefType      string `json:"ref_type"`
+	CommitSHA    string `json:"commit_sha"`
+	MetadataType string `json:"metadata_type"`
+	Filename     string `json:"filename"`
+	// name and checksum of the schema the metadata file was validated by
+	Schema  string                           `json:"schema"`
+	IsValid bool                             `json:"is_valid"`
+	Errors  []*Door43MetadataValidationError `json:"errors"`
+	// problems found with the files the metadata file lists, such as missing project files
+	Findings []*Door43MetadataFinding `json:"findings"`
+	// whether the metadata file is valid and no finding is an error, so the ref can be in the catalog
+	IsPublishable bool `json:"is_publishable"`
+	// swagger:strfmt date-time
+	Validated time.Time `json:"validated"`
+}
+
+// Door43MetadataFinding represents a problem found when linting the files listed in a metadata file
+type Door43MetadataFinding struct {
+	// the check that found it, e.g. "project_path" or "usfm_chapters"
+	Check string `json:"check"`
+	// "error" if it keeps the ref out of the catalog, "warning" if it is only flagged
+	Severity string `json:"severity"`
+	// path of the file or directory in the repo
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Door43MetadataValidationError represents a schema error of a metadata file
+type Door43MetadataValidationError struct {
+	// path of the field in the metadata, e.g. "dublin_core.language"
+	Field       string      `json:"field"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`
+}
+
+// Door43MetadataBookStats represents the statistics of the USFM file of a book of the Bible of a catalog entry
+type Door43MetadataBookStats struct {
+	// identifier of the book, e.g. "gen"
+	Book string `json:"book"`
+	// path of the USFM file in the repo
+	Path string `json:"path"`
+	// number of chapters with at least one verse
+	Chapters int `json:"chapters"`
+	// number of chapters of the book in the English versification
+	ExpectedChapters int `json:"expected_chapters"`
+	// number of verses that have text
+	Verses int `json:"verses"`
+	// number of verses of the book in the English versification
+	ExpectedVerses int `json:"expected_verses"`
+	// percentage of the expected verses that have text
+	VersePercent float64 `json:"verse_percent"`
+	// number of words of the verses, leaving out notes and headings
+	Words int `json:"words"`
+	// number of words aligned to the original language with \zaln markers
+	AlignedWords int `json:"aligned_words"`
+	// percentage of the words that are aligned
+	AlignmentPercent float64 `json:"alignment_percent"`
+}
@@ -265,6 +265,34 @@ func (p *ReleasePayload) JSONPayload() ([]byte, error) {
 	return json.MarshalIndent(p, "", "  ")
 }
 
+/*** DCS Customizations ***/
+
+// HookCatalogAction defines hook catalog action type
+type HookCatalogAction string
+
+// all catalog actions
+const (
+	HookCatalogCreated HookCatalogAction = "created"
+	HookCatalogUpdated HookCatalogAction = "updated"
+	HookCatalogDeleted HookCatalogAction = "deleted"
+)
+
+// CatalogPayload represents a payload information of catalog event, sent when a catalog entry of a repo's release
+// or default branch is created, updated or deleted
+type CatalogPayload struct {
+	Action     HookCatalogAction `json:"action"`
+	Entry      *Door43MetadataV5 `json:"entry"`
+	Repository *Repository       `json:"repository"`
+}
+
+// JSONPayload implements Payload
+func (p *CatalogPayload) JSONPayload() ([]byte, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	return json.MarshalIndent(p, "", "  ")
+}
+
+/*** END DCS Customizations ***/
+
 // __________             .__
 // \______   \__ __  _____|  |__
 //  |     ___/  |  \/  ___/  |  \
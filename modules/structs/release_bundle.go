@@ -0,0 +1,56 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// CreateReleaseBundleOption options for releasing a set of repos of an org together
+type CreateReleaseBundleOption struct {
+	// name of the bundle, e.g. "en ULT+UST+TN+TQ+TW+TA v41"
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+	// tag of the release of every repo
+	// required: true
+	TagName string `json:"tag_name" binding:"Required;MaxSize(255)"`
+	// branch to release in every repo, the default branch of each repo if empty
+	Target string `json:"target_branch"`
+	// title of the release of every repo, the name of the bundle if empty
+	Title        string `json:"title" binding:"MaxSize(255)"`
+	Note         string `json:"body"`
+	IsPrerelease bool   `json:"prerelease"`
+	// names of the repos of the org to release
+	// required: true
+	Repos []string `json:"repos" binding:"Required"`
+}
+
+// ReleaseBundleMember represents the release of a repo made by a release bundle
+type ReleaseBundleMember struct {
+	// full name of the repo when it was released
+	Repo      string `json:"repo"`
+	ReleaseID int64  `json:"release_id"`
+	// catalog entry of the release, null if it is not in the catalog, such as when it is still being processed
+	Entry *Door43MetadataV5 `json:"entry"`
+}
+
+// ReleaseBundle represents a release of a set of repos of an org made together with the same tag
+type ReleaseBundle struct {
+	ID           int64                  `json:"id"`
+	Owner        string                 `json:"owner"`
+	Name         string                 `json:"name"`
+	TagName      string                 `json:"tag_name"`
+	Title        string                 `json:"title"`
+	Note         string                 `json:"body"`
+	IsPrerelease bool                   `json:"prerelease"`
+	Publisher    *User                  `json:"publisher"`
+	Members      []*ReleaseBundleMember `json:"members"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// CatalogBundlesV5 results of a successful request for the release bundles of the catalog
+type CatalogBundlesV5 struct {
+	OK   bool             `json:"ok"`
+	Data []*ReleaseBundle `json:"data"`
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// ScrubRule represents a rule of what to scrub from the history of a repo
+type ScrubRule struct {
+	Name string `json:"name"`
+	// globs of the paths of the files to scrub. A glob without a "/" matches the file name in any directory
+	Files []string `json:"files"`
+	// dot separated keys of the values to empty in JSON files. A "*" key matches every key or element and a path
+	// starting with ".." matches at any depth
+	JSONPaths []string `json:"json_paths,omitempty"`
+	// regular expressions whose matches are replaced with the replacement, which can refer to submatches with $1
+	Patterns    []string `json:"patterns,omitempty"`
+	Replacement string   `json:"replacement,omitempty"`
+	// whether the patterns also apply to the commit messages
+	CommitMessages bool `json:"commit_messages,omitempty"`
+}
+
+// ScrubRepoOption options for scrubbing every branch, tag and pull request head of a repo
+type ScrubRepoOption struct {
+	// rules to apply, the default rules of the site if empty
+	Rules []*ScrubRule `json:"rules"`
+	// name to replace the authors, committers and taggers with, empty along with the email to keep them
+	IdentityName  string `json:"identity_name" binding:"MaxSize(255)"`
+	IdentityEmail string `json:"identity_email" binding:"MaxSize(255)"`
+	// only report what would be scrubbed
+	DryRun bool `json:"dry_run"`
+}
+
+// ScrubRef represents a branch, tag or pull request head whose history is rewritten by a scrub
+type ScrubRef struct {
+	Name        string `json:"name"`
+	OldCommitID string `json:"old_commit_id"`
+	// rewritten commit, or tag object for an annotated tag, empty for a dry run
+	NewCommitID string `json:"new_commit_id,omitempty"`
+}
+
+// ScrubCommit represents a commit with something to scrub
+type ScrubCommit struct {
+	CommitID string `json:"commit_id"`
+	// first line of the scrubbed commit message
+	Summary string   `json:"summary"`
+	Files   []string `json:"files,omitempty"`
+	// whether the commit message is scrubbed
+	Message bool `json:"message,omitempty"`
+	// whether the author or committer is replaced
+	Identity bool `json:"identity,omitempty"`
+}
+
+// ScrubReport represents what a scrub changed, or would change for a dry run
+type ScrubReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Refs    []*ScrubRef    `json:"refs"`
+	Commits []*ScrubCommit `json:"commits"`
+	// paths of the files scrubbed in at least one commit
+	Files []string `json:"files"`
+}
+
+// RepoScrub represents the audit record of a scrub of a repo
+type RepoScrub struct {
+	ID int64 `json:"id"`
+	// full name of the repo when it was scrubbed
+	Repository string       `json:"repository"`
+	Doer       *User        `json:"doer"`
+	DryRun     bool         `json:"dry_run"`
+	Rules      []*ScrubRule `json:"rules"`
+	// name and email the authors and committers were replaced with, empty if they were kept
+	Identity string `json:"identity"`
+	// refs, commits and files scrubbed, null if the scrub failed before rewriting the history
+	Report *ScrubReport `json:"report"`
+	Error  string       `json:"error"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
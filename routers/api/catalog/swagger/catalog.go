@@ -36,6 +36,41 @@ type swaggerResponseCatalogEntryV5 struct {
 	Body api.Door43MetadataV5 `json:"body"`
 }
 
+// CatalogChangesV5
+// swagger:response CatalogChangesV5
+type swaggerResponseCatalogChangesV5 struct {
+	// in:body
+	Body api.CatalogChangesV5 `json:"body"`
+}
+
+// CatalogWithdrawnV5
+// swagger:response CatalogWithdrawnV5
+type swaggerResponseCatalogWithdrawnV5 struct {
+	// in:body
+	Body api.CatalogWithdrawnV5 `json:"body"`
+}
+
+// CatalogRelationsV5
+// swagger:response CatalogRelationsV5
+type swaggerResponseCatalogRelationsV5 struct {
+	// in:body
+	Body api.CatalogRelationsV5 `json:"body"`
+}
+
+// CatalogBundlesV5
+// swagger:response CatalogBundlesV5
+type swaggerResponseCatalogBundlesV5 struct {
+	// in:body
+	Body api.CatalogBundlesV5 `json:"body"`
+}
+
+// CatalogBundleV5
+// swagger:response CatalogBundleV5
+type swaggerResponseCatalogBundleV5 struct {
+	// in:body
+	Body api.ReleaseBundle `json:"body"`
+}
+
 // CatalogMetadata
 // swagger:response CatalogMetadata
 type swaggerResponseCatalogMetadata struct {
@@ -73,6 +73,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
+	"code.gitea.io/gitea/services/auth"
 
 	"gitea.com/go-chi/session"
 	"github.com/go-chi/cors"
@@ -200,6 +201,10 @@ func Routes() *web.Route {
 		}))
 	}
 	m.Use(context.APIContexter())
+
+	// Get user from session or token if logged in, so private catalog entries they can read are included
+	m.Use(context.APIAuth(auth.NewGroup(auth.Methods()...)))
+
 	m.Use(context.ToggleAPI(&context.ToggleOptions{
 		SignInRequired: setting.Service.RequireSignInView,
 	}))
@@ -44,6 +44,8 @@ func Search(ctx *context.APIContext) {
 	// swagger:operation GET /v4/search v4 catalogSearch
 	// ---
 	// summary: Catalog search
+	// description: Returns the entries of the public repos, and of the private and internal repos the authenticated
+	//              user or token can read.
 	// produces:
 	// - application/json
 	// parameters:
@@ -458,9 +460,11 @@ func searchCatalog(ctx *context.APIContext) {
 		Subjects:        QueryStrings(ctx, "subject"),
 		CheckingLevels:  QueryStrings(ctx, "checkingLevel"),
 		Books:           QueryStrings(ctx, "book"),
+		ExcludeTypes:    models.ProjectMetadataTypes,
 		IncludeHistory:  ctx.QueryBool("includeHistory"),
 		ShowIngredients: ctx.QueryBool("showIngredients"),
 		IncludeMetadata: includeMetadata,
+		Actor:           ctx.User,
 	}
 
 	var sortModes = QueryStrings(ctx, "sort")
@@ -73,6 +73,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
+	"code.gitea.io/gitea/services/auth"
 
 	"gitea.com/go-chi/session"
 	"github.com/go-chi/cors"
@@ -200,6 +201,10 @@ func Routes() *web.Route {
 		}))
 	}
 	m.Use(context.APIContexter())
+
+	// Get user from session or token if logged in, so private catalog entries they can read are included
+	m.Use(context.APIAuth(auth.NewGroup(auth.Methods()...)))
+
 	m.Use(context.ToggleAPI(&context.ToggleOptions{
 		SignInRequired: setting.Service.RequireSignInView,
 	}))
@@ -223,9 +228,31 @@ func Routes() *web.Route {
 				}, repoAssignment())
 			})
 		})
+		m.Group("/changes", func() {
+			m.Get("", ListChanges)
+			m.Get("/feed.atom", ListChangesAtom)
+			m.Get("/feed.json", ListChangesJSONFeed)
+		})
+		m.Get("/withdrawn", ListWithdrawn)
+		m.Group("/bundles", func() {
+			m.Get("", ListBundles)
+			m.Get("/{id}", GetBundle)
+		})
+		m.Group("/opds", func() {
+			m.Get("/opensearch.xml", OPDSOpenSearch)
+			m.Group("/{version}", func() {
+				m.Get("", OPDSRoot)
+				m.Get("/languages", OPDSLanguages)
+				m.Get("/subjects", OPDSSubjects)
+				m.Get("/publications", OPDSPublications)
+			})
+		})
 		m.Group("/entry/{username}/{reponame}/{tag}", func() {
 			m.Get("", GetCatalogEntry)
 			m.Get("/metadata", GetCatalogMetadata)
+			m.Get("/archive/{archive}", GetCatalogEntryArchive)
+			m.Get("/relations", GetCatalogEntryRelations)
+			m.Get("/dependents", GetCatalogEntryDependents)
 		}, repoAssignment())
 	}, sudo())
 
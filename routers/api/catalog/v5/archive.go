@@ -0,0 +1,190 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/door43metadata"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	archiver_service "code.gitea.io/gitea/services/archiver"
+)
+
+// GetCatalogEntryArchive downloads an archive of the given books of a catalog entry
+func GetCatalogEntryArchive(ctx *context.APIContext) {
+	// swagger:operation GET /v5/entry/{owner}/{repo}/{tag}/archive/{archive} v5 v5GetCatalogEntryArchive
+	// ---
+	// summary: Archive of only some of the books of a catalog entry
+	// description: Archives the given books of the entry along with its metadata file and its root files such as
+	//              the license and media.yaml. Archives are cached, so the same books are only archived once.
+	// produces:
+	// - application/octet-stream
+	// - application/zip
+	// - application/gzip
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: name of the owner
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: tag
+	//   in: path
+	//   description: release tag, default branch or other branch in the catalog
+	//   type: string
+	//   required: true
+	// - name: archive
+	//   in: path
+	//   description: the archive format
+	//   type: string
+	//   enum: [zip, tar.gz]
+	//   required: true
+	// - name: book
+	//   in: query
+	//   description: book (project id) to include in the archive, can be repeated or a comma-separated list
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: success
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	format := ctx.Params("archive")
+	if format != "zip" && format != "tar.gz" {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid archive format: \"%s\"", format))
+		return
+	}
+	books := QueryStrings(ctx, "book")
+	if len(books) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("at least one book is required"))
+		return
+	}
+
+	tag := ctx.Params("tag")
+	dm := getCatalogEntry(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	gitRepo, err := git.OpenRepository(ctx.Repo.Repository.RepoPath())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenRepository", err)
+		return
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(tag)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCommit", err)
+		}
+		return
+	}
+	paths, err := door43metadata.GetArchivePaths(dm, commit, books)
+	if err != nil {
+		if door43metadata.IsErrBookNotInEntry(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetArchivePaths", err)
+		}
+		return
+	}
+
+	aReq, err := archiver_service.NewRequestOfPaths(ctx.Repo.Repository.ID, gitRepo, tag+"."+format,
+		strings.ToLower(strings.Join(books, "-")), paths)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewRequestOfPaths", err)
+		return
+	}
+
+	archiver, err := models.GetRepoArchiverOfSubset(models.DefaultDBContext(), aReq.RepoID, aReq.Type, aReq.CommitID, aReq.Subset)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRepoArchiverOfSubset", err)
+		return
+	}
+	if archiver != nil && archiver.Status == models.RepoArchiverReady {
+		downloadArchive(ctx, aReq.GetArchiveName(), archiver)
+		return
+	}
+
+	if err := archiver_service.StartArchive(aReq); err != nil {
+		ctx.Error(http.StatusInternalServerError, "StartArchive", err)
+		return
+	}
+
+	var times int
+	var t = time.NewTicker(time.Second * 1)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-graceful.GetManager().HammerContext().Done():
+			log.Warn("exit archive download because system stop")
+			return
+		case <-t.C:
+			if times > 20 {
+				ctx.Error(http.StatusInternalServerError, "", errors.New("wait download timeout"))
+				return
+			}
+			times++
+			archiver, err = models.GetRepoArchiverOfSubset(models.DefaultDBContext(), aReq.RepoID, aReq.Type, aReq.CommitID, aReq.Subset)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "GetRepoArchiverOfSubset", err)
+				return
+			}
+			if archiver != nil && archiver.Status == models.RepoArchiverReady {
+				downloadArchive(ctx, aReq.GetArchiveName(), archiver)
+				return
+			}
+		}
+	}
+}
+
+// downloadArchive serves an archive from storage, redirecting to it if it can be served directly
+func downloadArchive(ctx *context.APIContext, archiveName string, archiver *models.RepoArchiver) {
+	downloadName := ctx.Repo.Repository.Name + "-" + archiveName
+
+	rPath, err := archiver.RelativePath()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "RelativePath", err)
+		return
+	}
+
+	if setting.RepoArchive.ServeDirect {
+		// If we have a signed url (S3, object storage), redirect to this directly.
+		u, err := storage.RepoArchives.URL(rPath, downloadName)
+		if u != nil && err == nil {
+			ctx.Redirect(u.String())
+			return
+		}
+	}
+
+	fr, err := storage.RepoArchives.Open(rPath)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Open", err)
+		return
+	}
+	defer fr.Close()
+	ctx.ServeStream(fr, downloadName)
+}
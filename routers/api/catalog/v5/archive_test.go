@@ -0,0 +1,51 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCatalogEntryArchive(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	// an entry of a branch that no longer exists
+	assert.NoError(t, models.InsertDoor43Metadata(&models.Door43Metadata{
+		RepoID:          1,
+		MetadataType:    models.MetadataTypeRC,
+		MetadataVersion: "rc0.2",
+		Metadata:        &map[string]interface{}{},
+		Stage:           models.StageBranch,
+		BranchOrTag:     "deleted-branch",
+		Books:           []string{"gen"},
+	}))
+
+	for _, tc := range []struct {
+		tag    string
+		book   string
+		status int
+	}{
+		{"deleted-branch", "gen", http.StatusNotFound},
+		{"no-entry", "gen", http.StatusNotFound},
+		{"deleted-branch", "", http.StatusUnprocessableEntity},
+	} {
+		ctx := test.MockContext(t, "api/catalog/v5/entry/user2/repo1/"+tc.tag+"/archive/zip")
+		test.LoadRepo(t, ctx, 1)
+		ctx.SetParams("tag", tc.tag)
+		ctx.SetParams("archive", "zip")
+		if tc.book != "" {
+			ctx.Req.Form.Set("book", tc.book)
+		}
+		GetCatalogEntryArchive(&context.APIContext{Context: ctx})
+		assert.EqualValues(t, tc.status, ctx.Resp.Status(), tc.tag)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListBundles lists the release bundles, which are sets of repos of an org released together
+func ListBundles(ctx *context.APIContext) {
+	// swagger:operation GET /v5/bundles v5 v5ListBundles
+	// ---
+	// summary: Release bundles, each a set of repos of an org released together with the same tag
+	// description: Lists the bundles, the most recently released first, with the catalog entries of their releases.
+	//              A release that is not in the catalog, such as one still being processed, has a null entry.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: query
+	//   description: owner of the repos of the bundles
+	//   type: string
+	// - name: tag
+	//   in: query
+	//   description: tag of the releases of the bundles
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogBundlesV5"
+
+	opts := &models.FindReleaseBundlesOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		TagName:     ctx.Query("tag"),
+		Actor:       ctx.User,
+	}
+	if ownerName := ctx.Query("owner"); ownerName != "" {
+		owner, err := models.GetUserByName(ownerName)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.JSON(http.StatusOK, api.CatalogBundlesV5{
+					OK:   true,
+					Data: []*api.ReleaseBundle{},
+				})
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+		opts.OwnerID = owner.ID
+	}
+
+	bundles, count, err := models.FindReleaseBundles(opts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, api.SearchError{
+			OK:    false,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	results := make([]*api.ReleaseBundle, len(bundles))
+	for i, bundle := range bundles {
+		if results[i], err = toCatalogBundle(ctx, bundle); err != nil {
+			ctx.Error(http.StatusInternalServerError, "toCatalogBundle", err)
+			return
+		}
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, api.CatalogBundlesV5{
+		OK:   true,
+		Data: results,
+	})
+}
+
+// GetBundle gets a release bundle with the catalog entries of its releases
+func GetBundle(ctx *context.APIContext) {
+	// swagger:operation GET /v5/bundles/{id} v5 v5GetBundle
+	// ---
+	// summary: A release bundle, a set of repos of an org released together with the same tag
+	// description: Returns the bundle with the catalog entries of its releases. A release that is not in the catalog,
+	//              such as one still being processed, has a null entry.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the bundle
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogBundleV5"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	bundle, err := models.GetReleaseBundleByID(ctx.ParamsInt64("id"))
+	if err != nil {
+		if models.IsErrReleaseBundleNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetReleaseBundleByID", err)
+		}
+		return
+	}
+	result, err := toCatalogBundle(ctx, bundle)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "toCatalogBundle", err)
+		return
+	}
+	if len(result.Members) == 0 && !(ctx.IsSigned && ctx.User.IsAdmin) {
+		ctx.NotFound()
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// toCatalogBundle converts a release bundle to one with the members the user can read, along with their catalog entries
+func toCatalogBundle(ctx *context.APIContext, bundle *models.ReleaseBundle) (*api.ReleaseBundle, error) {
+	result := convert.ToReleaseBundle(bundle, ctx.User)
+	members := make([]*api.ReleaseBundleMember, 0, len(result.Members))
+	for i, member := range bundle.Members {
+		if member.Repo == nil {
+			continue
+		}
+		accessMode, err := models.AccessLevel(ctx.User, member.Repo)
+		if err != nil {
+			return nil, err
+		}
+		if accessMode < models.AccessModeRead {
+			continue
+		}
+		dm, err := member.GetDoor43Metadata()
+		if err != nil {
+			return nil, err
+		}
+		if dm != nil {
+			result.Members[i].Entry = convert.ToDoor43MetadataV5(dm, accessMode)
+		}
+		members = append(members, result.Members[i])
+	}
+	result.Members = members
+	return result, nil
+}
@@ -12,7 +12,9 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/dcs"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 )
 
@@ -44,6 +46,8 @@ func Search(ctx *context.APIContext) {
 	// swagger:operation GET /v5/search v5 v5Search
 	// ---
 	// summary: Catalog search
+	// description: Returns the entries of the public repos, and of the private and internal repos the authenticated
+	//              user or token can read.
 	// produces:
 	// - application/json
 	// parameters:
@@ -63,21 +67,32 @@ func Search(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given release tag(s)
 	//   type: string
+	// - name: branch
+	//   in: query
+	//   description: search only for entries of the given branch(es), either the default branch or other branches
+	//                in the catalog when stage is "branch"
+	//   type: string
 	// - name: lang
 	//   in: query
 	//   description: search only for entries with the given language(s)
 	//   type: string
+	// - name: gatewayLanguage
+	//   in: query
+	//   description: if true, search only for entries in a gateway language, if false, only for entries in other languages
+	//   type: boolean
 	// - name: stage
 	//   in: query
 	//   description: 'specifies which release stage to be return of these stages:
 	//                "prod" - return only the production releases (default);
 	//                "preprod" - return the pre-production release if it exists instead of the production release;
 	//                "draft" - return the draft release if it exists instead of pre-production or production release;
-	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above'
+	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above;
+	//                "branch" - also return the other branches repos have in the catalog, such as review branches'
 	//   type: string
 	// - name: subject
 	//   in: query
-	//   description: search only for entries with the given subject(s). Must match the entire string (case insensitive)
+	//   description: search only for entries with the given subject(s), by title or resource ID. Must match the entire
+	//                string (case insensitive). A subject also matches the subjects that are aliases of it
 	//   type: string
 	// - name: checkingLevel
 	//   in: query
@@ -87,6 +102,13 @@ func Search(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given book(s) (project ids)
 	//   type: string
+	// - name: metadataType
+	//   in: query
+	//   description: search only for entries with the given metadata type(s). Supported values are
+	//                "rc" (Resource Container, manifest.yaml), "sb" (Scripture Burrito, metadata.json),
+	//                "ts" (translationStudio project, manifest.json) and "tc" (translationCore project, manifest.json).
+	//                Use "rc" to exclude in-progress translationStudio and translationCore projects
+	//   type: string
 	// - name: includeHistory
 	//   in: query
 	//   description: if true, all releases, not just the latest, are included. Default is false
@@ -110,6 +132,18 @@ func Search(ctx *context.APIContext) {
 	//   description: sort order, either "asc" (ascending) or "desc" (descending).
 	//                Default is "asc", ignored if "sort" is not specified.
 	//   type: string
+	// - name: version
+	//   in: query
+	//   description: 'search only for entries with a release tag version satisfying the constraint, e.g. "~41" or "41.x"
+	//                (any 41 version), "^41.2", ">=40 <42" or "40 || 42", returning the latest entry of each repo that does
+	//                unless includeHistory is true. Tags can be semantic versions or dates (e.g. "2021-05-18").
+	//                "latest" is the same as not giving a constraint'
+	//   type: string
+	// - name: facets
+	//   in: query
+	//   description: 'also return the number of entries matching the search (of all pages) for each value of the given
+	//                field(s) in "facets". Supported values are "lang", "subject", "owner", "book", "checkingLevel" and "stage"'
+	//   type: string
 	// - name: page
 	//   in: query
 	//   description: page number of results to return (1-based)
@@ -152,21 +186,32 @@ func SearchOwner(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given release tag(s)
 	//   type: string
+	// - name: branch
+	//   in: query
+	//   description: search only for entries of the given branch(es), either the default branch or other branches
+	//                in the catalog when stage is "branch"
+	//   type: string
 	// - name: lang
 	//   in: query
 	//   description: search only for entries with the given language(s)
 	//   type: string
+	// - name: gatewayLanguage
+	//   in: query
+	//   description: if true, search only for entries in a gateway language, if false, only for entries in other languages
+	//   type: boolean
 	// - name: stage
 	//   in: query
 	//   description: 'specifies which release stage to be return of these stages:
 	//                "prod" - return only the production releases (default);
 	//                "preprod" - return the pre-production release if it exists instead of the production release;
 	//                "draft" - return the draft release if it exists instead of pre-production or production release;
-	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above'
+	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above;
+	//                "branch" - also return the other branches repos have in the catalog, such as review branches'
 	//   type: string
 	// - name: subject
 	//   in: query
-	//   description: search only for entries with the given subject(s). Must match the entire string (case insensitive)
+	//   description: search only for entries with the given subject(s), by title or resource ID. Must match the entire
+	//                string (case insensitive). A subject also matches the subjects that are aliases of it
 	//   type: string
 	// - name: checkingLevel
 	//   in: query
@@ -176,6 +221,13 @@ func SearchOwner(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given book(s) (project ids)
 	//   type: string
+	// - name: metadataType
+	//   in: query
+	//   description: search only for entries with the given metadata type(s). Supported values are
+	//                "rc" (Resource Container, manifest.yaml), "sb" (Scripture Burrito, metadata.json),
+	//                "ts" (translationStudio project, manifest.json) and "tc" (translationCore project, manifest.json).
+	//                Use "rc" to exclude in-progress translationStudio and translationCore projects
+	//   type: string
 	// - name: includeHistory
 	//   in: query
 	//   description: if true, all releases, not just the latest, are included. Default is false
@@ -199,6 +251,18 @@ func SearchOwner(ctx *context.APIContext) {
 	//   description: sort order, either "asc" (ascending) or "desc" (descending).
 	//                Default is "asc", ignored if "sort" is not specified.
 	//   type: string
+	// - name: version
+	//   in: query
+	//   description: 'search only for entries with a release tag version satisfying the constraint, e.g. "~41" or "41.x"
+	//                (any 41 version), "^41.2", ">=40 <42" or "40 || 42", returning the latest entry of each repo that does
+	//                unless includeHistory is true. Tags can be semantic versions or dates (e.g. "2021-05-18").
+	//                "latest" is the same as not giving a constraint'
+	//   type: string
+	// - name: facets
+	//   in: query
+	//   description: 'also return the number of entries matching the search (of all pages) for each value of the given
+	//                field(s) in "facets". Supported values are "lang", "subject", "owner", "book", "checkingLevel" and "stage"'
+	//   type: string
 	// - name: page
 	//   in: query
 	//   description: page number of results to return (1-based)
@@ -242,21 +306,32 @@ func SearchRepo(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given release tag(s)
 	//   type: string
+	// - name: branch
+	//   in: query
+	//   description: search only for entries of the given branch(es), either the default branch or other branches
+	//                in the catalog when stage is "branch"
+	//   type: string
 	// - name: lang
 	//   in: query
 	//   description: search only for entries with the given language(s)
 	//   type: string
+	// - name: gatewayLanguage
+	//   in: query
+	//   description: if true, search only for entries in a gateway language, if false, only for entries in other languages
+	//   type: boolean
 	// - name: stage
 	//   in: query
 	//   description: 'specifies which release stage to be return of these stages:
 	//                "prod" - return only the production releases (default);
 	//                "preprod" - return the pre-production release if it exists instead of the production release;
 	//                "draft" - return the draft release if it exists instead of pre-production or production release;
-	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above'
+	//                "latest" -return the default branch (e.g. master) if it is a valid RC instead of the above;
+	//                "branch" - also return the other branches repos have in the catalog, such as review branches'
 	//   type: string
 	// - name: subject
 	//   in: query
-	//   description: search only for entries with the given subject(s). Must match the entire string (case insensitive)
+	//   description: search only for entries with the given subject(s), by title or resource ID. Must match the entire
+	//                string (case insensitive). A subject also matches the subjects that are aliases of it
 	//   type: string
 	// - name: checkingLevel
 	//   in: query
@@ -266,6 +341,13 @@ func SearchRepo(ctx *context.APIContext) {
 	//   in: query
 	//   description: search only for entries with the given book(s) (project ids)
 	//   type: string
+	// - name: metadataType
+	//   in: query
+	//   description: search only for entries with the given metadata type(s). Supported values are
+	//                "rc" (Resource Container, manifest.yaml), "sb" (Scripture Burrito, metadata.json),
+	//                "ts" (translationStudio project, manifest.json) and "tc" (translationCore project, manifest.json).
+	//                Use "rc" to exclude in-progress translationStudio and translationCore projects
+	//   type: string
 	// - name: includeHistory
 	//   in: query
 	//   description: if true, all releases, not just the latest, are included. Default is false
@@ -289,6 +371,18 @@ func SearchRepo(ctx *context.APIContext) {
 	//   description: sort order, either "asc" (ascending) or "desc" (descending).
 	//                Default is "asc", ignored if "sort" is not specified.
 	//   type: string
+	// - name: version
+	//   in: query
+	//   description: 'search only for entries with a release tag version satisfying the constraint, e.g. "~41" or "41.x"
+	//                (any 41 version), "^41.2", ">=40 <42" or "40 || 42", returning the latest entry of each repo that does
+	//                unless includeHistory is true. Tags can be semantic versions or dates (e.g. "2021-05-18").
+	//                "latest" is the same as not giving a constraint'
+	//   type: string
+	// - name: facets
+	//   in: query
+	//   description: 'also return the number of entries matching the search (of all pages) for each value of the given
+	//                field(s) in "facets". Supported values are "lang", "subject", "owner", "book", "checkingLevel" and "stage"'
+	//   type: string
 	// - name: page
 	//   in: query
 	//   description: page number of results to return (1-based)
@@ -326,7 +420,7 @@ func GetCatalogEntry(ctx *context.APIContext) {
 	//   required: true
 	// - name: tag
 	//   in: path
-	//   description: release tag or default branch
+	//   description: release tag, default branch or other branch in the catalog
 	//   type: string
 	//   required: true
 	// responses:
@@ -336,13 +430,7 @@ func GetCatalogEntry(ctx *context.APIContext) {
 	//     "$ref": "#/responses/validationError"
 
 	tag := ctx.Params("tag")
-	var dm *models.Door43Metadata
-	var err error
-	if tag == ctx.Repo.Repository.DefaultBranch {
-		dm, err = models.GetDoor43MetadataByRepoIDAndReleaseID(ctx.Repo.Repository.ID, 0)
-	} else {
-		dm, err = models.GetDoor43MetadataByRepoIDAndTagName(ctx.Repo.Repository.ID, tag)
-	}
+	dm, err := models.GetDoor43MetadataByRepoAndRef(ctx.Repo.Repository, tag)
 	if err != nil {
 		ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataByRepoIDAndTagName", err)
 		return
@@ -358,10 +446,19 @@ func GetCatalogEntry(ctx *context.APIContext) {
 			Error: err.Error(),
 		})
 	}
-	ctx.JSON(http.StatusOK, convert.ToDoor43MetadataV5(dm, accessMode))
+	entry := convert.ToDoor43MetadataV5(dm, accessMode)
+	stats, err := models.GetDoor43MetadataBookStats(dm.RepoID, tag)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataBookStats", err)
+		return
+	}
+	for _, s := range stats {
+		entry.BookStats = append(entry.BookStats, convert.ToDoor43MetadataBookStats(s))
+	}
+	ctx.JSON(http.StatusOK, entry)
 }
 
-// GetCatalogMetadata Get the metadata (RC 0.2.0 manifest) in JSON format for the given ownername, reponame and ref
+// GetCatalogMetadata Get the metadata (RC 0.2.0 manifest or SB metadata.json) in JSON format for the given ownername, reponame and ref
 func GetCatalogMetadata(ctx *context.APIContext) {
 	// swagger:operation GET /v5/entry/{owner}/{repo}/{tag}/metadata v5 v5GetMetadata
 	// ---
@@ -381,23 +478,46 @@ func GetCatalogMetadata(ctx *context.APIContext) {
 	//   required: true
 	// - name: tag
 	//   in: path
-	//   description: release tag or default branch
+	//   description: release tag, default branch or other branch in the catalog
 	//   type: string
 	//   required: true
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/CatalogMetadata"
-	//   "422":
-	//     "$ref": "#/responses/validationError"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
 
-	dm, err := models.GetDoor43MetadataByRepoIDAndTagName(ctx.Repo.Repository.ID, ctx.Repo.TagName)
-	if err != nil {
-		ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataByRepoIDAndTagName", err)
+	dm := getCatalogEntry(ctx)
+	if ctx.Written() {
 		return
 	}
 	ctx.JSON(http.StatusOK, dm.Metadata)
 }
 
+// getCatalogEntry returns the catalog entry of the repo's tag or branch in the path, responding with a
+// not found error if there is none
+func getCatalogEntry(ctx *context.APIContext) *models.Door43Metadata {
+	tag := ctx.Params("tag")
+	dm, err := models.GetDoor43MetadataByRepoAndRef(ctx.Repo.Repository, tag)
+	if err != nil {
+		if models.IsErrDoor43MetadataNotExist(err) || models.IsErrReleaseNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataByRepoIDAndTagName", err)
+		}
+		return nil
+	}
+	return dm
+}
+
+// queryOptionalBool returns the boolean value of the query parameter, none if it is not given
+func queryOptionalBool(ctx *context.APIContext, name string) util.OptionalBool {
+	if ctx.Query(name) == "" {
+		return util.OptionalBoolNone
+	}
+	return util.OptionalBoolOf(ctx.QueryBool(name))
+}
+
 // QueryStrings After calling QueryStrings on the context, it also separates strings that have commas into substrings
 func QueryStrings(ctx *context.APIContext, name string) []string {
 	strs := ctx.QueryStrings(name)
@@ -445,6 +565,22 @@ func searchCatalog(ctx *context.APIContext) {
 	if query != "" {
 		keywords = models.SplitAtCommaNotInString(query, false)
 	}
+	version, err := dcs.ParseVersionConstraint(ctx.Query("version"))
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+
+	var facets []models.CatalogFacet
+	for _, facetStr := range QueryStrings(ctx, "facets") {
+		facet := models.CatalogFacet(facetStr)
+		if !facet.IsValid() {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid facet: \"%s\"", facetStr))
+			return
+		}
+		facets = append(facets, facet)
+	}
+
 	listOptions := utils.GetListOptions(ctx)
 
 	opts := &models.SearchCatalogOptions{
@@ -454,14 +590,19 @@ func searchCatalog(ctx *context.APIContext) {
 		Repos:           repos,
 		RepoID:          repoID,
 		Tags:            QueryStrings(ctx, "tag"),
+		Branches:        QueryStrings(ctx, "branch"),
 		Stage:           stage,
 		Languages:       QueryStrings(ctx, "lang"),
+		GatewayLanguage: queryOptionalBool(ctx, "gatewayLanguage"),
 		Subjects:        QueryStrings(ctx, "subject"),
 		CheckingLevels:  QueryStrings(ctx, "checkingLevel"),
 		Books:           QueryStrings(ctx, "book"),
+		MetadataTypes:   QueryStrings(ctx, "metadataType"),
+		Version:         version,
 		IncludeHistory:  ctx.QueryBool("includeHistory"),
 		ShowIngredients: ctx.QueryBool("showIngredients"),
 		IncludeMetadata: includeMetadata,
+		Actor:           ctx.User,
 	}
 
 	var sortModes = QueryStrings(ctx, "sort")
@@ -511,10 +652,30 @@ func searchCatalog(ctx *context.APIContext) {
 		}
 	}
 
+	var facetResults map[string][]*api.CatalogFacetCount
+	if len(facets) > 0 {
+		facetCounts, err := models.SearchCatalogFacets(opts, facets)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, api.SearchError{
+				OK:    false,
+				Error: err.Error(),
+			})
+			return
+		}
+		facetResults = make(map[string][]*api.CatalogFacetCount, len(facetCounts))
+		for facet, counts := range facetCounts {
+			facetResults[string(facet)] = make([]*api.CatalogFacetCount, len(counts))
+			for i, count := range counts {
+				facetResults[string(facet)][i] = &api.CatalogFacetCount{Value: count.Value, Count: count.Count}
+			}
+		}
+	}
+
 	ctx.SetLinkHeader(int(count), opts.PageSize)
 	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
 	ctx.JSON(http.StatusOK, api.CatalogSearchResultsV5{
-		OK:   true,
-		Data: results,
+		OK:     true,
+		Data:   results,
+		Facets: facetResults,
 	})
 }
@@ -0,0 +1,349 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ListChanges lists the changes made to the catalog after a cursor or since a time
+func ListChanges(ctx *context.APIContext) {
+	// swagger:operation GET /v5/changes v5 v5ListChanges
+	// ---
+	// summary: Catalog changes, to sync the catalog incrementally
+	// description: Lists the catalog entries created, updated and deleted after the `after` cursor and/or since
+	//              the `since` time, in the order the changes were made. Pass the returned `next_cursor` as `after`
+	//              to get the next page, and to get the changes made since the last request once there are no more.
+	//              The entries of a repo made private are given as deleted to those who can no longer read them.
+	//              Changes are only listed once they are 10 seconds old, so changes still being committed are not skipped.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: after
+	//   in: query
+	//   description: cursor, only return the changes made after the change with this id
+	//   type: integer
+	// - name: since
+	//   in: query
+	//   description: only return the changes made at or after this time, either in RFC 3339 format or a Unix timestamp
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogChangesV5"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts, ok := getChangesOptions(ctx)
+	if !ok {
+		return
+	}
+	changes, ok := findChanges(ctx, opts)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, api.CatalogChangesV5{
+		OK:         true,
+		Data:       changes,
+		NextCursor: getNextCursor(opts, changes),
+	})
+}
+
+// ListChangesAtom lists the changes made to the catalog after a cursor or since a time as an Atom feed
+func ListChangesAtom(ctx *context.APIContext) {
+	// swagger:operation GET /v5/changes/feed.atom v5 v5ListChangesAtom
+	// ---
+	// summary: Catalog changes as an Atom feed
+	// produces:
+	// - application/atom+xml
+	// parameters:
+	// - name: after
+	//   in: query
+	//   description: cursor, only return the changes made after the change with this id
+	//   type: integer
+	// - name: since
+	//   in: query
+	//   description: only return the changes made at or after this time, either in RFC 3339 format or a Unix timestamp
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     description: Atom feed of the catalog changes
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts, ok := getChangesOptions(ctx)
+	if !ok {
+		return
+	}
+	changes, ok := findChanges(ctx, opts)
+	if !ok {
+		return
+	}
+
+	feed := &atomFeed{
+		ID:      changesURL("", nil),
+		Title:   "DCS Catalog Changes",
+		Updated: getFeedUpdated(changes).Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Href: changesURL("/feed.atom", ctx.Req.URL.Query())},
+			{Rel: "next", Href: changesURL("/feed.atom", url.Values{"after": {strconv.FormatInt(getNextCursor(opts, changes), 10)}})},
+		},
+	}
+	for _, change := range changes {
+		entry := atomEntry{
+			ID:         fmt.Sprintf("%s#%d", changesURL("", nil), change.ID),
+			Title:      fmt.Sprintf("%s %s %s", change.FullName, change.BranchOrTag, change.Type),
+			Updated:    change.Changed.Format(time.RFC3339),
+			Categories: []atomCategory{{Term: change.Type}},
+		}
+		if change.Entry != nil {
+			entry.Summary = change.Entry.Title
+			entry.Links = []atomLink{{Rel: "alternate", Href: change.Entry.Self, Type: "application/json"}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "MarshalIndent", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write([]byte(xml.Header))
+	_, _ = ctx.Resp.Write(data)
+}
+
+// ListChangesJSONFeed lists the changes made to the catalog after a cursor or since a time as a JSON Feed
+func ListChangesJSONFeed(ctx *context.APIContext) {
+	// swagger:operation GET /v5/changes/feed.json v5 v5ListChangesJSONFeed
+	// ---
+	// summary: Catalog changes as a JSON Feed (https://jsonfeed.org/version/1.1), each item having the change in `_catalog`
+	// produces:
+	// - application/feed+json
+	// parameters:
+	// - name: after
+	//   in: query
+	//   description: cursor, only return the changes made after the change with this id
+	//   type: integer
+	// - name: since
+	//   in: query
+	//   description: only return the changes made at or after this time, either in RFC 3339 format or a Unix timestamp
+	//   type: string
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     description: JSON Feed of the catalog changes
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts, ok := getChangesOptions(ctx)
+	if !ok {
+		return
+	}
+	changes, ok := findChanges(ctx, opts)
+	if !ok {
+		return
+	}
+
+	feed := &jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       "DCS Catalog Changes",
+		HomePageURL: setting.AppURL + "catalog",
+		FeedURL:     changesURL("/feed.json", ctx.Req.URL.Query()),
+		NextURL:     changesURL("/feed.json", url.Values{"after": {strconv.FormatInt(getNextCursor(opts, changes), 10)}}),
+		Items:       make([]*jsonFeedItem, 0, len(changes)),
+	}
+	for _, change := range changes {
+		item := &jsonFeedItem{
+			ID:           strconv.FormatInt(change.ID, 10),
+			Title:        fmt.Sprintf("%s %s %s", change.FullName, change.BranchOrTag, change.Type),
+			ContentText:  fmt.Sprintf("%s %s %s", change.FullName, change.BranchOrTag, change.Type),
+			DateModified: change.Changed.Format(time.RFC3339),
+			Tags:         []string{change.Type},
+			Catalog:      change,
+		}
+		if change.Entry != nil {
+			item.URL = change.Entry.Self
+			item.ContentText = change.Entry.Title
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "MarshalIndent", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(data)
+}
+
+// getChangesOptions returns the options to find the catalog changes with from the query, writing an error if invalid
+func getChangesOptions(ctx *context.APIContext) (models.FindDoor43MetadataChangesOptions, bool) {
+	opts := models.FindDoor43MetadataChangesOptions{
+		AfterID: ctx.QueryInt64("after"),
+		Before:  timeutil.TimeStamp(time.Now().Add(-models.Door43MetadataChangeCommitLag).Unix()),
+		Limit:   convert.ToCorrectPageSize(ctx.QueryInt("limit")),
+		Actor:   ctx.User,
+	}
+	var ok bool
+	opts.Since, ok = getSince(ctx)
+	return opts, ok
+}
+
+// getSince returns the time of the since query, given as either a Unix timestamp or in RFC 3339 format, or 0 if not
+// given, writing an error if invalid
+func getSince(ctx *context.APIContext) (timeutil.TimeStamp, bool) {
+	since := ctx.Query("since")
+	if since == "" {
+		return 0, true
+	}
+	if unix, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return timeutil.TimeStamp(unix), true
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return timeutil.TimeStamp(t.Unix()), true
+	}
+	ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid since: \"%s\"", since))
+	return 0, false
+}
+
+// findChanges finds the catalog changes and converts them, writing an error if it fails
+func findChanges(ctx *context.APIContext, opts models.FindDoor43MetadataChangesOptions) ([]*api.CatalogChangeV5, bool) {
+	changes, err := models.FindDoor43MetadataChanges(opts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, api.SearchError{
+			OK:    false,
+			Error: err.Error(),
+		})
+		return nil, false
+	}
+
+	results := make([]*api.CatalogChangeV5, len(changes))
+	for i, change := range changes {
+		results[i] = &api.CatalogChangeV5{
+			ID:          change.ID,
+			Type:        change.Type.String(),
+			EntryID:     change.Door43MetadataID,
+			Owner:       change.OwnerName,
+			Name:        change.RepoName,
+			FullName:    change.FullName(),
+			BranchOrTag: change.BranchOrTag,
+			Changed:     change.CreatedUnix.AsTime(),
+		}
+		if dm := change.Door43Metadata; dm != nil {
+			if err := dm.LoadAttributes(); err != nil {
+				ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+				return nil, false
+			}
+			accessMode, err := models.AccessLevel(ctx.User, dm.Repo)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+				return nil, false
+			}
+			if accessMode >= models.AccessModeRead {
+				results[i].Entry = convert.ToDoor43MetadataV5(dm, accessMode)
+			}
+		}
+	}
+	return results, true
+}
+
+// getNextCursor returns the cursor to get the changes after the given ones with
+func getNextCursor(opts models.FindDoor43MetadataChangesOptions, changes []*api.CatalogChangeV5) int64 {
+	if len(changes) == 0 {
+		return opts.AfterID
+	}
+	return changes[len(changes)-1].ID
+}
+
+// getFeedUpdated returns the time of the latest of the changes of a feed, or now if there are none
+func getFeedUpdated(changes []*api.CatalogChangeV5) time.Time {
+	if len(changes) == 0 {
+		return time.Now()
+	}
+	return changes[len(changes)-1].Changed
+}
+
+// changesURL returns the URL of the changes endpoint with the given suffix and query
+func changesURL(suffix string, query url.Values) string {
+	u := setting.AppURL + "api/catalog/v5/changes" + suffix
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type jsonFeed struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	HomePageURL string          `json:"home_page_url"`
+	FeedURL     string          `json:"feed_url"`
+	NextURL     string          `json:"next_url"`
+	Items       []*jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID           string               `json:"id"`
+	URL          string               `json:"url,omitempty"`
+	Title        string               `json:"title"`
+	ContentText  string               `json:"content_text"`
+	DateModified string               `json:"date_modified"`
+	Tags         []string             `json:"tags"`
+	Catalog      *api.CatalogChangeV5 `json:"_catalog"`
+}
@@ -0,0 +1,655 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/dcs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// OPDS versions of the feeds, the {version} of their path
+const (
+	opdsVersion1 = "v1.2"
+	opdsVersion2 = "v2"
+)
+
+// OPDS kinds of feeds, whether they list other feeds or publications
+const (
+	opdsKindNavigation  = "navigation"
+	opdsKindAcquisition = "acquisition"
+)
+
+const (
+	opdsTitle             = "DCS Catalog"
+	opdsRelAcquisition    = "http://opds-spec.org/acquisition/open-access"
+	opdsTypeJSON          = "application/opds+json"
+	opdsTypeOpenSearch    = "application/opensearchdescription+xml"
+	opdsSchemaBookType    = "http://schema.org/Book"
+	opdsPublicationsTitle = "All publications"
+)
+
+// OPDSRoot is the start of the OPDS catalog, linking to the feeds by language and subject and of all publications
+func OPDSRoot(ctx *context.APIContext) {
+	// swagger:operation GET /v5/opds/{version} v5 v5OPDSRoot
+	// ---
+	// summary: Start of the OPDS catalog of the published (prod) catalog entries, a navigation feed
+	// produces:
+	// - application/atom+xml
+	// - application/opds+json
+	// parameters:
+	// - name: version
+	//   in: path
+	//   description: OPDS version, v1.2 for an Atom feed or v2 for a JSON feed
+	//   type: string
+	//   enum: [v1.2, v2]
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: OPDS navigation feed
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	version, ok := getOPDSVersion(ctx)
+	if !ok {
+		return
+	}
+	feed := newOPDSFeed(version, "", opdsTitle, opdsKindNavigation)
+	feed.navigation = []*opdsNavigation{
+		{title: "By language", href: opdsURL(version, "/languages", nil), kind: opdsKindNavigation},
+		{title: "By subject", href: opdsURL(version, "/subjects", nil), kind: opdsKindNavigation},
+		{title: opdsPublicationsTitle, href: opdsURL(version, "/publications", nil), kind: opdsKindAcquisition},
+	}
+	writeOPDSFeed(ctx, version, feed)
+}
+
+// OPDSLanguages lists the languages of the published catalog entries, each linking to its publications
+func OPDSLanguages(ctx *context.APIContext) {
+	// swagger:operation GET /v5/opds/{version}/languages v5 v5OPDSLanguages
+	// ---
+	// summary: OPDS navigation feed of the languages of the published (prod) catalog entries
+	// produces:
+	// - application/atom+xml
+	// - application/opds+json
+	// parameters:
+	// - name: version
+	//   in: path
+	//   description: OPDS version, v1.2 for an Atom feed or v2 for a JSON feed
+	//   type: string
+	//   enum: [v1.2, v2]
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: OPDS navigation feed
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	writeOPDSFacetFeed(ctx, models.CatalogFacetLanguage, "/languages", "Languages", func(code string) string {
+		if lang := dcs.GetLanguage(code); lang != nil && lang.Name != "" {
+			return fmt.Sprintf("%s (%s)", lang.Name, code)
+		}
+		return code
+	})
+}
+
+// OPDSSubjects lists the subjects of the published catalog entries, each linking to its publications
+func OPDSSubjects(ctx *context.APIContext) {
+	// swagger:operation GET /v5/opds/{version}/subjects v5 v5OPDSSubjects
+	// ---
+	// summary: OPDS navigation feed of the subjects of the published (prod) catalog entries
+	// produces:
+	// - application/atom+xml
+	// - application/opds+json
+	// parameters:
+	// - name: version
+	//   in: path
+	//   description: OPDS version, v1.2 for an Atom feed or v2 for a JSON feed
+	//   type: string
+	//   enum: [v1.2, v2]
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: OPDS navigation feed
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	writeOPDSFacetFeed(ctx, models.CatalogFacetSubject, "/subjects", "Subjects", func(subject string) string {
+		return subject
+	})
+}
+
+// OPDSPublications lists the published catalog entries with links to download them
+func OPDSPublications(ctx *context.APIContext) {
+	// swagger:operation GET /v5/opds/{version}/publications v5 v5OPDSPublications
+	// ---
+	// summary: OPDS acquisition feed of the published (prod) catalog entries, linking to their zipball and PDF
+	//          attachments
+	// produces:
+	// - application/atom+xml
+	// - application/opds+json
+	// parameters:
+	// - name: version
+	//   in: path
+	//   description: OPDS version, v1.2 for an Atom feed or v2 for a JSON feed
+	//   type: string
+	//   enum: [v1.2, v2]
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: keyword(s). Can use multiple `q=<keyword>`s or a comma-delimited string for more than one keyword.
+	//   type: string
+	// - name: lang
+	//   in: query
+	//   description: search only for entries with the given language(s).
+	//   type: string
+	// - name: subject
+	//   in: query
+	//   description: search only for entries with the given subject(s).
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     description: OPDS acquisition feed
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	version, ok := getOPDSVersion(ctx)
+	if !ok {
+		return
+	}
+
+	var keywords []string
+	for _, q := range QueryStrings(ctx, "q") {
+		keywords = append(keywords, models.SplitAtCommaNotInString(q, false)...)
+	}
+	opts := &models.SearchCatalogOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		Keywords:    keywords,
+		Stage:       models.StageProd,
+		Languages:   QueryStrings(ctx, "lang"),
+		Subjects:    QueryStrings(ctx, "subject"),
+		OrderBy:     []models.CatalogOrderBy{models.CatalogOrderByLangCode, models.CatalogOrderBySubject, models.CatalogOrderByTitle},
+		Actor:       ctx.User,
+	}
+	dms, count, err := models.SearchCatalog(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchCatalog", err)
+		return
+	}
+
+	title := opdsPublicationsTitle
+	if len(opts.Languages) > 0 || len(opts.Subjects) > 0 || len(keywords) > 0 {
+		title = strings.Join(append(append(append([]string{}, opts.Languages...), opts.Subjects...), keywords...), ", ")
+	}
+	feed := newOPDSFeed(version, "/publications", title, opdsKindAcquisition)
+	feed.paginate(ctx.Req.URL.Query(), opts.Page, opts.PageSize, count)
+	for i, dm := range dms {
+		publication := toOPDSPublication(dm)
+		if i == 0 || publication.updated.After(feed.updated) {
+			feed.updated = publication.updated
+		}
+		feed.publications = append(feed.publications, publication)
+	}
+	writeOPDSFeed(ctx, version, feed)
+}
+
+// OPDSOpenSearch is the OpenSearch description of how to search the OPDS catalog
+func OPDSOpenSearch(ctx *context.APIContext) {
+	// swagger:operation GET /v5/opds/opensearch.xml v5 v5OPDSOpenSearch
+	// ---
+	// summary: OpenSearch description of the search of the OPDS catalog
+	// produces:
+	// - application/opensearchdescription+xml
+	// responses:
+	//   "200":
+	//     description: OpenSearch description
+
+	description := &openSearchDescription{
+		ShortName:   opdsTitle,
+		Description: "Search the published resources of the " + opdsTitle,
+		URLs: []openSearchURL{
+			{
+				Type:     opdsFeedType(opdsVersion1, opdsKindAcquisition),
+				Template: opdsURL(opdsVersion1, "/publications", nil) + "?q={searchTerms}&page={startPage?}",
+			},
+			{
+				Type:     opdsTypeJSON,
+				Template: opdsURL(opdsVersion2, "/publications", nil) + "?q={searchTerms}&page={startPage?}",
+			},
+		},
+	}
+	writeXML(ctx, opdsTypeOpenSearch, description)
+}
+
+// getOPDSVersion returns the OPDS version of the path, writing a not found error if it isn't one
+func getOPDSVersion(ctx *context.APIContext) (string, bool) {
+	version := ctx.Params("version")
+	if version != opdsVersion1 && version != opdsVersion2 {
+		ctx.NotFound()
+		return "", false
+	}
+	return version, true
+}
+
+// writeOPDSFacetFeed writes a navigation feed of the values of a facet of the published catalog entries, each linking
+// to the feed of the publications filtered by it
+func writeOPDSFacetFeed(ctx *context.APIContext, facet models.CatalogFacet, feedPath, title string, valueTitle func(string) string) {
+	version, ok := getOPDSVersion(ctx)
+	if !ok {
+		return
+	}
+	facets, err := models.SearchCatalogFacets(&models.SearchCatalogOptions{
+		Stage: models.StageProd,
+		Actor: ctx.User,
+	}, []models.CatalogFacet{facet})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchCatalogFacets", err)
+		return
+	}
+
+	feed := newOPDSFeed(version, feedPath, title, opdsKindNavigation)
+	for _, value := range facets[facet] {
+		feed.navigation = append(feed.navigation, &opdsNavigation{
+			title: valueTitle(value.Value),
+			href:  opdsURL(version, "/publications", url.Values{string(facet): {value.Value}}),
+			kind:  opdsKindAcquisition,
+			count: value.Count,
+		})
+	}
+	writeOPDSFeed(ctx, version, feed)
+}
+
+// opdsURL returns the URL of an OPDS feed of the given version with the given path and query
+func opdsURL(version, feedPath string, query url.Values) string {
+	u := setting.AppURL + "api/catalog/v5/opds/" + version + feedPath
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// opdsFeedType returns the media type of an OPDS feed of the given version and kind
+func opdsFeedType(version, kind string) string {
+	if version == opdsVersion2 {
+		return opdsTypeJSON
+	}
+	return "application/atom+xml;profile=opds-catalog;kind=" + kind
+}
+
+// opdsFeed is an OPDS feed, written as an Atom feed for OPDS 1.2 and as JSON for OPDS 2.0
+type opdsFeed struct {
+	id           string
+	title        string
+	kind         string
+	updated      time.Time
+	links        []*opdsLink
+	navigation   []*opdsNavigation
+	publications []*opdsPublication
+	// total, page and pageSize are only set for the feeds of publications
+	total    int64
+	page     int
+	pageSize int
+}
+
+type opdsLink struct {
+	rel       string
+	href      string
+	mediaType string
+	title     string
+	templated bool
+}
+
+// opdsNavigation is a link of a navigation feed to another feed, with the number of publications it has if known
+type opdsNavigation struct {
+	title string
+	href  string
+	kind  string
+	count int64
+}
+
+type opdsPublication struct {
+	id         string
+	title      string
+	author     string
+	language   string
+	subject    string
+	summary    string
+	identifier string
+	issued     time.Time
+	updated    time.Time
+	links      []*opdsLink
+}
+
+// newOPDSFeed returns a feed at the path of the given version, linked to itself, the start of the catalog and its
+// search
+func newOPDSFeed(version, feedPath, title, kind string) *opdsFeed {
+	feed := &opdsFeed{
+		id:      opdsURL(version, feedPath, nil),
+		title:   title,
+		kind:    kind,
+		updated: time.Now(),
+		links: []*opdsLink{
+			{rel: "self", href: opdsURL(version, feedPath, nil), mediaType: opdsFeedType(version, kind)},
+			{rel: "start", href: opdsURL(version, "", nil), mediaType: opdsFeedType(version, opdsKindNavigation)},
+		},
+	}
+	if version == opdsVersion2 {
+		feed.links = append(feed.links, &opdsLink{rel: "search", href: opdsURL(version, "/publications", nil) + "{?q}", mediaType: opdsTypeJSON, templated: true})
+	} else {
+		feed.links = append(feed.links, &opdsLink{rel: "search", href: setting.AppURL + "api/catalog/v5/opds/opensearch.xml", mediaType: opdsTypeOpenSearch})
+	}
+	return feed
+}
+
+// paginate sets the self link of the feed to the page of the query and adds the links to its first, previous, next
+// and last pages
+func (f *opdsFeed) paginate(query url.Values, page, pageSize int, total int64) {
+	f.total, f.page, f.pageSize = total, page, pageSize
+	self := f.links[0]
+	base := strings.SplitN(self.href, "?", 2)[0]
+	pageURL := func(p int) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("page", strconv.Itoa(p))
+		return base + "?" + q.Encode()
+	}
+
+	lastPage := 1
+	if pageSize > 0 && total > 0 {
+		lastPage = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	self.href = pageURL(page)
+	f.links = append(f.links, &opdsLink{rel: "first", href: pageURL(1), mediaType: self.mediaType})
+	if page > 1 {
+		f.links = append(f.links, &opdsLink{rel: "previous", href: pageURL(page - 1), mediaType: self.mediaType})
+	}
+	if page < lastPage {
+		f.links = append(f.links, &opdsLink{rel: "next", href: pageURL(page + 1), mediaType: self.mediaType})
+	}
+	f.links = append(f.links, &opdsLink{rel: "last", href: pageURL(lastPage), mediaType: self.mediaType})
+}
+
+// toOPDSPublication returns the publication of a catalog entry, with attributes loaded, linking to its zipball and the
+// PDF attachments of its release
+func toOPDSPublication(dm *models.Door43Metadata) *opdsPublication {
+	p := &opdsPublication{
+		id:         dm.APIURLV5(),
+		title:      dm.Title,
+		author:     dm.Repo.OwnerName,
+		language:   dm.Language,
+		subject:    dm.Subject,
+		summary:    fmt.Sprintf("%s, %s %s", dm.Subject, dm.LanguageTitle, dm.BranchOrTag),
+		identifier: dm.Repo.FullName() + "@" + dm.BranchOrTag,
+		issued:     dm.ReleaseDateUnix.AsTime(),
+		updated:    dm.UpdatedUnix.AsTime(),
+		links: []*opdsLink{
+			{rel: opdsRelAcquisition, href: dm.GetZipballURL(), mediaType: "application/zip", title: "Zip"},
+		},
+	}
+	if dm.Release != nil {
+		for _, attachment := range dm.Release.Attachments {
+			if strings.EqualFold(path.Ext(attachment.Name), ".pdf") {
+				p.links = append(p.links, &opdsLink{rel: opdsRelAcquisition, href: attachment.DownloadURL(), mediaType: "application/pdf", title: attachment.Name})
+			}
+		}
+		p.links = append(p.links, &opdsLink{rel: "alternate", href: dm.Release.HTMLURL(), mediaType: "text/html"})
+	}
+	p.links = append(p.links, &opdsLink{rel: "related", href: dm.APIURLV5(), mediaType: "application/json"})
+	return p
+}
+
+// writeOPDSFeed writes the feed as an OPDS 1.2 Atom feed or an OPDS 2.0 JSON feed
+func writeOPDSFeed(ctx *context.APIContext, version string, feed *opdsFeed) {
+	if version == opdsVersion2 {
+		data, err := json.MarshalIndent(toOPDS2Feed(feed), "", "  ")
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "MarshalIndent", err)
+			return
+		}
+		ctx.Resp.Header().Set("Content-Type", opdsTypeJSON+"; charset=utf-8")
+		ctx.Resp.WriteHeader(http.StatusOK)
+		_, _ = ctx.Resp.Write(data)
+		return
+	}
+	writeXML(ctx, opdsFeedType(version, feed.kind), toOPDS1Feed(feed))
+}
+
+// writeXML writes the XML of the value with the given media type
+func writeXML(ctx *context.APIContext, mediaType string, v interface{}) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "MarshalIndent", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write([]byte(xml.Header))
+	_, _ = ctx.Resp.Write(data)
+}
+
+type opds1Feed struct {
+	XMLName      xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID           string       `xml:"id"`
+	Title        string       `xml:"title"`
+	Updated      string       `xml:"updated"`
+	Author       *opds1Author `xml:"author"`
+	TotalResults *int64       `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults,omitempty"`
+	ItemsPerPage *int         `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage,omitempty"`
+	StartIndex   *int         `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex,omitempty"`
+	Links        []opds1Link  `xml:"link"`
+	Entries      []opds1Entry `xml:"entry"`
+}
+
+type opds1Author struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type opds1Link struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type opds1Content struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type opds1Entry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Author     *opds1Author   `xml:"author"`
+	Language   string         `xml:"http://purl.org/dc/terms/ language,omitempty"`
+	Identifier string         `xml:"http://purl.org/dc/terms/ identifier,omitempty"`
+	Issued     string         `xml:"http://purl.org/dc/terms/ issued,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *opds1Content  `xml:"content"`
+	Links      []opds1Link    `xml:"link"`
+}
+
+// toOPDS1Feed returns the Atom feed of an OPDS 1.2 catalog of the feed
+func toOPDS1Feed(feed *opdsFeed) *opds1Feed {
+	result := &opds1Feed{
+		ID:      feed.id,
+		Title:   feed.title,
+		Updated: feed.updated.Format(time.RFC3339),
+		Author:  &opds1Author{Name: setting.AppName, URI: setting.AppURL},
+		Links:   toOPDS1Links(feed.links),
+	}
+	if feed.kind == opdsKindAcquisition {
+		startIndex := (feed.page-1)*feed.pageSize + 1
+		result.TotalResults, result.ItemsPerPage, result.StartIndex = &feed.total, &feed.pageSize, &startIndex
+	}
+	for _, navigation := range feed.navigation {
+		entry := opds1Entry{
+			ID:      navigation.href,
+			Title:   navigation.title,
+			Updated: feed.updated.Format(time.RFC3339),
+			Links:   []opds1Link{{Rel: "subsection", Href: navigation.href, Type: opdsFeedType(opdsVersion1, navigation.kind)}},
+		}
+		if navigation.count > 0 {
+			entry.Content = &opds1Content{Type: "text", Text: fmt.Sprintf("%d publications", navigation.count)}
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	for _, publication := range feed.publications {
+		entry := opds1Entry{
+			ID:         publication.id,
+			Title:      publication.title,
+			Updated:    publication.updated.Format(time.RFC3339),
+			Language:   publication.language,
+			Identifier: publication.identifier,
+			Issued:     publication.issued.Format(time.RFC3339),
+			Summary:    publication.summary,
+			Links:      toOPDS1Links(publication.links),
+		}
+		if publication.author != "" {
+			entry.Author = &opds1Author{Name: publication.author}
+		}
+		if publication.subject != "" {
+			entry.Categories = []atomCategory{{Term: publication.subject}}
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return result
+}
+
+func toOPDS1Links(links []*opdsLink) []opds1Link {
+	result := make([]opds1Link, len(links))
+	for i, link := range links {
+		result[i] = opds1Link{Rel: link.rel, Href: link.href, Type: link.mediaType, Title: link.title}
+	}
+	return result
+}
+
+type opds2Feed struct {
+	Metadata     opds2Metadata       `json:"metadata"`
+	Links        []*opds2Link        `json:"links"`
+	Navigation   []*opds2Link        `json:"navigation,omitempty"`
+	Publications []*opds2Publication `json:"publications,omitempty"`
+}
+
+type opds2Metadata struct {
+	Title         string `json:"title"`
+	Modified      string `json:"modified"`
+	NumberOfItems *int64 `json:"numberOfItems,omitempty"`
+	ItemsPerPage  int    `json:"itemsPerPage,omitempty"`
+	CurrentPage   int    `json:"currentPage,omitempty"`
+}
+
+type opds2Link struct {
+	Rel        string                 `json:"rel,omitempty"`
+	Href       string                 `json:"href"`
+	Type       string                 `json:"type,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Templated  bool                   `json:"templated,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type opds2Publication struct {
+	Metadata opds2PublicationMetadata `json:"metadata"`
+	Links    []*opds2Link             `json:"links"`
+}
+
+type opds2PublicationMetadata struct {
+	Type        string   `json:"@type"`
+	Identifier  string   `json:"identifier"`
+	Title       string   `json:"title"`
+	Author      string   `json:"author,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Subject     []string `json:"subject,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Published   string   `json:"published"`
+	Modified    string   `json:"modified"`
+}
+
+// toOPDS2Feed returns the JSON of an OPDS 2.0 catalog of the feed
+func toOPDS2Feed(feed *opdsFeed) *opds2Feed {
+	result := &opds2Feed{
+		Metadata: opds2Metadata{
+			Title:    feed.title,
+			Modified: feed.updated.Format(time.RFC3339),
+		},
+		Links: toOPDS2Links(feed.links),
+	}
+	if feed.kind == opdsKindAcquisition {
+		result.Metadata.NumberOfItems = &feed.total
+		result.Metadata.ItemsPerPage = feed.pageSize
+		result.Metadata.CurrentPage = feed.page
+	}
+	for _, navigation := range feed.navigation {
+		link := &opds2Link{Href: navigation.href, Type: opdsTypeJSON, Title: navigation.title}
+		if navigation.count > 0 {
+			link.Properties = map[string]interface{}{"numberOfItems": navigation.count}
+		}
+		result.Navigation = append(result.Navigation, link)
+	}
+	for _, publication := range feed.publications {
+		p := &opds2Publication{
+			Metadata: opds2PublicationMetadata{
+				Type:        opdsSchemaBookType,
+				Identifier:  publication.id,
+				Title:       publication.title,
+				Author:      publication.author,
+				Language:    publication.language,
+				Description: publication.summary,
+				Published:   publication.issued.Format(time.RFC3339),
+				Modified:    publication.updated.Format(time.RFC3339),
+			},
+			Links: toOPDS2Links(publication.links),
+		}
+		if publication.subject != "" {
+			p.Metadata.Subject = []string{publication.subject}
+		}
+		result.Publications = append(result.Publications, p)
+	}
+	return result
+}
+
+func toOPDS2Links(links []*opdsLink) []*opds2Link {
+	result := make([]*opds2Link, len(links))
+	for i, link := range links {
+		result[i] = &opds2Link{Rel: link.rel, Href: link.href, Type: link.mediaType, Title: link.title, Templated: link.templated}
+	}
+	return result
+}
+
+// openSearchDescription is the OpenSearch description of the search of the catalog
+type openSearchDescription struct {
+	XMLName     xml.Name        `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	ShortName   string          `xml:"ShortName"`
+	Description string          `xml:"Description"`
+	URLs        []openSearchURL `xml:"Url"`
+}
+
+type openSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// insertOPDSTestEntries publishes two English entries and a French one
+func insertOPDSTestEntries(t *testing.T) {
+	for _, dm := range []*models.Door43Metadata{
+		{RepoID: 1, Title: "Unlocked Literal Bible", Language: "en", Subject: "Bible"},
+		{RepoID: 4, Title: "Translation Notes", Language: "en", Subject: "Translation Notes"},
+		{RepoID: 8, Title: "Louis Segond", Language: "fr", Subject: "Bible"},
+	} {
+		dm.MetadataType = models.MetadataTypeRC
+		dm.MetadataVersion = "rc0.2"
+		dm.Metadata = &map[string]interface{}{}
+		dm.Stage = models.StageProd
+		dm.BranchOrTag = "v1"
+		dm.ReleaseDateUnix = 1
+		assert.NoError(t, models.InsertDoor43Metadata(dm))
+	}
+}
+
+// getOPDS calls the handler of an OPDS feed of the given version and query
+func getOPDS(t *testing.T, handler func(*context.APIContext), version, feedPath string, query url.Values) *httptest.ResponseRecorder {
+	ctx := test.MockContext(t, "api/catalog/v5/opds/"+version+feedPath+"?"+query.Encode())
+	ctx.Req.Form = ctx.Req.URL.Query()
+	ctx.SetParams("version", version)
+	recorder := httptest.NewRecorder()
+	ctx.Resp = context.NewResponse(recorder)
+	handler(&context.APIContext{Context: ctx})
+	return recorder
+}
+
+func decodeOPDS1(t *testing.T, recorder *httptest.ResponseRecorder, kind string) *opds1Feed {
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, opdsFeedType(opdsVersion1, kind)+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	feed := new(opds1Feed)
+	assert.NoError(t, xml.NewDecoder(recorder.Body).Decode(feed))
+	return feed
+}
+
+func decodeOPDS2(t *testing.T, recorder *httptest.ResponseRecorder) *opds2Feed {
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, opdsTypeJSON+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	feed := new(opds2Feed)
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(feed))
+	return feed
+}
+
+// getOPDS1Link returns the href of the link of the given rel, or "" if there isn't one
+func getOPDS1Link(links []opds1Link, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func getOPDS2Link(links []*opds2Link, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func TestOPDSRoot(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	feed1 := decodeOPDS1(t, getOPDS(t, OPDSRoot, opdsVersion1, "", nil), opdsKindNavigation)
+	assert.Equal(t, opdsTitle, feed1.Title)
+	assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v1.2", getOPDS1Link(feed1.Links, "start"))
+	assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/opensearch.xml", getOPDS1Link(feed1.Links, "search"))
+	if assert.Len(t, feed1.Entries, 3) {
+		assert.Equal(t, []opds1Link{{
+			Rel:  "subsection",
+			Href: setting.AppURL + "api/catalog/v5/opds/v1.2/languages",
+			Type: opdsFeedType(opdsVersion1, opdsKindNavigation),
+		}}, feed1.Entries[0].Links)
+		assert.Equal(t, opdsFeedType(opdsVersion1, opdsKindAcquisition), feed1.Entries[2].Links[0].Type)
+	}
+
+	feed2 := decodeOPDS2(t, getOPDS(t, OPDSRoot, opdsVersion2, "", nil))
+	assert.Equal(t, opdsTitle, feed2.Metadata.Title)
+	assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v2/publications{?q}", getOPDS2Link(feed2.Links, "search"))
+	if assert.Len(t, feed2.Navigation, 3) {
+		assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v2/subjects", feed2.Navigation[1].Href)
+	}
+	assert.Nil(t, feed2.Publications)
+
+	recorder := getOPDS(t, OPDSRoot, "v3", "", nil)
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestOPDSFacets(t *testing.T) {
+	models.PrepareTestEnv(t)
+	insertOPDSTestEntries(t)
+
+	// each language links to the feed of its publications, with their count
+	feed1 := decodeOPDS1(t, getOPDS(t, OPDSLanguages, opdsVersion1, "/languages", nil), opdsKindNavigation)
+	if assert.Len(t, feed1.Entries, 2) {
+		assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v1.2/publications?lang=en", feed1.Entries[0].Links[0].Href)
+		assert.True(t, strings.HasSuffix(feed1.Entries[0].Title, "en") || strings.HasSuffix(feed1.Entries[0].Title, "(en)"))
+		assert.Equal(t, &opds1Content{Type: "text", Text: "2 publications"}, feed1.Entries[0].Content)
+		assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v1.2/publications?lang=fr", feed1.Entries[1].Links[0].Href)
+	}
+
+	feed2 := decodeOPDS2(t, getOPDS(t, OPDSSubjects, opdsVersion2, "/subjects", nil))
+	if assert.Len(t, feed2.Navigation, 2) {
+		assert.Equal(t, "Bible", feed2.Navigation[0].Title)
+		assert.Equal(t, setting.AppURL+"api/catalog/v5/opds/v2/publications?subject=Bible", feed2.Navigation[0].Href)
+		assert.EqualValues(t, 2, feed2.Navigation[0].Properties["numberOfItems"])
+		assert.Equal(t, "Translation Notes", feed2.Navigation[1].Title)
+		assert.EqualValues(t, 1, feed2.Navigation[1].Properties["numberOfItems"])
+	}
+}
+
+func TestOPDSPublications(t *testing.T) {
+	models.PrepareTestEnv(t)
+	insertOPDSTestEntries(t)
+
+	// the second of the two English publications, one per page
+	query := url.Values{"lang": {"en"}, "limit": {"1"}, "page": {"2"}}
+	pageURL := func(version, page string) string {
+		return setting.AppURL + "api/catalog/v5/opds/" + version + "/publications?lang=en&limit=1&page=" + page
+	}
+
+	feed1 := decodeOPDS1(t, getOPDS(t, OPDSPublications, opdsVersion1, "/publications", query), opdsKindAcquisition)
+	assert.Equal(t, "en", feed1.Title)
+	assert.EqualValues(t, 2, *feed1.TotalResults)
+	assert.EqualValues(t, 1, *feed1.ItemsPerPage)
+	assert.EqualValues(t, 2, *feed1.StartIndex)
+	assert.Equal(t, pageURL(opdsVersion1, "2"), getOPDS1Link(feed1.Links, "self"))
+	assert.Equal(t, pageURL(opdsVersion1, "1"), getOPDS1Link(feed1.Links, "first"))
+	assert.Equal(t, pageURL(opdsVersion1, "1"), getOPDS1Link(feed1.Links, "previous"))
+	assert.Equal(t, "", getOPDS1Link(feed1.Links, "next"))
+	assert.Equal(t, pageURL(opdsVersion1, "2"), getOPDS1Link(feed1.Links, "last"))
+	if assert.Len(t, feed1.Entries, 1) {
+		entry := feed1.Entries[0]
+		assert.Equal(t, "Translation Notes", entry.Title)
+		assert.Equal(t, "en", entry.Language)
+		assert.Equal(t, "user5/repo4@v1", entry.Identifier)
+		assert.Equal(t, []atomCategory{{Term: "Translation Notes"}}, entry.Categories)
+		assert.Equal(t, opdsRelAcquisition, entry.Links[0].Rel)
+		assert.Equal(t, "application/zip", entry.Links[0].Type)
+	}
+
+	feed2 := decodeOPDS2(t, getOPDS(t, OPDSPublications, opdsVersion2, "/publications", query))
+	assert.EqualValues(t, 2, *feed2.Metadata.NumberOfItems)
+	assert.EqualValues(t, 1, feed2.Metadata.ItemsPerPage)
+	assert.EqualValues(t, 2, feed2.Metadata.CurrentPage)
+	assert.Equal(t, pageURL(opdsVersion2, "1"), getOPDS2Link(feed2.Links, "previous"))
+	assert.Equal(t, "", getOPDS2Link(feed2.Links, "next"))
+	if assert.Len(t, feed2.Publications, 1) {
+		assert.Equal(t, opdsSchemaBookType, feed2.Publications[0].Metadata.Type)
+		assert.Equal(t, "Translation Notes", feed2.Publications[0].Metadata.Title)
+		assert.Equal(t, []string{"Translation Notes"}, feed2.Publications[0].Metadata.Subject)
+	}
+
+	// the first page links to the next one and no previous one
+	query.Set("page", "1")
+	feed2 = decodeOPDS2(t, getOPDS(t, OPDSPublications, opdsVersion2, "/publications", query))
+	assert.Equal(t, "", getOPDS2Link(feed2.Links, "previous"))
+	assert.Equal(t, pageURL(opdsVersion2, "2"), getOPDS2Link(feed2.Links, "next"))
+	if assert.Len(t, feed2.Publications, 1) {
+		assert.Equal(t, "Unlocked Literal Bible", feed2.Publications[0].Metadata.Title)
+	}
+
+	feed2 = decodeOPDS2(t, getOPDS(t, OPDSPublications, opdsVersion2, "/publications", url.Values{"lang": {"de"}}))
+	assert.EqualValues(t, 0, *feed2.Metadata.NumberOfItems)
+	assert.Len(t, feed2.Publications, 0)
+}
+
+func TestOPDSOpenSearch(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	recorder := getOPDS(t, OPDSOpenSearch, "", "/opensearch.xml", nil)
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, opdsTypeOpenSearch+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	description := new(openSearchDescription)
+	assert.NoError(t, xml.NewDecoder(recorder.Body).Decode(description))
+	assert.Equal(t, opdsTitle, description.ShortName)
+	assert.Equal(t, []openSearchURL{
+		{
+			Type:     opdsFeedType(opdsVersion1, opdsKindAcquisition),
+			Template: setting.AppURL + "api/catalog/v5/opds/v1.2/publications?q={searchTerms}&page={startPage?}",
+		},
+		{
+			Type:     opdsTypeJSON,
+			Template: setting.AppURL + "api/catalog/v5/opds/v2/publications?q={searchTerms}&page={startPage?}",
+		},
+	}, description.URLs)
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetCatalogEntryRelations lists the resources a catalog entry relates to, resolved to catalog entries
+func GetCatalogEntryRelations(ctx *context.APIContext) {
+	// swagger:operation GET /v5/entry/{owner}/{repo}/{tag}/relations v5 v5GetCatalogEntryRelations
+	// ---
+	// summary: Resources a catalog entry relates to, such as the source texts it depends on
+	// description: Resolves each relation listed in the entry's metadata to the production release of the resource
+	//              with the highest version satisfying the relation's version, preferring the repos of the entry's
+	//              owner. A relation that only resolves to a prerelease, draft or default branch is "unpublished",
+	//              and one to a resource not in the catalog is "dangling".
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: name of the owner
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: tag
+	//   in: path
+	//   description: release tag, default branch or other branch in the catalog
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogRelationsV5"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	dm := getCatalogEntry(ctx)
+	if ctx.Written() {
+		return
+	}
+	relations, err := models.ResolveDoor43MetadataRelations(dm)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ResolveDoor43MetadataRelations", err)
+		return
+	}
+
+	results := make([]*api.CatalogRelationV5, len(relations))
+	for i, relation := range relations {
+		results[i] = &api.CatalogRelationV5{
+			Relation:   relation.Relation,
+			Language:   relation.Language,
+			Identifier: relation.Identifier,
+			Version:    relation.Version,
+			Status:     string(relation.Status),
+		}
+		if relation.Entry != nil {
+			if results[i].Entry, err = toCatalogEntry(ctx, relation.Entry); err != nil {
+				ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+				return
+			}
+		}
+	}
+	ctx.JSON(http.StatusOK, api.CatalogRelationsV5{
+		OK:   true,
+		Data: results,
+	})
+}
+
+// GetCatalogEntryDependents lists the catalog entries that have a relation that resolves to a catalog entry
+func GetCatalogEntryDependents(ctx *context.APIContext) {
+	// swagger:operation GET /v5/entry/{owner}/{repo}/{tag}/dependents v5 v5GetCatalogEntryDependents
+	// ---
+	// summary: Catalog entries that depend on a catalog entry
+	// description: Lists the entries with a relation that resolves to the entry, which are the ones whose relation
+	//              would no longer resolve to it if it were withdrawn.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: name of the owner
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: tag
+	//   in: path
+	//   description: release tag, default branch or other branch in the catalog
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogSearchResultsV5"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	dm := getCatalogEntry(ctx)
+	if ctx.Written() {
+		return
+	}
+	dependents, err := models.GetDoor43MetadataDependents(dm)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataDependents", err)
+		return
+	}
+
+	results := make([]*api.Door43MetadataV5, 0, len(dependents))
+	for _, dependent := range dependents {
+		accessMode, err := models.AccessLevel(ctx.User, dependent.Repo)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+			return
+		}
+		if accessMode < models.AccessModeRead {
+			continue
+		}
+		results = append(results, convert.ToDoor43MetadataV5(dependent, accessMode))
+	}
+	ctx.JSON(http.StatusOK, api.CatalogSearchResultsV5{
+		OK:   true,
+		Data: results,
+	})
+}
+
+// toCatalogEntry converts a door43 metadata with its attributes loaded to a catalog entry for the user
+func toCatalogEntry(ctx *context.APIContext, dm *models.Door43Metadata) (*api.Door43MetadataV5, error) {
+	accessMode, err := models.AccessLevel(ctx.User, dm.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return convert.ToDoor43MetadataV5(dm, accessMode), nil
+}
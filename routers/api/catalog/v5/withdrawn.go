@@ -0,0 +1,111 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v5
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListWithdrawn lists the catalog entries that have been withdrawn from the catalog
+func ListWithdrawn(ctx *context.APIContext) {
+	// swagger:operation GET /v5/withdrawn v5 v5ListWithdrawn
+	// ---
+	// summary: Catalog entries that have been withdrawn, with the reason why
+	// description: Lists the entries deleted from the catalog, the most recently withdrawn first, for as long as their
+	//              tombstones are kept. An entry that is created again is no longer listed.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: query
+	//   description: owner of the repos of the withdrawn entries
+	//   type: string
+	// - name: repo
+	//   in: query
+	//   description: name of the repo of the withdrawn entries
+	//   type: string
+	// - name: reason
+	//   in: query
+	//   description: reason the entries were withdrawn, can be repeated or a comma-separated list
+	//   type: string
+	//   enum: [repo_deleted, made_private, archived, invalid_manifest, invalid_container, release_deleted, branch_removed]
+	// - name: since
+	//   in: query
+	//   description: only return the entries withdrawn at or after this time, either in RFC 3339 format or a Unix timestamp
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CatalogWithdrawnV5"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts := models.FindDoor43MetadataTombstonesOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		OwnerName:   ctx.Query("owner"),
+		RepoName:    ctx.Query("repo"),
+		Actor:       ctx.User,
+	}
+	for _, reasonStr := range QueryStrings(ctx, "reason") {
+		reason := models.Door43MetadataDeleteReason(reasonStr)
+		if !reason.IsValid() {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid reason: \"%s\"", reasonStr))
+			return
+		}
+		opts.Reasons = append(opts.Reasons, reason)
+	}
+	var ok bool
+	if opts.Since, ok = getSince(ctx); !ok {
+		return
+	}
+
+	tombstones, count, err := models.FindDoor43MetadataTombstones(opts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, api.SearchError{
+			OK:    false,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	results := make([]*api.WithdrawnCatalogEntryV5, len(tombstones))
+	for i, tombstone := range tombstones {
+		results[i] = &api.WithdrawnCatalogEntryV5{
+			EntryID:         tombstone.Door43MetadataID,
+			Owner:           tombstone.OwnerName,
+			Name:            tombstone.RepoName,
+			FullName:        tombstone.FullName(),
+			BranchOrTag:     tombstone.BranchOrTag,
+			MetadataType:    tombstone.MetadataType,
+			MetadataVersion: tombstone.MetadataVersion,
+			Title:           tombstone.Title,
+			Subject:         tombstone.Subject,
+			Language:        tombstone.Language,
+			Stage:           tombstone.Stage.String(),
+			Reason:          string(tombstone.Reason),
+			Withdrawn:       tombstone.DeletedUnix.AsTime(),
+		}
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, api.CatalogWithdrawnV5{
+		OK:   true,
+		Data: results,
+	})
+}
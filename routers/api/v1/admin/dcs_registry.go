@@ -0,0 +1,305 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListSubjects lists the subjects of the subject registry
+func ListSubjects(ctx *context.APIContext) {
+	// swagger:operation GET /admin/subjects admin adminListSubjects
+	// ---
+	// summary: List the subjects of the subject registry
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DCSSubjectList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	subjects, err := models.GetDCSSubjects()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetDCSSubjects", err)
+		return
+	}
+	results := make([]*api.DCSSubject, len(subjects))
+	for i, s := range subjects {
+		results[i] = convert.ToDCSSubject(s)
+	}
+	ctx.JSON(http.StatusOK, &results)
+}
+
+// CreateSubject adds a subject to the subject registry
+func CreateSubject(ctx *context.APIContext) {
+	// swagger:operation POST /admin/subjects admin adminCreateSubject
+	// ---
+	// summary: Add a subject, or an alias of a subject, to the subject registry
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateDCSSubjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/DCSSubject"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	form := web.GetForm(ctx).(*api.CreateDCSSubjectOption)
+
+	s := &models.DCSSubject{
+		ResourceID: form.ResourceID,
+		Title:      form.Title,
+		AliasOf:    form.AliasOf,
+	}
+	if err := models.CreateDCSSubject(s); err != nil {
+		if models.IsErrDCSSubjectAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, "", err)
+		} else if models.IsErrDCSSubjectInvalid(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateDCSSubject", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToDCSSubject(s))
+}
+
+// EditSubject edits a subject of the subject registry
+func EditSubject(ctx *context.APIContext) {
+	// swagger:operation PATCH /admin/subjects/{subject} admin adminEditSubject
+	// ---
+	// summary: Edit the title of a subject of the subject registry, make it an alias of another or deprecate it
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: subject
+	//   in: path
+	//   description: resource ID of the subject to edit
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditDCSSubjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DCSSubject"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	form := web.GetForm(ctx).(*api.EditDCSSubjectOption)
+
+	s, err := models.GetDCSSubjectByResourceID(ctx.Params(":subject"))
+	if err != nil {
+		if models.IsErrDCSSubjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDCSSubjectByResourceID", err)
+		}
+		return
+	}
+	if form.Title != nil {
+		s.Title = *form.Title
+	}
+	if form.AliasOf != nil {
+		s.AliasOf = *form.AliasOf
+	}
+	if form.Deprecated != nil {
+		s.IsDeprecated = *form.Deprecated
+	}
+	if err := models.UpdateDCSSubject(s); err != nil {
+		if models.IsErrDCSSubjectInvalid(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "UpdateDCSSubject", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToDCSSubject(s))
+}
+
+// ListLanguages lists the languages of the language registry
+func ListLanguages(ctx *context.APIContext) {
+	// swagger:operation GET /admin/languages admin adminListLanguages
+	// ---
+	// summary: List the languages of the language registry
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: keyword to search for in the code, name and anglicized name of the languages
+	//   type: string
+	// - name: gateway
+	//   in: query
+	//   description: if true, only list the gateway languages
+	//   type: boolean
+	// - name: overridden
+	//   in: query
+	//   description: if true, only list the languages overridden by a site admin
+	//   type: boolean
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DCSLanguageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	listOptions := utils.GetListOptions(ctx)
+
+	languages, maxResults, err := models.SearchDCSLanguages(&models.SearchDCSLanguagesOptions{
+		ListOptions:  listOptions,
+		Keyword:      ctx.QueryTrim("q"),
+		IsGateway:    ctx.QueryBool("gateway"),
+		IsOverridden: ctx.QueryBool("overridden"),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchDCSLanguages", err)
+		return
+	}
+
+	results := make([]*api.DCSLanguage, len(languages))
+	for i, l := range languages {
+		results[i] = convert.ToDCSLanguage(l)
+	}
+
+	ctx.SetLinkHeader(int(maxResults), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", maxResults))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, &results)
+}
+
+// getDCSLanguage gets the language of the lang path parameter, writing the error response if it fails
+func getDCSLanguage(ctx *context.APIContext) *models.DCSLanguage {
+	l, err := models.GetDCSLanguageByCode(ctx.Params(":lang"))
+	if err != nil {
+		if models.IsErrDCSLanguageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDCSLanguageByCode", err)
+		}
+		return nil
+	}
+	return l
+}
+
+// EditLanguage overrides a language of the language registry
+func EditLanguage(ctx *context.APIContext) {
+	// swagger:operation PATCH /admin/languages/{lang} admin adminEditLanguage
+	// ---
+	// summary: Override the name, direction or gateway flag of a language of the language registry
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: lang
+	//   in: path
+	//   description: code of the language to override
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditDCSLanguageOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DCSLanguage"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	form := web.GetForm(ctx).(*api.EditDCSLanguageOption)
+
+	l := getDCSLanguage(ctx)
+	if ctx.Written() {
+		return
+	}
+	if form.Name != nil {
+		l.Name = *form.Name
+	}
+	if form.AnglicizedName != nil {
+		l.AnglicizedName = *form.AnglicizedName
+	}
+	if form.Direction != nil {
+		l.Direction = *form.Direction
+	}
+	if form.Gateway != nil {
+		l.IsGateway = *form.Gateway
+	}
+	if err := models.OverrideDCSLanguage(l); err != nil {
+		if models.IsErrDCSLanguageInvalidDirection(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "OverrideDCSLanguage", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToDCSLanguage(l))
+}
+
+// ResetLanguage drops the override of a language of the language registry
+func ResetLanguage(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/languages/{lang}/override admin adminResetLanguage
+	// ---
+	// summary: Drop the override of a language of the language registry, restoring it from langnames.json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: lang
+	//   in: path
+	//   description: code of the language to reset
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DCSLanguage"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	l := getDCSLanguage(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.ResetDCSLanguage(l); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ResetDCSLanguage", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToDCSLanguage(l))
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListScrubs lists the audit records of the scrubs of every repo
+func ListScrubs(ctx *context.APIContext) {
+	// swagger:operation GET /admin/scrubs admin adminListScrubs
+	// ---
+	// summary: List the audit records of the scrubs and dry runs of every repo, most recent first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoScrubList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	listOptions := utils.GetListOptions(ctx)
+
+	scrubs, maxResults, err := models.FindRepoScrubs(&models.FindRepoScrubsOptions{ListOptions: listOptions})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindRepoScrubs", err)
+		return
+	}
+
+	results := make([]*api.RepoScrub, len(scrubs))
+	for i, s := range scrubs {
+		results[i] = convert.ToRepoScrub(s, ctx.User)
+	}
+
+	ctx.SetLinkHeader(int(maxResults), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", maxResults))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, &results)
+}
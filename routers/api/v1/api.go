@@ -973,6 +973,11 @@ func Routes() *web.Route {
 				}, reqAnyRepoReader())
 				m.Get("/issue_templates", context.ReferencesGitRepo(false), repo.GetIssueTemplates)
 				m.Get("/languages", reqRepoReader(models.UnitTypeCode), repo.GetLanguages)
+				/*** DCS Customizations ***/
+				m.Get("/metadata/validation", reqRepoReader(models.UnitTypeCode), repo.GetMetadataValidation)
+				m.Post("/scrub", reqToken(), reqOwner(), bind(api.ScrubRepoOption{}), repo.Scrub)
+				m.Get("/scrubs", reqToken(), reqOwner(), repo.ListScrubs)
+				/*** END DCS Customizations ***/
 			}, repoAssignment())
 		})
 
@@ -1017,6 +1022,9 @@ func Routes() *web.Route {
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
 			}, reqToken(), reqOrgOwnership(), reqWebhooksEnabled())
+			/*** DCS Customizations ***/
+			m.Post("/release_bundles", reqToken(), reqOrgMembership(), bind(api.CreateReleaseBundleOption{}), org.CreateReleaseBundle)
+			/*** END DCS Customizations ***/
 		}, orgAssignment(true))
 		m.Group("/teams/{teamid}", func() {
 			m.Combo("").Get(org.GetTeam).
@@ -1063,6 +1071,19 @@ func Routes() *web.Route {
 				m.Post("/{username}/{reponame}", admin.AdoptRepository)
 				m.Delete("/{username}/{reponame}", admin.DeleteUnadoptedRepository)
 			})
+			/*** DCS Customizations ***/
+			m.Group("/subjects", func() {
+				m.Get("", admin.ListSubjects)
+				m.Post("", bind(api.CreateDCSSubjectOption{}), admin.CreateSubject)
+				m.Patch("/{subject}", bind(api.EditDCSSubjectOption{}), admin.EditSubject)
+			})
+			m.Group("/languages", func() {
+				m.Get("", admin.ListLanguages)
+				m.Patch("/{lang}", bind(api.EditDCSLanguageOption{}), admin.EditLanguage)
+				m.Delete("/{lang}/override", admin.ResetLanguage)
+			})
+			m.Get("/scrubs", admin.ListScrubs)
+			/*** END DCS Customizations ***/
 		}, reqToken(), reqSiteAdmin())
 
 		m.Group("/topics", func() {
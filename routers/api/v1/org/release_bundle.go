@@ -0,0 +1,100 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	release_service "code.gitea.io/gitea/services/release"
+)
+
+// CreateReleaseBundle releases a set of repos of an organization together
+func CreateReleaseBundle(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/release_bundles organization orgCreateReleaseBundle
+	// ---
+	// summary: Release a set of repos of an organization together with the same tag
+	// description: Checks that each repo can be released and that the manifest of its branch would put the release
+	//              into the catalog, then creates the release of every repo. If a release fails to be created, the
+	//              ones created before it are deleted along with their tags, so either every repo is released or none.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateReleaseBundleOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ReleaseBundle"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	form := web.GetForm(ctx).(*api.CreateReleaseBundleOption)
+
+	repos := make([]*models.Repository, len(form.Repos))
+	for i, name := range form.Repos {
+		repo, err := models.GetRepositoryByName(ctx.Org.Organization.ID, name)
+		if err != nil {
+			if models.IsErrRepoNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+			}
+			return
+		}
+		perm, err := models.GetUserRepoPermission(repo, ctx.User)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+			return
+		}
+		if !perm.CanRead(models.UnitTypeCode) {
+			ctx.NotFound()
+			return
+		}
+		if !perm.CanWrite(models.UnitTypeReleases) {
+			ctx.Error(http.StatusForbidden, "", fmt.Errorf("you can not create releases of %s", repo.FullName()))
+			return
+		}
+		repo.Owner = ctx.Org.Organization
+		repos[i] = repo
+	}
+
+	bundle, err := release_service.CreateBundle(release_service.CreateBundleOptions{
+		Owner:        ctx.Org.Organization,
+		Publisher:    ctx.User,
+		Name:         form.Name,
+		TagName:      form.TagName,
+		Title:        form.Title,
+		Note:         form.Note,
+		IsPrerelease: form.IsPrerelease,
+		Target:       form.Target,
+		Repos:        repos,
+	})
+	if err != nil {
+		if release_service.IsErrInvalidBundle(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateBundle", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToReleaseBundle(bundle, ctx.User))
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+)
+
+// GetMetadataValidation returns the outcome of validating the metadata file of a release or the default branch
+func GetMetadataValidation(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/metadata/validation repository repoGetMetadataValidation
+	// ---
+	// summary: Get the outcome of validating the metadata file (e.g. manifest.yaml) of a release or the default branch
+	// produces:
+	//   - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: ref
+	//   in: query
+	//   description: tag name of the release or the default branch name. Default is the default branch
+	//   type: string
+	//   required: false
+	// - name: ref_type
+	//   in: query
+	//   description: whether ref is a branch or a tag, for when a branch and a tag have the same name. Default is a
+	//                branch if ref is the default branch and a tag otherwise
+	//   type: string
+	//   enum: [branch, tag]
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Door43MetadataValidation"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	ref := ctx.Query("ref")
+	if ref == "" {
+		ref = ctx.Repo.Repository.DefaultBranch
+	}
+	refType := ctx.Query("ref_type")
+	switch refType {
+	case models.ValidationRefTypeBranch, models.ValidationRefTypeTag:
+	case "":
+		refType = models.ValidationRefTypeTag
+		if ref == ctx.Repo.Repository.DefaultBranch {
+			refType = models.ValidationRefTypeBranch
+		}
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid ref_type: \"%s\"", refType))
+		return
+	}
+
+	validation, err := models.GetDoor43MetadataValidation(ctx.Repo.Repository.ID, refType, ref)
+	if err != nil {
+		if models.IsErrDoor43MetadataValidationNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDoor43MetadataValidation", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToDoor43MetadataValidation(validation))
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetadataValidation(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	// a branch and a tag with the same name
+	assert.NoError(t, models.UpsertDoor43MetadataValidation(&models.Door43MetadataValidation{
+		RepoID: 1, Ref: "v1", RefType: models.ValidationRefTypeBranch, Filename: "manifest.yaml", IsValid: true,
+	}))
+	assert.NoError(t, models.UpsertDoor43MetadataValidation(&models.Door43MetadataValidation{
+		RepoID: 1, ReleaseID: 1, Ref: "v1", RefType: models.ValidationRefTypeTag, Filename: "manifest.yaml",
+	}))
+
+	get := func(ref, refType string) (int, *api.Door43MetadataValidation) {
+		ctx := test.MockContext(t, "user2/repo1/metadata/validation")
+		test.LoadRepo(t, ctx, 1)
+		recorder := httptest.NewRecorder()
+		ctx.Resp = context.NewResponse(recorder)
+		if ref != "" {
+			ctx.Req.Form.Set("ref", ref)
+		}
+		if refType != "" {
+			ctx.Req.Form.Set("ref_type", refType)
+		}
+		GetMetadataValidation(&context.APIContext{Context: ctx})
+
+		status := ctx.Resp.Status()
+		if status != http.StatusOK {
+			return status, nil
+		}
+		validation := new(api.Door43MetadataValidation)
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(validation))
+		return status, validation
+	}
+
+	// a ref other than the default branch is a tag by default
+	status, validation := get("v1", "")
+	assert.EqualValues(t, http.StatusOK, status)
+	assert.EqualValues(t, models.ValidationRefTypeTag, validation.RefType)
+	assert.False(t, validation.IsValid)
+
+	status, validation = get("v1", models.ValidationRefTypeBranch)
+	assert.EqualValues(t, http.StatusOK, status)
+	assert.EqualValues(t, models.ValidationRefTypeBranch, validation.RefType)
+	assert.True(t, validation.IsValid)
+
+	status, _ = get("v1", "bad")
+	assert.EqualValues(t, http.StatusUnprocessableEntity, status)
+
+	// the default branch hasn't been validated
+	status, _ = get("", "")
+	assert.EqualValues(t, http.StatusNotFound, status)
+
+	status, _ = get("v2", "")
+	assert.EqualValues(t, http.StatusNotFound, status)
+}
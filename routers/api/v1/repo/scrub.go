@@ -0,0 +1,150 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/scrubber"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// Scrub scrubs every branch, tag and pull request head of a repo. The scrub runs within the request and carries on
+// if the client disconnects, its outcome is then in the audit records of the repo.
+func Scrub(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/scrub repository repoScrub
+	// ---
+	// summary: Scrub sensitive data from every branch, tag and pull request head of a repo, rewriting its history, or preview it with a dry run
+	// description: The scrub runs within the request, which can take minutes for a large repo, so a client or a proxy
+	//              in between may time out first. The scrub then carries on and its outcome can be found with
+	//              GET /repos/{owner}/{repo}/scrubs.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/ScrubRepoOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoScrub"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	form := web.GetForm(ctx).(*api.ScrubRepoOption)
+
+	if ctx.Repo.Repository.IsMirror {
+		ctx.Error(http.StatusUnprocessableEntity, "", "a mirror can not be scrubbed, as it would be restored by its next sync")
+		return
+	}
+
+	rules := make([]*scrubber.Rule, 0, len(form.Rules))
+	for _, r := range form.Rules {
+		if r == nil {
+			continue
+		}
+		rules = append(rules, &scrubber.Rule{
+			Name:           r.Name,
+			Files:          r.Files,
+			JSONPaths:      r.JSONPaths,
+			Patterns:       r.Patterns,
+			Replacement:    r.Replacement,
+			CommitMessages: r.CommitMessages,
+		})
+	}
+
+	scrub, err := scrubber.ScrubRepository(ctx.Repo.Repository, ctx.User, scrubber.ScrubOptions{
+		Rules:         rules,
+		IdentityName:  form.IdentityName,
+		IdentityEmail: form.IdentityEmail,
+		DryRun:        form.DryRun,
+	})
+	if err != nil {
+		if scrubber.IsErrInvalidRule(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else if scrubber.IsErrRepoChanged(err) {
+			ctx.Error(http.StatusConflict, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ScrubRepository", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToRepoScrub(scrub, ctx.User))
+}
+
+// ListScrubs lists the audit records of the scrubs of a repo
+func ListScrubs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/scrubs repository repoListScrubs
+	// ---
+	// summary: List the audit records of the scrubs and dry runs of a repo, most recent first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoScrubList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	listOptions := utils.GetListOptions(ctx)
+
+	scrubs, maxResults, err := models.FindRepoScrubs(&models.FindRepoScrubsOptions{
+		ListOptions: listOptions,
+		RepoID:      ctx.Repo.Repository.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindRepoScrubs", err)
+		return
+	}
+
+	results := make([]*api.RepoScrub, len(scrubs))
+	for i, s := range scrubs {
+		results[i] = convert.ToRepoScrub(s, ctx.User)
+	}
+
+	ctx.SetLinkHeader(int(maxResults), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", maxResults))
+	ctx.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
+	ctx.JSON(http.StatusOK, &results)
+}
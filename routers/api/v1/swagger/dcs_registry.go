@@ -0,0 +1,37 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package swagger
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// DCSSubject
+// swagger:response DCSSubject
+type swaggerDCSSubject struct {
+	// in:body
+	Body api.DCSSubject `json:"body"`
+}
+
+// DCSSubjectList
+// swagger:response DCSSubjectList
+type swaggerDCSSubjectList struct {
+	// in:body
+	Body []api.DCSSubject `json:"body"`
+}
+
+// DCSLanguage
+// swagger:response DCSLanguage
+type swaggerDCSLanguage struct {
+	// in:body
+	Body api.DCSLanguage `json:"body"`
+}
+
+// DCSLanguageList
+// swagger:response DCSLanguageList
+type swaggerDCSLanguageList struct {
+	// in:body
+	Body []api.DCSLanguage `json:"body"`
+}
@@ -166,4 +166,23 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	UserSettingsOptions api.UserSettingsOptions
+
+	/*** DCS Customizations ***/
+
+	// in:body
+	CreateDCSSubjectOption api.CreateDCSSubjectOption
+
+	// in:body
+	EditDCSSubjectOption api.EditDCSSubjectOption
+
+	// in:body
+	EditDCSLanguageOption api.EditDCSLanguageOption
+
+	// in:body
+	ScrubRepoOption api.ScrubRepoOption
+
+	// in:body
+	CreateReleaseBundleOption api.CreateReleaseBundleOption
+
+	/*** END DCS Customizations ***/
 }
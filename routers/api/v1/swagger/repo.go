@@ -316,3 +316,14 @@ type swaggerCombinedStatus struct {
 	// in: body
 	Body api.CombinedStatus `json:"body"`
 }
+
+/*** DCS Customizations ***/
+
+// Door43MetadataValidation
+// swagger:response Door43MetadataValidation
+type swaggerDoor43MetadataValidation struct {
+	// in: body
+	Body api.Door43MetadataValidation `json:"body"`
+}
+
+/*** END DCS Customizations ***/
@@ -0,0 +1,23 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package swagger
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// RepoScrub
+// swagger:response RepoScrub
+type swaggerRepoScrub struct {
+	// in:body
+	Body api.RepoScrub `json:"body"`
+}
+
+// RepoScrubList
+// swagger:response RepoScrubList
+type swaggerRepoScrubList struct {
+	// in:body
+	Body []api.RepoScrub `json:"body"`
+}
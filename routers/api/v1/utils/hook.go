@@ -129,6 +129,7 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 				PullRequestSync:      pullHook(form.Events, string(models.HookEventPullRequestSync)),
 				Repository:           util.IsStringInSlice(string(models.HookEventRepository), form.Events, true),
 				Release:              util.IsStringInSlice(string(models.HookEventRelease), form.Events, true),
+				Catalog:              util.IsStringInSlice(string(models.HookEventCatalog), form.Events, true), // DCS Customizations
 			},
 			BranchFilter: form.BranchFilter,
 		},
@@ -257,6 +258,7 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	w.PullRequest = util.IsStringInSlice(string(models.HookEventPullRequest), form.Events, true)
 	w.Repository = util.IsStringInSlice(string(models.HookEventRepository), form.Events, true)
 	w.Release = util.IsStringInSlice(string(models.HookEventRelease), form.Events, true)
+	w.Catalog = util.IsStringInSlice(string(models.HookEventCatalog), form.Events, true) // DCS Customizations
 	w.BranchFilter = form.BranchFilter
 
 	if err := w.UpdateEvent(); err != nil {
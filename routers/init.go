@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/cron"
+	"code.gitea.io/gitea/modules/door43metadata" // DCS Customizations
 	"code.gitea.io/gitea/modules/eventsource"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
@@ -99,6 +100,12 @@ func GlobalInit(ctx context.Context) {
 		log.Fatal("ORM engine initialization failed: %v", err)
 	}
 
+	/*** DCS Customizations ***/
+	if err := door43metadata.Init(); err != nil {
+		log.Fatal("Failed to initialize Door43 metadata: %v", err)
+	}
+	/*** END DCS Customizations ***/
+
 	if err := models.InitOAuth2(); err != nil {
 		log.Fatal("Failed to initialize OAuth2 support: %v", err)
 	}
@@ -117,6 +124,11 @@ func GlobalInit(ctx context.Context) {
 	if err := pull_service.Init(); err != nil {
 		log.Fatal("Failed to initialize test pull requests queue: %v", err)
 	}
+	/*** DCS Customizations ***/
+	if err := door43metadata.InitQueue(); err != nil {
+		log.Fatal("Failed to initialize Door43 metadata queue: %v", err)
+	}
+	/*** END DCS Customizations ***/
 	if err := task.Init(); err != nil {
 		log.Fatal("Failed to initialize task scheduler: %v", err)
 	}
@@ -117,6 +117,31 @@ func isErrUnverifiedCommit(err error) bool {
 	return ok
 }
 
+/*** DCS Customizations ***/
+
+// checkPushedMetadata validates the metadata files of the pushed commit of a ref, writing the response and returning
+// false if they are invalid or can't be checked
+func checkPushedMetadata(ctx *gitea_context.PrivateContext, repo *models.Repository, commitID string, env []string, ref string) bool {
+	message, err := door43metadata.ValidatePushedMetadata(repo.RepoPath(), commitID, env)
+	if err != nil {
+		log.Error("Unable to validate metadata of %s for %s in %-v: %v", commitID, ref, repo, err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: fmt.Sprintf("Unable to validate metadata of %s: %v", commitID, err),
+		})
+		return false
+	}
+	if message != "" {
+		log.Warn("Forbidden: %s in %-v is protected from invalid metadata in %s", ref, repo, commitID)
+		ctx.JSON(http.StatusForbidden, private.Response{
+			Err: fmt.Sprintf("%s is protected from invalid metadata, %s", ref, message),
+		})
+		return false
+	}
+	return true
+}
+
+/*** END DCS Customizations ***/
+
 // HookPreReceive checks whether a individual commit is acceptable
 func HookPreReceive(ctx *gitea_context.PrivateContext) {
 	opts := web.GetForm(ctx).(*private.HookOptions)
@@ -165,6 +190,17 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 		return
 	}
 
+	/*** DCS Customizations ***/
+	validateMetadata, err := repo.IsMetadataValidatedOnPush()
+	if err != nil {
+		log.Error("Unable to check if metadata is validated on push for %-v Error: %v", repo, err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: err.Error(),
+		})
+		return
+	}
+	/*** END DCS Customizations ***/
+
 	// Iterate across the provided old commit IDs
 	for i := range opts.OldCommitIDs {
 		oldCommitID := opts.OldCommitIDs[i]
@@ -247,6 +283,13 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 				}
 			}
 
+			/*** DCS Customizations ***/
+			// 3a. Enforce valid metadata if the repo or its org requires it
+			if validateMetadata && !checkPushedMetadata(ctx, repo, newCommitID, env, "branch "+branchName) {
+				return
+			}
+			/*** END DCS Customizations ***/
+
 			// Now there are several tests which can be overridden:
 			//
 			// 4. Check protected file patterns - this is overridable from the UI
@@ -393,6 +436,13 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 				})
 				return
 			}
+
+			/*** DCS Customizations ***/
+			// Tags are what releases are made from, so they must have valid metadata if the repo or its org requires it
+			if validateMetadata && newCommitID != git.EmptySHA && !checkPushedMetadata(ctx, repo, newCommitID, env, "tag "+tagName) {
+				return
+			}
+			/*** END DCS Customizations ***/
 		} else {
 			log.Error("Unexpected ref: %s", refFullName)
 			ctx.JSON(http.StatusInternalServerError, private.Response{
@@ -622,7 +672,8 @@ func SetDefaultBranch(ctx *gitea_context.PrivateContext) {
 	}
 
 	/*** DCS Customizations ***/
-	if err := door43metadata.ProcessDoor43MetadataForRepoRelease(repo, nil); err != nil {
+	// The other catalog branches of the repo may include the old or the new default branch
+	if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
 		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"Err": fmt.Sprintf("Unable to process default branch on repository: %s/%s Error: %v", ownerName, repoName, err),
 		})
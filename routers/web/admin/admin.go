@@ -19,6 +19,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/cron"
+	"code.gitea.io/gitea/modules/dcs" // DCS Customizations
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
@@ -316,6 +317,10 @@ func Config(ctx *context.Context) {
 	ctx.Data["EnableXORMLog"] = setting.EnableXORMLog
 	ctx.Data["LogSQL"] = setting.Database.LogSQL
 
+	/*** DCS Customizations ***/
+	ctx.Data["DCSRegistries"] = dcs.GetRegistryStatuses()
+	/*** END DCS Customizations ***/
+
 	ctx.HTML(http.StatusOK, tplConfig)
 }
 
@@ -0,0 +1,286 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplSubjects     base.TplName = "admin/dcs/subjects"
+	tplSubjectEdit  base.TplName = "admin/dcs/subject_edit"
+	tplLanguages    base.TplName = "admin/dcs/languages"
+	tplLanguageEdit base.TplName = "admin/dcs/language_edit"
+)
+
+// Subjects shows the subjects of the subject registry
+func Subjects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.subjects")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminSubjects"] = true
+
+	subjects, err := models.GetDCSSubjects()
+	if err != nil {
+		ctx.ServerError("GetDCSSubjects", err)
+		return
+	}
+	ctx.Data["Subjects"] = subjects
+	ctx.Data["Total"] = len(subjects)
+
+	ctx.HTML(http.StatusOK, tplSubjects)
+}
+
+// prepareSubjectEdit sets the data of the subject form, with the subjects that are not aliases for it to be an
+// alias of
+func prepareSubjectEdit(ctx *context.Context, title string) {
+	ctx.Data["Title"] = title
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminSubjects"] = true
+
+	subjects, err := models.GetDCSSubjects()
+	if err != nil {
+		ctx.ServerError("GetDCSSubjects", err)
+		return
+	}
+	targets := make([]*models.DCSSubject, 0, len(subjects))
+	for _, s := range subjects {
+		if s.AliasOf == "" && s.ResourceID != ctx.Params(":subject") {
+			targets = append(targets, s)
+		}
+	}
+	ctx.Data["AliasTargets"] = targets
+}
+
+// renderSubjectErr renders the subject form with the error of adding or updating the subject, or a server error
+func renderSubjectErr(ctx *context.Context, err error, form *forms.AdminSubjectForm) {
+	switch {
+	case models.IsErrDCSSubjectAlreadyExist(err):
+		ctx.Data["Err_ResourceID"] = true
+		ctx.RenderWithErr(ctx.Tr("admin.subjects.already_exist"), tplSubjectEdit, form)
+	case models.IsErrDCSSubjectInvalid(err):
+		ctx.RenderWithErr(ctx.Tr("admin.subjects.invalid", err.(models.ErrDCSSubjectInvalid).Reason), tplSubjectEdit, form)
+	default:
+		ctx.ServerError("DCSSubject", err)
+	}
+}
+
+// NewSubject shows the form to add a subject
+func NewSubject(ctx *context.Context) {
+	prepareSubjectEdit(ctx, ctx.Tr("admin.subjects.new"))
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["PageIsNewSubject"] = true
+	ctx.HTML(http.StatusOK, tplSubjectEdit)
+}
+
+// NewSubjectPost adds a subject
+func NewSubjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AdminSubjectForm)
+	prepareSubjectEdit(ctx, ctx.Tr("admin.subjects.new"))
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["PageIsNewSubject"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplSubjectEdit)
+		return
+	}
+
+	s := &models.DCSSubject{
+		ResourceID: form.ResourceID,
+		Title:      form.Title,
+		AliasOf:    form.AliasOf,
+	}
+	if err := models.CreateDCSSubject(s); err != nil {
+		renderSubjectErr(ctx, err, form)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.subjects.new_success", s.ResourceID))
+	ctx.Redirect(setting.AppSubURL + "/admin/subjects")
+}
+
+// getSubject gets the subject of the subject path parameter, rendering the error if it fails
+func getSubject(ctx *context.Context) *models.DCSSubject {
+	s, err := models.GetDCSSubjectByResourceID(ctx.Params(":subject"))
+	if err != nil {
+		if models.IsErrDCSSubjectNotExist(err) {
+			ctx.NotFound("GetDCSSubjectByResourceID", err)
+		} else {
+			ctx.ServerError("GetDCSSubjectByResourceID", err)
+		}
+		return nil
+	}
+	return s
+}
+
+// EditSubject shows the form to edit a subject
+func EditSubject(ctx *context.Context) {
+	prepareSubjectEdit(ctx, ctx.Tr("admin.subjects.edit"))
+	if ctx.Written() {
+		return
+	}
+	s := getSubject(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["Subject"] = s
+	ctx.Data["resource_id"] = s.ResourceID
+	ctx.Data["title"] = s.Title
+	ctx.Data["alias_of"] = s.AliasOf
+	ctx.Data["is_deprecated"] = s.IsDeprecated
+	ctx.HTML(http.StatusOK, tplSubjectEdit)
+}
+
+// EditSubjectPost updates a subject
+func EditSubjectPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AdminSubjectForm)
+	prepareSubjectEdit(ctx, ctx.Tr("admin.subjects.edit"))
+	if ctx.Written() {
+		return
+	}
+	s := getSubject(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["Subject"] = s
+	// The resource ID can not be changed so it is not posted
+	form.ResourceID = s.ResourceID
+	ctx.Data["resource_id"] = s.ResourceID
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplSubjectEdit)
+		return
+	}
+
+	s.Title = form.Title
+	s.AliasOf = form.AliasOf
+	s.IsDeprecated = form.IsDeprecated
+	if err := models.UpdateDCSSubject(s); err != nil {
+		renderSubjectErr(ctx, err, form)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.subjects.update_success", s.ResourceID))
+	ctx.Redirect(setting.AppSubURL + "/admin/subjects")
+}
+
+// Languages shows the languages of the language registry
+func Languages(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.languages")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminLanguages"] = true
+
+	opts := &models.SearchDCSLanguagesOptions{
+		ListOptions: models.ListOptions{
+			PageSize: setting.UI.Admin.UserPagingNum,
+			Page:     ctx.QueryInt("page"),
+		},
+		Keyword:      ctx.QueryTrim("q"),
+		IsGateway:    ctx.QueryBool("gateway"),
+		IsOverridden: ctx.QueryBool("overridden"),
+	}
+	if opts.Page <= 1 {
+		opts.Page = 1
+	}
+
+	languages, count, err := models.SearchDCSLanguages(opts)
+	if err != nil {
+		ctx.ServerError("SearchDCSLanguages", err)
+		return
+	}
+	ctx.Data["Keyword"] = opts.Keyword
+	ctx.Data["IsGateway"] = opts.IsGateway
+	ctx.Data["IsOverridden"] = opts.IsOverridden
+	ctx.Data["Total"] = count
+	ctx.Data["Languages"] = languages
+
+	pager := context.NewPagination(int(count), opts.PageSize, opts.Page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "gateway", "IsGateway")
+	pager.AddParam(ctx, "overridden", "IsOverridden")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplLanguages)
+}
+
+// getLanguage gets the language of the lang path parameter for the language form, rendering the error if it fails
+func getLanguage(ctx *context.Context) *models.DCSLanguage {
+	ctx.Data["Title"] = ctx.Tr("admin.languages.edit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminLanguages"] = true
+
+	l, err := models.GetDCSLanguageByCode(ctx.Params(":lang"))
+	if err != nil {
+		if models.IsErrDCSLanguageNotExist(err) {
+			ctx.NotFound("GetDCSLanguageByCode", err)
+		} else {
+			ctx.ServerError("GetDCSLanguageByCode", err)
+		}
+		return nil
+	}
+	ctx.Data["Language"] = l
+	return l
+}
+
+// EditLanguage shows the form to override a language
+func EditLanguage(ctx *context.Context) {
+	getLanguage(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplLanguageEdit)
+}
+
+// EditLanguagePost overrides a language
+func EditLanguagePost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AdminLanguageForm)
+	l := getLanguage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplLanguageEdit)
+		return
+	}
+
+	l.Name = form.Name
+	l.AnglicizedName = form.AnglicizedName
+	l.Direction = form.Direction
+	l.IsGateway = form.IsGateway
+	if err := models.OverrideDCSLanguage(l); err != nil {
+		ctx.ServerError("OverrideDCSLanguage", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.languages.update_success", l.Code))
+	ctx.Redirect(setting.AppSubURL + "/admin/languages/" + l.Code)
+}
+
+// ResetLanguage drops the override of a language
+func ResetLanguage(ctx *context.Context) {
+	l := getLanguage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.ResetDCSLanguage(l); err != nil {
+		ctx.ServerError("ResetDCSLanguage", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.languages.reset_success", l.Code))
+	ctx.Redirect(setting.AppSubURL + "/admin/languages/" + l.Code)
+}
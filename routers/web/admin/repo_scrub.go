@@ -0,0 +1,178 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/scrubber"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplScrubs    base.TplName = "admin/dcs/scrubs"
+	tplScrubNew  base.TplName = "admin/dcs/scrub_new"
+	tplScrubView base.TplName = "admin/dcs/scrub"
+)
+
+// Scrubs shows the audit records of the scrubs of every repo
+func Scrubs(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.scrubs")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminScrubs"] = true
+
+	opts := &models.FindRepoScrubsOptions{
+		ListOptions: models.ListOptions{
+			PageSize: setting.UI.Admin.RepoPagingNum,
+			Page:     ctx.QueryInt("page"),
+		},
+	}
+	if opts.Page <= 1 {
+		opts.Page = 1
+	}
+
+	scrubs, count, err := models.FindRepoScrubs(opts)
+	if err != nil {
+		ctx.ServerError("FindRepoScrubs", err)
+		return
+	}
+	ctx.Data["Scrubs"] = scrubs
+	ctx.Data["Total"] = count
+
+	pager := context.NewPagination(int(count), opts.PageSize, opts.Page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplScrubs)
+}
+
+// prepareNewScrub sets the data of the scrub form
+func prepareNewScrub(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.scrubs.new")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminScrubs"] = true
+}
+
+// NewScrub shows the form to scrub a repo, with the default rules
+func NewScrub(ctx *context.Context) {
+	prepareNewScrub(ctx)
+
+	rules, err := scrubber.GetDefaultRules()
+	if err != nil {
+		ctx.ServerError("GetDefaultRules", err)
+		return
+	}
+	rulesJSON, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		ctx.ServerError("MarshalIndent", err)
+		return
+	}
+	ctx.Data["repo_name"] = ctx.Query("repo")
+	ctx.Data["rules"] = string(rulesJSON)
+	ctx.Data["dry_run"] = true
+	ctx.HTML(http.StatusOK, tplScrubNew)
+}
+
+// NewScrubPost scrubs a repo, or previews it with a dry run, showing the audit record of the scrub
+func NewScrubPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.AdminScrubForm)
+	prepareNewScrub(ctx)
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplScrubNew)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(form.RepoName), "/", 2)
+	if len(parts) != 2 {
+		parts = append(parts, "")
+	}
+	repo, err := models.GetRepositoryByOwnerAndName(parts[0], parts[1])
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Data["Err_RepoName"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.scrubs.repo_not_exist"), tplScrubNew, form)
+		} else {
+			ctx.ServerError("GetRepositoryByOwnerAndName", err)
+		}
+		return
+	}
+	if repo.IsMirror {
+		ctx.Data["Err_RepoName"] = true
+		ctx.RenderWithErr(ctx.Tr("admin.scrubs.mirror"), tplScrubNew, form)
+		return
+	}
+
+	rules, err := scrubber.ParseRules([]byte(form.Rules))
+	if err != nil {
+		ctx.Data["Err_Rules"] = true
+		ctx.RenderWithErr(ctx.Tr("admin.scrubs.invalid_rules", err), tplScrubNew, form)
+		return
+	}
+
+	scrub, err := scrubber.ScrubRepository(repo, ctx.User, scrubber.ScrubOptions{
+		Rules:         rules,
+		IdentityName:  form.IdentityName,
+		IdentityEmail: form.IdentityEmail,
+		DryRun:        form.DryRun,
+	})
+	if err != nil {
+		switch {
+		case scrubber.IsErrInvalidRule(err):
+			ctx.Data["Err_Rules"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.scrubs.invalid_rules", err), tplScrubNew, form)
+			return
+		case scrubber.IsErrRepoChanged(err):
+			ctx.Flash.Error(ctx.Tr("admin.scrubs.repo_changed", repo.FullName()))
+		case scrub == nil:
+			ctx.ServerError("ScrubRepository", err)
+			return
+		default:
+			ctx.Flash.Error(ctx.Tr("admin.scrubs.failed", repo.FullName()))
+		}
+	} else if form.DryRun {
+		ctx.Flash.Success(ctx.Tr("admin.scrubs.dry_run_success", repo.FullName()))
+	} else {
+		ctx.Flash.Success(ctx.Tr("admin.scrubs.success", repo.FullName()))
+	}
+	if scrub == nil || scrub.ID == 0 {
+		ctx.Redirect(setting.AppSubURL + "/admin/scrubs")
+		return
+	}
+	ctx.Redirect(fmt.Sprintf("%s/admin/scrubs/%d", setting.AppSubURL, scrub.ID))
+}
+
+// ViewScrub shows the audit record of a scrub, with the refs, commits and files it scrubbed
+func ViewScrub(ctx *context.Context) {
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminScrubs"] = true
+
+	s, err := models.GetRepoScrubByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrRepoScrubNotExist(err) {
+			ctx.NotFound("GetRepoScrubByID", err)
+		} else {
+			ctx.ServerError("GetRepoScrubByID", err)
+		}
+		return
+	}
+	ctx.Data["Title"] = ctx.Tr("admin.scrubs.view", s.RepoFullName)
+	ctx.Data["RepoScrub"] = s
+	scrub := convert.ToRepoScrub(s, ctx.User)
+	ctx.Data["Scrub"] = scrub
+	if rulesJSON, err := json.MarshalIndent(scrub.Rules, "", "  "); err == nil {
+		ctx.Data["Rules"] = string(rulesJSON)
+	}
+	ctx.HTML(http.StatusOK, tplScrubView)
+}
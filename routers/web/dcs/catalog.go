@@ -79,7 +79,7 @@ func RenderCatalogSearch(ctx *context.Context, opts *CatalogSearchOptions) {
 		orderBy = models.CatalogOrderByNewest
 	}
 
-	var keywords, books, langs, subjects, repos, owners, tags, checkingLevels []string
+	var keywords, books, langs, subjects, repos, owners, tags, checkingLevels, metadataTypes []string
 	stage := models.StageProd
 	query := strings.Trim(ctx.Query("q"), " ")
 	if query != "" {
@@ -98,6 +98,8 @@ func RenderCatalogSearch(ctx *context.Context, opts *CatalogSearchOptions) {
 				tags = append(tags, strings.TrimPrefix(token, "tag:"))
 			} else if strings.HasPrefix(token, "checkinglevel:") {
 				checkingLevels = append(checkingLevels, strings.TrimPrefix(token, "checkinglevel:"))
+			} else if strings.HasPrefix(token, "metadatatype:") {
+				metadataTypes = append(metadataTypes, strings.TrimPrefix(token, "metadatatype:"))
 			} else if strings.HasPrefix(token, "stage:") {
 				if s, ok := models.StageMap[strings.Trim(strings.TrimPrefix(token, "stage:"), `"`)]; ok {
 					stage = s
@@ -127,6 +129,8 @@ func RenderCatalogSearch(ctx *context.Context, opts *CatalogSearchOptions) {
 		Owners:          owners,
 		Tags:            tags,
 		CheckingLevels:  checkingLevels,
+		MetadataTypes:   metadataTypes,
+		Actor:           ctx.User,
 	})
 	if err != nil {
 		ctx.ServerError("SearchCatalog", err)
@@ -0,0 +1,40 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*** DCS Customizations - Router for rc:// links ***/
+
+package dcs
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/door43metadata"
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	// tplRCNotFound page template of an rc:// link that can not be resolved.
+	tplRCNotFound base.TplName = "catalog/rc_not_found"
+)
+
+// RCLink redirects /rc/<language>/<resource>/<type>/<path> to the file the rc:// link links to in the catalog
+func RCLink(ctx *context.Context) {
+	link := "rc://" + ctx.Params("*")
+	dm, filePath, err := door43metadata.ResolveRCLink(link)
+	if err != nil {
+		if door43metadata.IsErrRCLinkUnresolvable(err) {
+			log.Debug("RCLink: %v", err)
+			ctx.Data["Title"] = ctx.Tr("rc.unresolvable_title")
+			ctx.Data["RCLink"] = link
+			ctx.Data["Reason"] = ctx.Tr("rc.unresolvable_" + string(err.(door43metadata.ErrRCLinkUnresolvable).Reason))
+			ctx.HTML(http.StatusNotFound, tplRCNotFound)
+			return
+		}
+		ctx.ServerError("ResolveRCLink", err)
+		return
+	}
+	ctx.Redirect(dm.GetFileURL(filePath))
+}
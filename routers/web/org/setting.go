@@ -36,6 +36,7 @@ func Settings(ctx *context.Context) {
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["CurrentVisibility"] = ctx.Org.Organization.Visibility
 	ctx.Data["RepoAdminChangeTeamAccess"] = ctx.Org.Organization.RepoAdminChangeTeamAccess
+	ctx.Data["ValidateMetadataOnPush"] = ctx.Org.Organization.ValidateMetadataOnPush // DCS Customizations
 	ctx.HTML(http.StatusOK, tplSettingsOptions)
 }
 
@@ -92,6 +93,7 @@ func SettingsPost(ctx *context.Context) {
 	org.Website = form.Website
 	org.Location = form.Location
 	org.RepoAdminChangeTeamAccess = form.RepoAdminChangeTeamAccess
+	org.ValidateMetadataOnPush = form.ValidateMetadataOnPush // DCS Customizations
 
 	visibilityChanged := form.Visibility != org.Visibility
 	org.Visibility = form.Visibility
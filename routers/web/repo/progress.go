@@ -0,0 +1,55 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*** DCS Customizations - Router for the progress of the books of a Bible translation ***/
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+)
+
+const (
+	tplProgress base.TplName = "repo/progress"
+)
+
+// Progress renders the statistics of the books of the default branch, or of the release of the ref query
+func Progress(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.progress")
+	ctx.Data["PageIsProgress"] = true
+
+	refs, err := models.GetDoor43MetadataBookStatsRefs(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetDoor43MetadataBookStatsRefs", err)
+		return
+	}
+	ref := ctx.Query("ref")
+	if ref == "" {
+		ref = ctx.Repo.Repository.DefaultBranch
+		if len(refs) > 0 {
+			ref = refs[0]
+		}
+	}
+
+	stats, err := models.GetDoor43MetadataBookStats(ctx.Repo.Repository.ID, ref)
+	if err != nil {
+		ctx.ServerError("GetDoor43MetadataBookStats", err)
+		return
+	}
+
+	ctx.Data["ProgressRefs"] = refs
+	ctx.Data["ProgressRef"] = ref
+	ctx.Data["BookStats"] = stats
+	if len(stats) > 0 {
+		ctx.Data["BookStatsTotal"] = stats.Total()
+		ctx.Data["BookStatsCommitSHA"] = stats[0].CommitSHA
+	}
+	ctx.HTML(http.StatusOK, tplProgress)
+}
+
+/*** END DCS Customizations ***/
@@ -127,6 +127,10 @@ func releasesOrTags(ctx *context.Context, isTagList bool) {
 		cacheUsers[ctx.User.ID] = ctx.User
 	}
 	var ok bool
+	/*** DCS Customizations ***/
+	metadataValidations := make(map[int64]*models.Door43MetadataValidation)
+	metadataRelationProblems := make(map[int64][]*models.ResolvedDoor43MetadataRelation)
+	/*** END DCS Customizations ***/
 
 	for _, r := range releases {
 		if r.Publisher, ok = cacheUsers[r.PublisherID]; !ok {
@@ -158,6 +162,24 @@ func releasesOrTags(ctx *context.Context, isTagList bool) {
 				ctx.ServerError("GetDoor43Metadata", err)
 				return
 			}
+			validation, err := models.GetDoor43MetadataValidation(r.RepoID, models.ValidationRefTypeTag, r.TagName)
+			if err != nil && !models.IsErrDoor43MetadataValidationNotExist(err) {
+				ctx.ServerError("GetDoor43MetadataValidation", err)
+				return
+			}
+			if validation != nil {
+				metadataValidations[r.ID] = validation
+			}
+			if r.Door43Metadata != nil {
+				problems, err := getMetadataRelationProblems(r.Door43Metadata)
+				if err != nil {
+					ctx.ServerError("getMetadataRelationProblems", err)
+					return
+				}
+				if len(problems) > 0 {
+					metadataRelationProblems[r.ID] = problems
+				}
+			}
 		}
 		/*** END DCS Customizations ***/
 
@@ -173,6 +195,10 @@ func releasesOrTags(ctx *context.Context, isTagList bool) {
 
 	ctx.Data["Releases"] = releases
 	ctx.Data["ReleasesNum"] = len(releases)
+	/*** DCS Customizations ***/
+	ctx.Data["MetadataValidations"] = metadataValidations
+	ctx.Data["MetadataRelationProblems"] = metadataRelationProblems
+	/*** END DCS Customizations ***/
 
 	pager := context.NewPagination(int(count), opts.PageSize, opts.Page, 5)
 	pager.SetDefaultParams(ctx)
@@ -235,6 +261,33 @@ func SingleRelease(ctx *context.Context) {
 		return
 	}
 
+	/*** DCS Customizations ***/
+	metadataValidations := make(map[int64]*models.Door43MetadataValidation)
+	metadataRelationProblems := make(map[int64][]*models.ResolvedDoor43MetadataRelation)
+	if !release.IsTag {
+		validation, err := models.GetDoor43MetadataValidation(release.RepoID, models.ValidationRefTypeTag, release.TagName)
+		if err != nil && !models.IsErrDoor43MetadataValidationNotExist(err) {
+			ctx.ServerError("GetDoor43MetadataValidation", err)
+			return
+		}
+		if validation != nil {
+			metadataValidations[release.ID] = validation
+		}
+		if release.Door43Metadata != nil {
+			problems, err := getMetadataRelationProblems(release.Door43Metadata)
+			if err != nil {
+				ctx.ServerError("getMetadataRelationProblems", err)
+				return
+			}
+			if len(problems) > 0 {
+				metadataRelationProblems[release.ID] = problems
+			}
+		}
+	}
+	ctx.Data["MetadataValidations"] = metadataValidations
+	ctx.Data["MetadataRelationProblems"] = metadataRelationProblems
+	/*** END DCS Customizations ***/
+
 	ctx.Data["Releases"] = []*models.Release{release}
 	ctx.HTML(http.StatusOK, tplReleases)
 }
@@ -542,3 +595,22 @@ func deleteReleaseOrTag(ctx *context.Context, isDelTag bool) {
 		"redirect": ctx.Repo.RepoLink + "/releases",
 	})
 }
+
+/*** DCS Customizations ***/
+
+// getMetadataRelationProblems returns the relations of a catalog entry that do not resolve to a production release
+func getMetadataRelationProblems(dm *models.Door43Metadata) ([]*models.ResolvedDoor43MetadataRelation, error) {
+	relations, err := models.ResolveDoor43MetadataRelations(dm)
+	if err != nil {
+		return nil, err
+	}
+	problems := make([]*models.ResolvedDoor43MetadataRelation, 0, len(relations))
+	for _, relation := range relations {
+		if relation.Status != models.RelationResolved {
+			problems = append(problems, relation)
+		}
+	}
+	return problems, nil
+}
+
+/*** END DCS Customizations ***/
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/door43metadata"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
@@ -35,6 +36,8 @@ import (
 	"code.gitea.io/gitea/services/mailer"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
+
+	"github.com/gobwas/glob"
 )
 
 const (
@@ -468,6 +471,37 @@ func SettingsPost(ctx *context.Context) {
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 
+	/*** DCS Customizations ***/
+	case "metadata":
+		catalogBranches := strings.TrimSpace(form.CatalogBranches)
+		for _, expr := range strings.Split(catalogBranches, ";") {
+			if _, err := glob.Compile(strings.TrimSpace(expr), '/'); err != nil {
+				ctx.Flash.Error(ctx.Tr("repo.settings.catalog_branches_invalid", expr))
+				ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+				return
+			}
+		}
+		catalogBranchesChanged := repo.CatalogBranches != catalogBranches
+		if repo.ValidateMetadataOnPush != form.ValidateMetadataOnPush || catalogBranchesChanged {
+			repo.ValidateMetadataOnPush = form.ValidateMetadataOnPush
+			repo.CatalogBranches = catalogBranches
+			if err := models.UpdateRepository(repo, false); err != nil {
+				ctx.ServerError("UpdateRepository", err)
+				return
+			}
+		}
+		if catalogBranchesChanged {
+			if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+				ctx.ServerError("QueueDoor43MetadataForRepo", err)
+				return
+			}
+		}
+		log.Trace("Repository metadata settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+	/*** END DCS Customizations ***/
+
 	case "admin":
 		if !ctx.User.IsAdmin {
 			ctx.Error(http.StatusForbidden)
@@ -740,9 +774,10 @@ func SettingsPost(ctx *context.Context) {
 			}
 		}
 
-		if err := scrubber.ScrubSensitiveData(repo, ctx.User, scrubber.ScrubSensitiveDataOptions{
-			LastCommitID:  ctx.Repo.CommitID,
-			CommitMessage: ctx.Tr("repo.settings.scrub_commit_message")}); err != nil {
+		if _, err := scrubber.ScrubRepository(repo, ctx.User, scrubber.ScrubOptions{
+			IdentityName:  setting.DCS.ScrubIdentityName,
+			IdentityEmail: setting.DCS.ScrubIdentityEmail,
+		}); err != nil {
 			log.Error("%v", err)
 			ctx.Flash.Error(ctx.Tr("repo.settings.scrub_error"))
 		} else {
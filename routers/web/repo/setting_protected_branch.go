@@ -85,8 +85,9 @@ func ProtectedBranchPost(ctx *context.Context) {
 				return
 			}
 			/*** DCS Customizations ***/
-			if err := door43metadata.ProcessDoor43MetadataForRepoRelease(repo, nil); err != nil {
-				ctx.ServerError("ProcessDoor43MetadataForRepoRelease", err)
+			// The other catalog branches of the repo may include the old or the new default branch
+			if err := door43metadata.QueueDoor43MetadataForRepo(repo); err != nil {
+				ctx.ServerError("QueueDoor43MetadataForRepo", err)
 				return
 			}
 			/*** END DCS Customizations ***/
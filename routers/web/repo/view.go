@@ -530,7 +530,7 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 			err := markup.Render(&markup.RenderContext{
 				Filename:  blob.Name(),
 				URLPrefix: path.Dir(treeLink),
-				Metas:     ctx.Repo.Repository.ComposeDocumentMetas(),
+				Metas:     composeDocumentMetas(ctx, markupType), // DCS Customizations
 				GitRepo:   ctx.Repo.GitRepo,
 			}, rd, &result)
 			if err != nil {
@@ -601,7 +601,7 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 			err := markup.Render(&markup.RenderContext{
 				Filename:  blob.Name(),
 				URLPrefix: path.Dir(treeLink),
-				Metas:     ctx.Repo.Repository.ComposeDocumentMetas(),
+				Metas:     composeDocumentMetas(ctx, markupType), // DCS Customizations
 				GitRepo:   ctx.Repo.GitRepo,
 			}, rd, &result)
 			if err != nil {
@@ -845,3 +845,42 @@ func Forks(ctx *context.Context) {
 
 	ctx.HTML(http.StatusOK, tplForks)
 }
+
+/*** DCS Customizations ***/
+
+// composeDocumentMetas returns the metas to render a document of the repo with. USFM and TSV documents are rendered in
+// the direction and language of the door43 metadata of the branch or tag being viewed.
+func composeDocumentMetas(ctx *context.Context, markupType string) map[string]string {
+	metas := ctx.Repo.Repository.ComposeDocumentMetas()
+	if markupType != "usfm" && markupType != "tsv" {
+		return metas
+	}
+
+	var dm *models.Door43Metadata
+	var err error
+	if ctx.Repo.IsViewTag {
+		dm, err = models.GetDoor43MetadataByRepoIDAndTagName(ctx.Repo.Repository.ID, ctx.Repo.TagName)
+	} else if ctx.Repo.IsViewBranch && ctx.Repo.BranchName == ctx.Repo.Repository.DefaultBranch {
+		dm, err = ctx.Repo.Repository.GetDefaultBranchMetadata()
+	}
+	if err != nil {
+		if !models.IsErrDoor43MetadataNotExist(err) && !models.IsErrReleaseNotExist(err) {
+			log.Error("Unable to get the door43 metadata of %s: %v", ctx.Repo.Repository.FullName(), err)
+		}
+		return metas
+	}
+	if dm == nil {
+		return metas
+	}
+
+	// The repo's metas are cached, so they are copied rather than changed
+	documentMetas := make(map[string]string, len(metas)+2)
+	for k, v := range metas {
+		documentMetas[k] = v
+	}
+	documentMetas[markup.MetaLangDirection] = dm.LanguageDir
+	documentMetas[markup.MetaLang] = dm.Language
+	return documentMetas
+}
+
+/*** END DCS Customizations ***/
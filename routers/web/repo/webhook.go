@@ -177,6 +177,7 @@ func ParseHookEvent(form forms.WebhookForm) *models.HookEvent {
 			PullRequestReview:    form.PullRequestReview,
 			PullRequestSync:      form.PullRequestSync,
 			Repository:           form.Repository,
+			Catalog:              form.Catalog, // DCS Customizations
 		},
 		BranchFilter: form.BranchFilter,
 	}
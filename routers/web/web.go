@@ -411,6 +411,26 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("", admin.Organizations)
 		})
 
+		/*** DCS Customizations ***/
+		m.Group("/subjects", func() {
+			m.Get("", admin.Subjects)
+			m.Combo("/new").Get(admin.NewSubject).Post(bindIgnErr(forms.AdminSubjectForm{}), admin.NewSubjectPost)
+			m.Combo("/{subject}").Get(admin.EditSubject).Post(bindIgnErr(forms.AdminSubjectForm{}), admin.EditSubjectPost)
+		})
+
+		m.Group("/languages", func() {
+			m.Get("", admin.Languages)
+			m.Combo("/{lang}").Get(admin.EditLanguage).Post(bindIgnErr(forms.AdminLanguageForm{}), admin.EditLanguagePost)
+			m.Post("/{lang}/reset", admin.ResetLanguage)
+		})
+
+		m.Group("/scrubs", func() {
+			m.Get("", admin.Scrubs)
+			m.Combo("/new").Get(admin.NewScrub).Post(bindIgnErr(forms.AdminScrubForm{}), admin.NewScrubPost)
+			m.Get("/{id}", admin.ViewScrub)
+		})
+		/*** END DCS Customizations ***/
+
 		m.Group("/repos", func() {
 			m.Get("", admin.Repos)
 			m.Combo("/unadopted").Get(admin.UnadoptedRepos).Post(admin.AdoptOrDeleteRepository)
@@ -900,6 +920,10 @@ func RegisterRoutes(m *web.Route) {
 			m.Get("/{period}", repo.ActivityAuthors)
 		}, context.RepoRef(), repo.MustBeNotEmpty, context.RequireRepoReaderOr(models.UnitTypeCode))
 
+		/*** DCS Customizations ***/
+		m.Get("/progress", repo.MustBeNotEmpty, reqRepoCodeReader, repo.Progress)
+		/*** END DCS Customizations ***/
+
 		m.Group("/archive", func() {
 			m.Get("/*", repo.Download)
 			m.Post("/*", repo.InitiateDownload)
@@ -1045,5 +1069,6 @@ func RegisterRoutes(m *web.Route) {
 	m.Group("/catalog", func() {
 		m.Get("", dcs.Catalog)
 	}, ignSignIn)
+	m.Get("/rc/*", ignSignIn, dcs.RCLink)
 	/*** END DCS Customizations ***/
 }
@@ -6,11 +6,14 @@
 package archiver
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	"code.gitea.io/gitea/models"
@@ -32,6 +35,13 @@ type ArchiveRequest struct {
 	refName  string
 	Type     git.ArchiveType
 	CommitID string
+	/*** DCS Customizations ***/
+	// Paths are the only paths of the commit to archive, all of it if there are none
+	Paths []string
+	// Subset identifies the paths in the archive's storage path, empty if all of the commit is archived
+	Subset     string
+	subsetName string
+	/*** END DCS Customizations ***/
 }
 
 // SHA1 hashes will only go up to 40 characters, but SHA256 hashes will go all
@@ -87,9 +97,37 @@ func NewRequest(repoID int64, repo *git.Repository, uri string) (*ArchiveRequest
 	return r, nil
 }
 
+/*** DCS Customizations ***/
+
+// NewRequestOfPaths creates an archival request, based on the URI, of only the given paths of the commit, which must
+// all exist in it. The name of the subset of paths is added to the name of the archive.
+func NewRequestOfPaths(repoID int64, repo *git.Repository, uri, subsetName string, paths []string) (*ArchiveRequest, error) {
+	r, err := NewRequest(repoID, repo, uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return r, nil
+	}
+	r.Paths = make([]string, len(paths))
+	copy(r.Paths, paths)
+	sort.Strings(r.Paths)
+	hash := sha1.Sum([]byte(strings.Join(r.Paths, "\n")))
+	r.Subset = hex.EncodeToString(hash[:])
+	r.subsetName = subsetName
+	return r, nil
+}
+
+/*** END DCS Customizations ***/
+
 // GetArchiveName returns the name of the caller, based on the ref used by the
 // caller to create this request.
 func (aReq *ArchiveRequest) GetArchiveName() string {
+	/*** DCS Customizations ***/
+	if aReq.subsetName != "" {
+		return strings.ReplaceAll(aReq.refName+"-"+aReq.subsetName, "/", "-") + "." + aReq.Type.String()
+	}
+	/*** END DCS Customizations ***/
 	return strings.ReplaceAll(aReq.refName, "/", "-") + "." + aReq.Type.String()
 }
 
@@ -100,7 +138,7 @@ func doArchive(r *ArchiveRequest) (*models.RepoArchiver, error) {
 	}
 	defer commiter.Close()
 
-	archiver, err := models.GetRepoArchiver(ctx, r.RepoID, r.Type, r.CommitID)
+	archiver, err := models.GetRepoArchiverOfSubset(ctx, r.RepoID, r.Type, r.CommitID, r.Subset) // DCS Customizations
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +154,7 @@ func doArchive(r *ArchiveRequest) (*models.RepoArchiver, error) {
 			RepoID:   r.RepoID,
 			Type:     r.Type,
 			CommitID: r.CommitID,
+			Subset:   r.Subset, // DCS Customizations
 			Status:   models.RepoArchiverGenerating,
 		}
 		if err := models.AddRepoArchiver(ctx, archiver); err != nil {
@@ -165,12 +204,13 @@ func doArchive(r *ArchiveRequest) (*models.RepoArchiver, error) {
 			}
 		}()
 
-		err = gitRepo.CreateArchive(
+		err = gitRepo.CreateArchiveOfPaths( // DCS Customizations
 			graceful.GetManager().ShutdownContext(),
 			archiver.Type,
 			w,
 			setting.Repository.PrefixArchiveFiles,
 			archiver.CommitID,
+			r.Paths, // DCS Customizations
 		)
 		_ = w.CloseWithError(err)
 		done <- err
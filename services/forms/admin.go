@@ -71,3 +71,50 @@ func (f *AdminDashboardForm) Validate(req *http.Request, errs binding.Errors) bi
 	ctx := context.GetContext(req)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+/*** DCS Customizations ***/
+
+// AdminSubjectForm form for admin to add or edit a subject of the subject registry
+type AdminSubjectForm struct {
+	ResourceID   string `form:"resource_id" binding:"MaxSize(50)"`
+	Title        string `binding:"MaxSize(255)"`
+	AliasOf      string `binding:"MaxSize(50)"`
+	IsDeprecated bool
+}
+
+// Validate validates form fields
+func (f *AdminSubjectForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AdminLanguageForm form for admin to override a language of the language registry
+type AdminLanguageForm struct {
+	Name           string `binding:"MaxSize(255)"`
+	AnglicizedName string `binding:"MaxSize(255)"`
+	Direction      string `binding:"Required;In(ltr,rtl)"`
+	IsGateway      bool
+}
+
+// Validate validates form fields
+func (f *AdminLanguageForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AdminScrubForm form for admin to scrub the history of a repo
+type AdminScrubForm struct {
+	RepoName      string `binding:"Required;MaxSize(200)"`
+	Rules         string
+	IdentityName  string `binding:"MaxSize(255)"`
+	IdentityEmail string `binding:"MaxSize(255)"`
+	DryRun        bool
+}
+
+// Validate validates form fields
+func (f *AdminScrubForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+/*** END DCS Customizations ***/
@@ -46,6 +46,7 @@ type UpdateOrgSettingForm struct {
 	Visibility                structs.VisibleType
 	MaxRepoCreation           int
 	RepoAdminChangeTeamAccess bool
+	ValidateMetadataOnPush    bool // DCS Customizations
 }
 
 // Validate validates the fields
@@ -159,6 +159,12 @@ type RepoSettingForm struct {
 	// Signing Settings
 	TrustModel string
 
+	/*** DCS Customizations ***/
+	// Metadata Settings
+	ValidateMetadataOnPush bool
+	CatalogBranches        string
+	/*** END DCS Customizations ***/
+
 	// Admin settings
 	EnableHealthCheck bool
 }
@@ -234,6 +240,7 @@ type WebhookForm struct {
 	PullRequestReview    bool
 	PullRequestSync      bool
 	Repository           bool
+	Catalog              bool // DCS Customizations
 	Active               bool
 	BranchFilter         string `binding:"GlobPattern"`
 }
@@ -0,0 +1,210 @@
+// Copyright 2021 unfoldingWord. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package release
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/door43metadata"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// CreateBundleOptions are the options of creating a release bundle
+type CreateBundleOptions struct {
+	// Owner is the org of the repos
+	Owner     *models.User
+	Publisher *models.User
+	Name      string
+	// TagName is the tag of the release of every repo
+	TagName string
+	// Title and Note are those of the release of every repo, the title being the name of the bundle if empty
+	Title        string
+	Note         string
+	IsPrerelease bool
+	// Target is the branch to release in every repo, the default branch of each repo if empty
+	Target string
+	Repos  []*models.Repository
+}
+
+// ErrInvalidBundle represents an error that the repos of a bundle can't all be released as asked
+type ErrInvalidBundle struct {
+	// Problems are why each repo that can't be released can't be, prefixed by the full name of the repo
+	Problems []string
+}
+
+// IsErrInvalidBundle checks if an error is a ErrInvalidBundle.
+func IsErrInvalidBundle(err error) bool {
+	_, ok := err.(ErrInvalidBundle)
+	return ok
+}
+
+func (err ErrInvalidBundle) Error() string {
+	return fmt.Sprintf("the bundle can not be released:\n%s", strings.Join(err.Problems, "\n"))
+}
+
+// CreateBundle creates the release of the tag of every repo of a bundle, after checking that each repo can be released
+// and that its manifest would put the release into the catalog, and records the bundle. If a release fails to be
+// created, the releases created before it are deleted along with their tags, so either every repo is released or none.
+func CreateBundle(opts CreateBundleOptions) (*models.ReleaseBundle, error) {
+	if len(opts.Repos) == 0 {
+		return nil, ErrInvalidBundle{Problems: []string{"there are no repos to release"}}
+	}
+
+	// The validated commit of each repo is released, rather than its branch, in case it is pushed to in the meantime
+	commitIDs := make([]string, len(opts.Repos))
+	var problems []string
+	seen := make(map[int64]bool, len(opts.Repos))
+	for i, repo := range opts.Repos {
+		if seen[repo.ID] {
+			problems = append(problems, fmt.Sprintf("%s: the repo is listed more than once", repo.FullName()))
+			continue
+		}
+		seen[repo.ID] = true
+
+		problem, commitID, err := validateBundleRepo(opts, repo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", repo.FullName(), err)
+		}
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", repo.FullName(), problem))
+		}
+		commitIDs[i] = commitID
+	}
+	if len(problems) > 0 {
+		return nil, ErrInvalidBundle{Problems: problems}
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = opts.Name
+	}
+	releases := make([]*models.Release, 0, len(opts.Repos))
+	for i, repo := range opts.Repos {
+		rel := &models.Release{
+			RepoID:       repo.ID,
+			Repo:         repo,
+			PublisherID:  opts.Publisher.ID,
+			Publisher:    opts.Publisher,
+			TagName:      opts.TagName,
+			Target:       commitIDs[i],
+			Title:        title,
+			Note:         opts.Note,
+			IsPrerelease: opts.IsPrerelease,
+		}
+		if err := createBundleRelease(repo, rel); err != nil {
+			rollbackBundle(opts.Publisher, releases)
+			return nil, fmt.Errorf("%s: %v", repo.FullName(), err)
+		}
+		releases = append(releases, rel)
+	}
+
+	bundle := &models.ReleaseBundle{
+		OwnerID:      opts.Owner.ID,
+		Owner:        opts.Owner,
+		Name:         opts.Name,
+		TagName:      opts.TagName,
+		Title:        title,
+		Note:         opts.Note,
+		IsPrerelease: opts.IsPrerelease,
+		PublisherID:  opts.Publisher.ID,
+		Publisher:    opts.Publisher,
+	}
+	members := make([]*models.ReleaseBundleMember, len(releases))
+	for i, rel := range releases {
+		members[i] = &models.ReleaseBundleMember{
+			RepoID:       rel.RepoID,
+			Repo:         rel.Repo,
+			RepoFullName: rel.Repo.FullName(),
+			ReleaseID:    rel.ID,
+		}
+	}
+	if err := models.CreateReleaseBundle(bundle, members); err != nil {
+		rollbackBundle(opts.Publisher, releases)
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// validateBundleRepo returns why the repo can't be released as part of the bundle, or "" along with the ID of the
+// commit to release if it can be
+func validateBundleRepo(opts CreateBundleOptions, repo *models.Repository) (string, string, error) {
+	if repo.OwnerID != opts.Owner.ID {
+		return fmt.Sprintf("the repo is not owned by %s", opts.Owner.Name), "", nil
+	}
+	if repo.IsArchived || repo.IsMirror || repo.IsEmpty {
+		return "the repo is archived, a mirror or empty", "", nil
+	}
+
+	isExist, err := models.IsReleaseExist(repo.ID, opts.TagName)
+	if err != nil {
+		return "", "", err
+	}
+	if isExist {
+		return fmt.Sprintf("the tag %s already exists", opts.TagName), "", nil
+	}
+	protectedTags, err := repo.GetProtectedTags()
+	if err != nil {
+		return "", "", fmt.Errorf("GetProtectedTags: %v", err)
+	}
+	isAllowed, err := models.IsUserAllowedToControlTag(protectedTags, opts.TagName, opts.Publisher.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if !isAllowed {
+		return fmt.Sprintf("the tag %s is protected", opts.TagName), "", nil
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return "", "", err
+	}
+	defer gitRepo.Close()
+
+	if gitRepo.IsTagExist(opts.TagName) {
+		return fmt.Sprintf("the tag %s already exists", opts.TagName), "", nil
+	}
+	branch := opts.Target
+	if branch == "" {
+		branch = repo.DefaultBranch
+	}
+	if !gitRepo.IsBranchExist(branch) {
+		return fmt.Sprintf("the branch %s does not exist", branch), "", nil
+	}
+	commit, err := gitRepo.GetBranchCommit(branch)
+	if err != nil {
+		return "", "", fmt.Errorf("GetBranchCommit: %v", err)
+	}
+	message, err := door43metadata.ValidateCommitMetadata(commit)
+	if err != nil {
+		return "", "", err
+	}
+	if message != "" {
+		return fmt.Sprintf("the branch %s would not be put into the catalog:\n%s", branch, message), "", nil
+	}
+	return "", commit.ID.String(), nil
+}
+
+// createBundleRelease creates the release of a repo of a bundle
+func createBundleRelease(repo *models.Repository, rel *models.Release) error {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	return CreateRelease(gitRepo, rel, nil, "")
+}
+
+// rollbackBundle deletes the releases created for a bundle that failed, along with their tags
+func rollbackBundle(doer *models.User, releases []*models.Release) {
+	for _, rel := range releases {
+		if err := DeleteReleaseByID(rel.ID, doer, true); err != nil {
+			log.Error("Unable to delete the release %s of %s of a failed bundle: %v", rel.TagName, rel.Repo.FullName(), err)
+		}
+	}
+}
@@ -136,7 +136,7 @@ func CreateRelease(gitRepo *git.Repository, rel *models.Release, attachmentUUIDs
 			if err := rel.LoadAttributes(); err != nil {
 				return err
 			}
-			return door43metadata.ProcessDoor43MetadataForRepoRelease(rel.Repo, rel)
+			return door43metadata.QueueDoor43MetadataForRepoRelease(rel.Repo, rel)
 		}
 	}
 	/*** END DCS Customizations ***/
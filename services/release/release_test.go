@@ -355,3 +355,42 @@ func TestCreateNewTag(t *testing.T) {
 	assert.NoError(t, CreateNewTag(user, repo, "master", "v2.0",
 		"v2.0 is released \n\n BUGFIX: .... \n\n 123"))
 }
+
+func TestCreateBundle_Invalid(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	otherRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 3}).(*models.Repository)
+
+	_, err := CreateBundle(CreateBundleOptions{
+		Owner:     user,
+		Publisher: user,
+		Name:      "bundle",
+		TagName:   "v2.0",
+		Repos:     []*models.Repository{repo, repo, otherRepo},
+	})
+	if assert.True(t, IsErrInvalidBundle(err)) {
+		problems := err.(ErrInvalidBundle).Problems
+		if assert.Len(t, problems, 3) {
+			// repo1 has no manifest
+			assert.Contains(t, problems[0], "there is no metadata file")
+			assert.Contains(t, problems[1], "listed more than once")
+			assert.Contains(t, problems[2], "not owned by user2")
+		}
+	}
+
+	// No release was created
+	models.AssertNotExistsBean(t, &models.Release{RepoID: repo.ID, TagName: "v2.0"})
+
+	_, err = CreateBundle(CreateBundleOptions{
+		Owner:     user,
+		Publisher: user,
+		Name:      "bundle",
+		TagName:   "v1.1",
+		Repos:     []*models.Repository{repo},
+	})
+	if assert.True(t, IsErrInvalidBundle(err)) {
+		assert.Contains(t, err.Error(), "the tag v1.1 already exists")
+	}
+}
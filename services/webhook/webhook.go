@@ -140,6 +140,13 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		return nil
 	}
 
+	/*** DCS Customizations ***/
+	// The catalog event is for syncing apps, it has nothing to show in chat webhooks (e.g. slack, discord, etc.)
+	if event == models.HookEventCatalog && w.Type != models.GITEA && w.Type != models.GOGS {
+		return nil
+	}
+	/*** END DCS Customizations ***/
+
 	// If payload has no associated branch (e.g. it's a new tag, issue, etc.),
 	// branch filter has no effect.
 	if branch := getPayloadBranch(p); branch != "" {